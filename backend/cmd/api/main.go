@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	
@@ -11,6 +12,8 @@ import (
 	"ecommerce-backend/internal/database"
 	"ecommerce-backend/internal/handlers"
 	"ecommerce-backend/internal/middleware"
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
 	"ecommerce-backend/internal/repository"
 	"ecommerce-backend/internal/services"
 )
@@ -20,6 +23,7 @@ func main() {
 
 	// Load configuration
 	cfg := config.LoadConfig()
+	money.DefaultCurrency = cfg.BaseCurrency
 
 	// Connect to database
 	db := database.Connect(cfg.DatabaseURL)
@@ -31,7 +35,7 @@ func main() {
 	// Apply CORS middleware
 	router.Use(middleware.CORS(cfg.AllowedOrigins))
 
-	// Setup routes
+	// Setup routes and background jobs
 	setupRoutes(router, db, cfg)
 
 	// Start server
@@ -46,21 +50,84 @@ func setupRoutes(router *mux.Router, db *sql.DB, cfg *config.Config) {
 	productRepo := repository.NewProductRepository(db)
 	cartRepo := repository.NewCartRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	abandonedCartRepo := repository.NewAbandonedCartRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	saleRepo := repository.NewSaleRepository(db)
+	taxRepo := repository.NewTaxRepository(db)
+	shippingRepo := repository.NewShippingRepository(db)
+	currencyRepo := repository.NewCurrencyRepository(db)
+	giftCardRepo := repository.NewGiftCardRepository(db)
+	loyaltyRepo := repository.NewLoyaltyRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	productService := services.NewProductService(productRepo)
-	cartService := services.NewCartService(cartRepo, productRepo)
-	orderService := services.NewOrderService(orderRepo, cartRepo, productRepo)
+	mailer, err := services.NewSender(cfg.MailDriver, cfg.MailDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notificationService := services.NewNotificationService(notificationRepo, mailer)
+	loginThrottleService := services.NewLoginThrottleService(loginAttemptRepo, cfg.LoginMaxFailures, cfg.LoginMaxIPFailures, cfg.LoginLockout)
+	roleService := services.NewRoleService(roleRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo)
+	twoFactorService := services.NewTwoFactorService(userRepo, sessionRepo, loginThrottleService, cfg.TOTPIssuer)
+	oidcProviders := []*services.OIDCProvider{}
+	for _, p := range cfg.OIDCProviders {
+		oidcProviders = append(oidcProviders, services.NewOIDCProvider(services.OIDCProviderConfig(p)))
+	}
+	authService := services.NewAuthService(userRepo, sessionRepo, notificationService, loginThrottleService, twoFactorService, oidcProviders,
+		cfg.JWTSecret, cfg.FrontendURL, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.PreAuthTokenTTL, cfg.PasswordResetTTL, cfg.EmailVerificationTTL)
+	saleService := services.NewSaleService(saleRepo)
+	productService := services.NewProductService(productRepo, saleService)
+	couponService := services.NewCouponService(couponRepo)
+	promotionService := services.NewPromotionService(promotionRepo)
+	shippingService := services.NewShippingService(shippingRepo)
+	currencyService := services.NewCurrencyService(currencyRepo)
+	cartService := services.NewCartService(cartRepo, productRepo, couponService, promotionService, saleService, shippingService, cfg.JWTSecret)
+	abandonedCartService := services.NewAbandonedCartService(abandonedCartRepo, cartRepo, productRepo, cartService, saleService,
+		notificationService, cfg.JWTSecret, cfg.FrontendURL, cfg.AbandonedCartAfter)
+	taxService := services.NewTaxService(taxRepo, cfg.PricesIncludeTax)
+	giftCardService := services.NewGiftCardService(giftCardRepo, notificationService)
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo, cfg.LoyaltyPointsPerUnit, cfg.LoyaltyPointValue, cfg.LoyaltyExpireAfter)
+	orderService := services.NewOrderService(orderRepo, cartRepo, productRepo, couponService, promotionService, saleService, taxService, shippingService, currencyService, giftCardService, loyaltyService, abandonedCartService, cfg.JWTSecret)
+	catalogService := services.NewCatalogService(catalogRepo)
+	inventoryService := services.NewInventoryService(inventoryRepo)
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler()
-	authHandler := handlers.NewAuthHandler(authService)
-	productHandler := handlers.NewProductHandler(productService)
-	cartHandler := handlers.NewCartHandler(cartService)
-	orderHandler := handlers.NewOrderHandler(orderService)
+	authHandler := handlers.NewAuthHandler(authService, cartService, orderService)
+	productHandler := handlers.NewProductHandler(productService, currencyService)
+	cartHandler := handlers.NewCartHandler(cartService, currencyService)
+	orderHandler := handlers.NewOrderHandler(orderService, cartService)
 	adminHandler := handlers.NewAdminHandler(productService, orderService, userRepo)
-	paymentHandler := handlers.NewPaymentHandler(db)
+	paymentHandler := handlers.NewPaymentHandler(db, giftCardService)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	abandonedCartHandler := handlers.NewAbandonedCartHandler(abandonedCartService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	saleHandler := handlers.NewSaleHandler(saleService)
+	taxHandler := handlers.NewTaxHandler(taxService)
+	shippingHandler := handlers.NewShippingHandler(shippingService)
+	currencyHandler := handlers.NewCurrencyHandler(currencyService)
+	giftCardHandler := handlers.NewGiftCardHandler(giftCardService)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	// Background jobs
+	go notificationService.Run(time.Minute)
+	go abandonedCartService.Run(cfg.AbandonedCartCheckInterval)
+	go loyaltyService.Run(time.Hour)
+	go loginThrottleService.Run(time.Hour)
+	log.Printf("✓ Abandoned cart check every %s (idle after %s)", cfg.AbandonedCartCheckInterval, cfg.AbandonedCartAfter)
 
 	// Health check
 	router.HandleFunc("/health", healthHandler.Check).Methods("GET", "OPTIONS")
@@ -72,6 +139,16 @@ func setupRoutes(router *mux.Router, db *sql.DB, cfg *config.Config) {
 	api.HandleFunc("/health", healthHandler.Check).Methods("GET", "OPTIONS")
 	api.HandleFunc("/auth/register", authHandler.Register).Methods("POST", "OPTIONS")
 	api.HandleFunc("/auth/login", authHandler.Login).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/2fa/verify", authHandler.VerifyTwoFactor).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/forgot-password", authHandler.ForgotPassword).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/reset-password", authHandler.ResetPassword).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/verify-email", authHandler.VerifyEmail).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/providers", authHandler.GetOIDCProviders).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/oidc/{provider}/start", authHandler.StartOIDCLogin).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/{provider}/callback", authHandler.OIDCLogin).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/{provider}/form-post", authHandler.OIDCFormPost).Methods("POST")
 	
 	// Product routes (public)
 	api.HandleFunc("/products", productHandler.GetProducts).Methods("GET", "OPTIONS")
@@ -79,24 +156,50 @@ func setupRoutes(router *mux.Router, db *sql.DB, cfg *config.Config) {
 	api.HandleFunc("/brands", productHandler.GetBrands).Methods("GET", "OPTIONS")
 	api.HandleFunc("/categories", productHandler.GetCategories).Methods("GET", "OPTIONS")
 	api.HandleFunc("/search/suggestions", productHandler.SearchSuggestions).Methods("GET", "OPTIONS")
+	api.HandleFunc("/currencies", currencyHandler.GetCurrencies).Methods("GET", "OPTIONS")
+	api.HandleFunc("/gift-cards/{code}", giftCardHandler.GetBalance).Methods("GET", "OPTIONS")
+
+	// Guest checkout routes (public)
+	api.HandleFunc("/orders/guest", orderHandler.CreateGuestOrder).Methods("POST", "OPTIONS")
+	api.HandleFunc("/orders/lookup", orderHandler.LookupOrder).Methods("GET", "POST", "OPTIONS")
+
+	// Cart routes (guest or logged-in; guests are identified by X-Cart-Token)
+	cart := api.PathPrefix("/cart").Subrouter()
+	cart.Use(middleware.OptionalAuthMiddleware(cfg.JWTSecret, authService))
+	cart.HandleFunc("", cartHandler.GetCart).Methods("GET", "OPTIONS")
+	cart.HandleFunc("", cartHandler.AddToCart).Methods("POST", "OPTIONS")
+	cart.HandleFunc("/clear", cartHandler.ClearCart).Methods("DELETE", "OPTIONS")
+	cart.HandleFunc("/recover", abandonedCartHandler.RecoverCart).Methods("GET", "POST", "OPTIONS")
+	cart.HandleFunc("/coupon", cartHandler.ApplyCoupon).Methods("POST", "OPTIONS")
+	cart.HandleFunc("/coupon", cartHandler.RemoveCoupon).Methods("DELETE", "OPTIONS")
+	cart.HandleFunc("/shipping-methods", cartHandler.GetShippingQuotes).Methods("GET", "OPTIONS")
+	cart.HandleFunc("/accept-prices", cartHandler.AcceptAllPrices).Methods("POST", "OPTIONS")
+	cart.HandleFunc("/{id}/accept-price", cartHandler.AcceptPrice).Methods("POST", "OPTIONS")
+	cart.HandleFunc("/{id}/save-for-later", cartHandler.SaveForLater).Methods("POST", "OPTIONS")
+	cart.HandleFunc("/{id}/move-to-cart", cartHandler.MoveToCart).Methods("POST", "OPTIONS")
+	cart.HandleFunc("/{id}", cartHandler.UpdateCartItem).Methods("PUT", "OPTIONS")
+	cart.HandleFunc("/{id}", cartHandler.RemoveFromCart).Methods("DELETE", "OPTIONS")
 
 	// Protected routes (auth required)
 	protected := api.PathPrefix("").Subrouter()
-	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret, authService))
+
+	// Buying needs a verified email where the environment requires it
+	requireVerifiedEmail := middleware.VerifiedEmailMiddleware(authService, cfg.RequireEmailVerification)
 	
 	// Auth protected routes
 	protected.HandleFunc("/auth/me", authHandler.Me).Methods("GET", "OPTIONS")
-	
-	// Cart routes (protected)
-	protected.HandleFunc("/cart", cartHandler.GetCart).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/cart", cartHandler.AddToCart).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/cart/{id}", cartHandler.UpdateCartItem).Methods("PUT", "OPTIONS")
-	protected.HandleFunc("/cart/{id}", cartHandler.RemoveFromCart).Methods("DELETE", "OPTIONS")
-	protected.HandleFunc("/cart/clear", cartHandler.ClearCart).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/auth/resend-verification", authHandler.ResendVerification).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/2fa", twoFactorHandler.GetStatus).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/auth/2fa/setup", twoFactorHandler.Setup).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/2fa/enable", twoFactorHandler.Enable).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/2fa/disable", twoFactorHandler.Disable).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes).Methods("POST", "OPTIONS")
 	
 	// Order routes (protected)
-	protected.HandleFunc("/orders", orderHandler.CreateOrder).Methods("POST", "OPTIONS")
+	protected.Handle("/orders", requireVerifiedEmail(http.HandlerFunc(orderHandler.CreateOrder))).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/orders", orderHandler.GetOrders).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/orders/claim", orderHandler.ClaimOrders).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/orders/{id}", orderHandler.GetOrder).Methods("GET", "OPTIONS")
 	
 	// Payment routes (protected)
@@ -104,33 +207,139 @@ func setupRoutes(router *mux.Router, db *sql.DB, cfg *config.Config) {
 	protected.HandleFunc("/payment/confirm", paymentHandler.ConfirmPayment).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/payment/status", paymentHandler.GetPaymentStatus).Methods("GET", "OPTIONS")
 	
+	// Gift card and store credit routes (protected)
+	protected.Handle("/gift-cards", requireVerifiedEmail(http.HandlerFunc(giftCardHandler.PurchaseGiftCard))).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/gift-cards", giftCardHandler.GetMyGiftCards).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/store-credit", giftCardHandler.GetStoreCredit).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/loyalty", loyaltyHandler.GetLoyalty).Methods("GET", "OPTIONS")
+	
 	// Address routes (protected)
 	protected.HandleFunc("/addresses", orderHandler.GetAddresses).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/addresses", orderHandler.CreateAddress).Methods("POST", "OPTIONS")
 	
-	// Admin routes (protected + admin role check)
+	// Admin routes (protected or API key + per-route permission of the user's role or key's scopes)
 	admin := api.PathPrefix("/admin").Subrouter()
-	admin.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-	admin.Use(middleware.AdminMiddleware())
+	admin.Use(middleware.AuthOrAPIKeyMiddleware(cfg.JWTSecret, authService, apiKeyService))
+
+	// Each admin route needs a permission of the user's role, or a scope of the API key
+	can := func(permission string, handler http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(roleService, permission)(handler)
+	}
 	
-	admin.HandleFunc("/stats", adminHandler.GetStats).Methods("GET", "OPTIONS")
-	admin.HandleFunc("/orders", adminHandler.GetAllOrders).Methods("GET", "OPTIONS")
-	admin.HandleFunc("/orders/{id}/status", adminHandler.UpdateOrderStatus).Methods("PUT", "OPTIONS")
-	admin.HandleFunc("/products/{id}/toggle", adminHandler.ToggleProduct).Methods("PUT", "OPTIONS")
-	admin.HandleFunc("/customers", adminHandler.GetAllCustomers).Methods("GET", "OPTIONS")
+	admin.Handle("/stats", can(models.PermStatsRead, adminHandler.GetStats)).Methods("GET", "OPTIONS")
+	admin.Handle("/orders", can(models.PermOrdersRead, adminHandler.GetAllOrders)).Methods("GET", "OPTIONS")
+	admin.Handle("/orders/{id}", can(models.PermOrdersRead, adminHandler.GetOrder)).Methods("GET", "OPTIONS")
+	admin.Handle("/orders/{id}/status", can(models.PermOrdersWrite, adminHandler.UpdateOrderStatus)).Methods("PUT", "OPTIONS")
+	admin.Handle("/orders/{id}/refunds", can(models.PermOrdersRefund, adminHandler.RefundOrder)).Methods("POST", "OPTIONS")
+	admin.Handle("/products/{id}/toggle", can(models.PermCatalogWrite, adminHandler.ToggleProduct)).Methods("PUT", "OPTIONS")
+	admin.Handle("/customers", can(models.PermCustomersRead, adminHandler.GetAllCustomers)).Methods("GET", "OPTIONS")
+	admin.Handle("/catalog/export", can(models.PermCatalogRead, catalogHandler.ExportCatalog)).Methods("GET", "OPTIONS")
+	admin.Handle("/catalog/import", can(models.PermCatalogWrite, catalogHandler.ImportCatalog)).Methods("POST", "OPTIONS")
+	admin.Handle("/inventory/sync", can(models.PermCatalogWrite, inventoryHandler.SyncStock)).Methods("POST", "OPTIONS")
+	admin.Handle("/inventory/audit", can(models.PermCatalogRead, inventoryHandler.GetStockAudit)).Methods("GET", "OPTIONS")
+	admin.Handle("/coupons", can(models.PermMarketingRead, couponHandler.GetCoupons)).Methods("GET", "OPTIONS")
+	admin.Handle("/coupons", can(models.PermMarketingWrite, couponHandler.CreateCoupon)).Methods("POST", "OPTIONS")
+	admin.Handle("/coupons/{id}", can(models.PermMarketingRead, couponHandler.GetCoupon)).Methods("GET", "OPTIONS")
+	admin.Handle("/coupons/{id}", can(models.PermMarketingWrite, couponHandler.UpdateCoupon)).Methods("PUT", "OPTIONS")
+	admin.Handle("/coupons/{id}", can(models.PermMarketingWrite, couponHandler.DeleteCoupon)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/promotions", can(models.PermMarketingRead, promotionHandler.GetPromotions)).Methods("GET", "OPTIONS")
+	admin.Handle("/promotions", can(models.PermMarketingWrite, promotionHandler.CreatePromotion)).Methods("POST", "OPTIONS")
+	admin.Handle("/promotions/{id}", can(models.PermMarketingRead, promotionHandler.GetPromotion)).Methods("GET", "OPTIONS")
+	admin.Handle("/promotions/{id}", can(models.PermMarketingWrite, promotionHandler.UpdatePromotion)).Methods("PUT", "OPTIONS")
+	admin.Handle("/promotions/{id}", can(models.PermMarketingWrite, promotionHandler.DeletePromotion)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/sales", can(models.PermMarketingRead, saleHandler.GetSales)).Methods("GET", "OPTIONS")
+	admin.Handle("/sales", can(models.PermMarketingWrite, saleHandler.CreateSale)).Methods("POST", "OPTIONS")
+	admin.Handle("/sales/{id}", can(models.PermMarketingWrite, saleHandler.UpdateSale)).Methods("PUT", "OPTIONS")
+	admin.Handle("/sales/{id}", can(models.PermMarketingWrite, saleHandler.DeleteSale)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/abandoned-carts/stats", can(models.PermMarketingRead, abandonedCartHandler.GetStats)).Methods("GET", "OPTIONS")
+	admin.Handle("/tax-rates", can(models.PermSettingsRead, taxHandler.GetTaxRates)).Methods("GET", "OPTIONS")
+	admin.Handle("/tax-rates", can(models.PermSettingsWrite, taxHandler.CreateTaxRate)).Methods("POST", "OPTIONS")
+	admin.Handle("/tax-rates/{id}", can(models.PermSettingsWrite, taxHandler.UpdateTaxRate)).Methods("PUT", "OPTIONS")
+	admin.Handle("/tax-rates/{id}", can(models.PermSettingsWrite, taxHandler.DeleteTaxRate)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/shipping-methods", can(models.PermSettingsRead, shippingHandler.GetShippingMethods)).Methods("GET", "OPTIONS")
+	admin.Handle("/shipping-methods", can(models.PermSettingsWrite, shippingHandler.CreateShippingMethod)).Methods("POST", "OPTIONS")
+	admin.Handle("/shipping-methods/{id}", can(models.PermSettingsWrite, shippingHandler.UpdateShippingMethod)).Methods("PUT", "OPTIONS")
+	admin.Handle("/shipping-rates", can(models.PermSettingsWrite, shippingHandler.CreateShippingRate)).Methods("POST", "OPTIONS")
+	admin.Handle("/shipping-rates/{id}", can(models.PermSettingsWrite, shippingHandler.UpdateShippingRate)).Methods("PUT", "OPTIONS")
+	admin.Handle("/shipping-rates/{id}", can(models.PermSettingsWrite, shippingHandler.DeleteShippingRate)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/exchange-rates", can(models.PermSettingsRead, currencyHandler.GetCurrencies)).Methods("GET", "OPTIONS")
+	admin.Handle("/exchange-rates/{currency}", can(models.PermSettingsWrite, currencyHandler.SetExchangeRate)).Methods("PUT", "OPTIONS")
+	admin.Handle("/exchange-rates/{currency}", can(models.PermSettingsWrite, currencyHandler.DeleteExchangeRate)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/gift-cards", can(models.PermGiftCardsRead, giftCardHandler.GetGiftCards)).Methods("GET", "OPTIONS")
+	admin.Handle("/gift-cards", can(models.PermGiftCardsWrite, giftCardHandler.IssueGiftCard)).Methods("POST", "OPTIONS")
+	admin.Handle("/gift-cards/{id}", can(models.PermGiftCardsWrite, giftCardHandler.UpdateGiftCard)).Methods("PUT", "OPTIONS")
+	admin.Handle("/users/{id}/store-credit", can(models.PermCustomersWrite, giftCardHandler.AdjustStoreCredit)).Methods("POST", "OPTIONS")
+	admin.Handle("/users/{id}/loyalty", can(models.PermCustomersRead, loyaltyHandler.GetUserLoyalty)).Methods("GET", "OPTIONS")
+	admin.Handle("/users/{id}/loyalty", can(models.PermCustomersWrite, loyaltyHandler.AdjustLoyalty)).Methods("POST", "OPTIONS")
+	admin.Handle("/users/{id}/sessions", can(models.PermCustomersWrite, authHandler.RevokeUserSessions)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/login-failures", can(models.PermSecurityRead, authHandler.GetLoginFailures)).Methods("GET", "OPTIONS")
+	admin.Handle("/permissions", can(models.PermRolesRead, roleHandler.GetPermissions)).Methods("GET", "OPTIONS")
+	admin.Handle("/roles", can(models.PermRolesRead, roleHandler.GetRoles)).Methods("GET", "OPTIONS")
+	admin.Handle("/roles", can(models.PermRolesWrite, roleHandler.CreateRole)).Methods("POST", "OPTIONS")
+	admin.Handle("/roles/{id}", can(models.PermRolesWrite, roleHandler.UpdateRole)).Methods("PUT", "OPTIONS")
+	admin.Handle("/roles/{id}", can(models.PermRolesWrite, roleHandler.DeleteRole)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/users/{id}/role", can(models.PermRolesWrite, roleHandler.AssignUserRole)).Methods("PUT", "OPTIONS")
+	admin.Handle("/api-keys", can(models.PermAPIKeysRead, apiKeyHandler.GetAPIKeys)).Methods("GET", "OPTIONS")
+	admin.Handle("/api-keys", can(models.PermAPIKeysWrite, apiKeyHandler.CreateAPIKey)).Methods("POST", "OPTIONS")
+	admin.Handle("/api-keys/{id}", can(models.PermAPIKeysWrite, apiKeyHandler.RevokeAPIKey)).Methods("DELETE", "OPTIONS")
+	admin.Handle("/api-keys/{id}/requests", can(models.PermAPIKeysRead, apiKeyHandler.GetAPIKeyRequests)).Methods("GET", "OPTIONS")
 	
 	log.Println("✓ Routes configured")
 	log.Println("  POST /api/auth/register")
 	log.Println("  POST /api/auth/login")
+	log.Println("  POST /api/auth/2fa/verify (second login step)")
+	log.Println("  POST /api/auth/refresh")
+	log.Println("  POST /api/auth/logout")
+	log.Println("  POST /api/auth/forgot-password")
+	log.Println("  POST /api/auth/reset-password")
+	log.Println("  POST /api/auth/verify-email")
+	log.Println("  GET  /api/auth/oidc/providers")
+	log.Println("  POST /api/auth/oidc/{provider}/start|callback (social sign-in)")
+	log.Println("  POST /api/auth/oidc/{provider}/form-post (provider redirect for form_post)")
 	log.Println("  GET  /api/auth/me (protected)")
+	log.Println("  POST /api/auth/resend-verification (protected)")
+	log.Println("  GET  /api/auth/2fa (protected)")
+	log.Println("  POST /api/auth/2fa/setup|enable|disable|recovery-codes (protected)")
 	log.Println("  GET  /api/products")
 	log.Println("  GET  /api/products/{id}")
 	log.Println("  GET  /api/brands")
 	log.Println("  GET  /api/categories")
-	log.Println("  GET  /api/cart (protected)")
-	log.Println("  POST /api/cart (protected)")
-	log.Println("  PUT  /api/cart/{id} (protected)")
-	log.Println("  DELETE /api/cart/{id} (protected)")
+	log.Println("  GET  /api/currencies")
+	log.Println("  GET  /api/gift-cards/{code} (balance)")
+	log.Println("  POST /api/gift-cards (protected, buy a gift card)")
+	log.Println("  GET  /api/store-credit (protected)")
+	log.Println("  GET  /api/loyalty (protected)")
+	log.Println("  GET  /api/cart (guest or user)")
+	log.Println("  POST /api/cart (guest or user)")
+	log.Println("  PUT  /api/cart/{id} (guest or user)")
+	log.Println("  DELETE /api/cart/{id} (guest or user)")
+	log.Println("  POST /api/cart/coupon (guest or user)")
+	log.Println("  GET  /api/cart/shipping-methods?country=&city= (guest or user)")
+	log.Println("  POST /api/orders/guest (guest checkout)")
+	log.Println("  GET  /api/orders/lookup?token= (guest order link)")
+	log.Println("  POST /api/cart/recover (abandoned cart email link)")
 	log.Println("  GET  /api/admin/stats (admin)")
 	log.Println("  GET  /api/admin/orders (admin)")
+	log.Println("  GET  /api/admin/catalog/export (admin)")
+	log.Println("  POST /api/admin/catalog/import (admin)")
+	log.Println("  POST /api/admin/inventory/sync (admin)")
+	log.Println("  GET  /api/admin/coupons (admin)")
+	log.Println("  GET  /api/admin/promotions (admin)")
+	log.Println("  GET  /api/admin/sales (admin)")
+	log.Println("  GET  /api/admin/abandoned-carts/stats (admin)")
+	log.Println("  GET  /api/admin/tax-rates (admin)")
+	log.Println("  POST /api/admin/orders/{id}/refunds (admin)")
+	log.Println("  GET  /api/admin/shipping-methods (admin)")
+	log.Println("  PUT  /api/admin/exchange-rates/{currency} (admin)")
+	log.Println("  POST /api/admin/gift-cards (admin)")
+	log.Println("  POST /api/admin/users/{id}/store-credit (admin)")
+	log.Println("  POST /api/admin/users/{id}/loyalty (admin)")
+	log.Println("  DELETE /api/admin/users/{id}/sessions (admin)")
+	log.Println("  GET  /api/admin/login-failures?email=&ip= (admin)")
+	log.Println("  GET  /api/admin/permissions (admin)")
+	log.Println("  GET|POST /api/admin/roles, PUT|DELETE /api/admin/roles/{id} (admin)")
+	log.Println("  PUT  /api/admin/users/{id}/role (admin)")
+	log.Println("  GET|POST /api/admin/api-keys, DELETE /api/admin/api-keys/{id} (admin)")
+	log.Println("  GET  /api/admin/api-keys/{id}/requests (admin)")
+	log.Println("  Admin routes also accept \"Authorization: ApiKey <key>\" within the key's scopes")
 }
\ No newline at end of file
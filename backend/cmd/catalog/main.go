@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"ecommerce-backend/internal/database"
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+	"ecommerce-backend/internal/services"
+)
+
+// Catalog import/export and stock sync tool
+//
+//	go run ./cmd/catalog export -o catalog.csv
+//	go run ./cmd/catalog import -f catalog.csv -dry-run
+//	go run ./cmd/catalog sync -f stock.csv
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("ERROR: DATABASE_URL is required")
+	}
+
+	switch os.Args[1] {
+	case "import":
+		runImport(databaseURL, os.Args[2:])
+	case "export":
+		runExport(databaseURL, os.Args[2:])
+	case "sync":
+		runSync(databaseURL, os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func runImport(databaseURL string, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("f", "", "CSV file to import (default: stdin)")
+	dryRun := fs.Bool("dry-run", false, "validate rows without saving")
+	fs.Parse(args)
+
+	var in io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal("Failed to open file:", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	db := database.Connect(databaseURL)
+	defer db.Close()
+
+	catalogService := services.NewCatalogService(repository.NewCatalogRepository(db))
+
+	result, err := catalogService.Import(in, *dryRun)
+	if err != nil {
+		log.Fatal("Import failed:", err)
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+
+	if len(result.Errors) > 0 {
+		fmt.Printf("❌ %d row(s) rejected, nothing was saved\n", len(result.Errors))
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Println("✓ Dry run passed, nothing was saved")
+		return
+	}
+	fmt.Println("✅ Catalog imported")
+}
+
+func runExport(databaseURL string, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	file := fs.String("o", "", "output CSV file (default: stdout)")
+	fs.Parse(args)
+
+	var out io.Writer = os.Stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			log.Fatal("Failed to create file:", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	db := database.Connect(databaseURL)
+	defer db.Close()
+
+	catalogService := services.NewCatalogService(repository.NewCatalogRepository(db))
+
+	if err := catalogService.Export(out); err != nil {
+		log.Fatal("Export failed:", err)
+	}
+
+	if *file != "" {
+		fmt.Println("✅ Catalog exported to", *file)
+	}
+}
+
+func runSync(databaseURL string, args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	file := fs.String("f", "", "CSV or JSON file with sku, stock_quantity, price_adjustment (default: stdin)")
+	format := fs.String("format", "", "csv or json (default: from file extension, csv for stdin)")
+	fs.Parse(args)
+
+	var in io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal("Failed to open file:", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if *format == "" {
+		*format = "csv"
+		if strings.HasSuffix(strings.ToLower(*file), ".json") {
+			*format = "json"
+		}
+	}
+
+	var items []models.StockSyncItem
+	var err error
+	switch *format {
+	case "csv":
+		items, err = services.ParseStockSyncCSV(in)
+	case "json":
+		items, err = services.ParseStockSyncJSON(in)
+	default:
+		log.Fatal("Unknown format: ", *format)
+	}
+	if err != nil {
+		log.Fatal("Failed to parse input:", err)
+	}
+
+	db := database.Connect(databaseURL)
+	defer db.Close()
+
+	inventoryService := services.NewInventoryService(repository.NewInventoryRepository(db))
+
+	result, err := inventoryService.SyncStock(items, "cli", nil, nil)
+	if err != nil {
+		log.Fatal("Sync failed:", err)
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	fmt.Printf("✅ Stock synced: %d updated, %d unchanged, %d unknown\n",
+		len(result.Updated), len(result.Unchanged), len(result.Unknown))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  catalog import [-f file.csv] [-dry-run]")
+	fmt.Fprintln(os.Stderr, "  catalog export [-o file.csv]")
+	fmt.Fprintln(os.Stderr, "  catalog sync [-f stock.csv|stock.json] [-format csv|json]")
+	os.Exit(2)
+}
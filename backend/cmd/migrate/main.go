@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 
+	"ecommerce-backend/internal/money"
+
 	_ "github.com/lib/pq"
 )
 
@@ -347,7 +349,7 @@ func migrateProducts(old, new *sql.DB) {
 		var name, slug string
 		var description sql.NullString
 		var brandID, categoryID sql.NullInt64
-		var basePrice float64
+		var basePrice money.Money
 		var isActive bool
 		var createdAt, updatedAt sql.NullTime
 
@@ -378,7 +380,7 @@ func migrateProductVariants(old, new *sql.DB) {
 		var id, productID, stockQuantity int
 		var sku, size, color string
 		var colorHex sql.NullString
-		var priceAdjustment float64
+		var priceAdjustment money.Money
 		var createdAt, updatedAt sql.NullTime
 
 		rows.Scan(&id, &productID, &sku, &size, &color, &colorHex, &stockQuantity, &priceAdjustment, &createdAt, &updatedAt)
@@ -472,7 +474,7 @@ func migrateOrders(old, new *sql.DB) {
 		var userID sql.NullInt64
 		var orderNumber, shippingAddressLine1, shippingCity, shippingPostalCode, shippingCountry, shippingFullName, shippingPhone, status string
 		var shippingAddressLine2, shippingState, paymentMethod, paymentStatus, paymentTransactionID, notes sql.NullString
-		var subtotal, shippingCost, tax, total float64
+		var subtotal, shippingCost, tax, total money.Money
 		var createdAt, updatedAt sql.NullTime
 
 		rows.Scan(&id, &userID, &orderNumber, &shippingAddressLine1, &shippingAddressLine2, 
@@ -511,7 +513,7 @@ func migrateOrderItems(old, new *sql.DB) {
 		var id, orderID, quantity int
 		var productVariantID sql.NullInt64
 		var productName, productSku, size, color string
-		var unitPrice, totalPrice float64
+		var unitPrice, totalPrice money.Money
 		var createdAt sql.NullTime
 
 		rows.Scan(&id, &orderID, &productVariantID, &productName, &productSku, &size, &color, &quantity, &unitPrice, &totalPrice, &createdAt)
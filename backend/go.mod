@@ -10,4 +10,4 @@ require (
 	golang.org/x/crypto v0.17.0
 )
 
-require github.com/stripe/stripe-go/v76 v76.25.0 // indirect
+require github.com/stripe/stripe-go/v76 v76.25.0
@@ -3,9 +3,13 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"ecommerce-backend/internal/money"
 )
 
 // Config holds all configuration for our application
@@ -15,6 +19,76 @@ type Config struct {
 	Port           string
 	AllowedOrigins []string
 	Environment    string
+
+	// Access tokens are short-lived; refresh tokens renew them until the session ends
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Two-factor login: time allowed between the password and code steps, and
+	// the name accounts appear under in authenticator apps
+	PreAuthTokenTTL time.Duration
+	TOTPIssuer      string
+
+	// How long a password reset link works
+	PasswordResetTTL time.Duration
+
+	// How long an email verification link works, and whether checkout needs a
+	// verified email (defaults to on in production only)
+	EmailVerificationTTL     time.Duration
+	RequireEmailVerification bool
+
+	// Login brute-force protection: failed logins allowed per account email
+	// and per IP before logins are locked for LoginLockout (backoff starts halfway)
+	LoginMaxFailures   int
+	LoginMaxIPFailures int
+	LoginLockout       time.Duration
+
+	// Where emails go: "log" (stdout) or "file" (one file per email in MailDir)
+	MailDriver string
+	MailDir    string
+
+	// Link target for emails (cart recovery etc.)
+	FrontendURL string
+
+	// Social sign-in providers (OIDC_PROVIDERS), empty when disabled
+	OIDCProviders []OIDCProvider
+
+	// Abandoned cart detection
+	AbandonedCartAfter         time.Duration
+	AbandonedCartCheckInterval time.Duration
+
+	// Whether catalog prices include KDV (true) or tax is added at checkout
+	PricesIncludeTax bool
+
+	// ISO 4217 code of the currency catalog prices are stored in
+	BaseCurrency string
+
+	// Loyalty points: earned per unit of the base currency, worth LoyaltyPointValue
+	// each at checkout, and expired after LoyaltyExpireAfter without activity
+	LoyaltyPointsPerUnit int
+	LoyaltyPointValue    money.Money
+	LoyaltyExpireAfter   time.Duration
+}
+
+// OIDCProvider is an OpenID Connect sign-in provider, configured with
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _SCOPES, _RESPONSE_MODE and
+// _REDIRECT_URL. Google and Apple only need a client ID and secret.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       string
+	ResponseMode string // "query", or "form_post" (posted to the API, which forwards to the frontend)
+	RedirectURL  string
+}
+
+// oidcDefaults are the settings of well-known providers
+var oidcDefaults = map[string]OIDCProvider{
+	"google": {Issuer: "https://accounts.google.com", Scopes: "openid email profile", ResponseMode: "query"},
+	// Apple only returns the email with form_post; its client secret is a
+	// signed JWT that has to be renewed at least every 6 months
+	"apple": {Issuer: "https://appleid.apple.com", Scopes: "openid email name", ResponseMode: "form_post"},
 }
 
 // LoadConfig reads .env and returns config
@@ -33,6 +107,34 @@ func LoadConfig() *Config {
 	// Optional with defaults
 	port := getEnvDefault("PORT", "8080")
 	environment := getEnvDefault("ENV", "development")
+	accessTokenTTL := getEnvDuration("ACCESS_TOKEN_TTL", "15m")
+	refreshTokenTTL := getEnvDuration("REFRESH_TOKEN_TTL", "720h")
+	preAuthTokenTTL := getEnvDuration("PRE_AUTH_TOKEN_TTL", "5m")
+	totpIssuer := getEnvDefault("TOTP_ISSUER", "FASHION")
+	passwordResetTTL := getEnvDuration("PASSWORD_RESET_TTL", "1h")
+	emailVerificationTTL := getEnvDuration("EMAIL_VERIFICATION_TTL", "48h")
+	requireEmailVerification := getEnvBool("REQUIRE_EMAIL_VERIFICATION", environment == "production")
+	loginMaxFailures := getEnvInt("LOGIN_MAX_FAILURES", 10)
+	loginMaxIPFailures := getEnvInt("LOGIN_MAX_IP_FAILURES", 50)
+	if loginMaxFailures == 0 || loginMaxIPFailures == 0 {
+		log.Fatalf("ERROR: LOGIN_MAX_FAILURES and LOGIN_MAX_IP_FAILURES must be at least 1")
+	}
+	loginLockout := getEnvDuration("LOGIN_LOCKOUT", "15m")
+	mailDriver := getEnvDefault("MAIL_DRIVER", "log")
+	mailDir := getEnvDefault("MAIL_DIR", "tmp/mail")
+	abandonedCartAfter := getEnvDuration("ABANDONED_CART_AFTER", "24h")
+	abandonedCartCheckInterval := getEnvDuration("ABANDONED_CART_CHECK_INTERVAL", "1h")
+	pricesIncludeTax := getEnvBool("PRICES_INCLUDE_TAX", true)
+	baseCurrency := strings.ToUpper(getEnvDefault("BASE_CURRENCY", "TRY"))
+	if len(baseCurrency) != 3 {
+		log.Fatalf("ERROR: BASE_CURRENCY must be a 3-letter ISO 4217 code, got %q", baseCurrency)
+	}
+	loyaltyPointsPerUnit := getEnvInt("LOYALTY_POINTS_PER_UNIT", 1)
+	loyaltyPointValue, err := money.Parse(getEnvDefault("LOYALTY_POINT_VALUE", "0.01"))
+	if err != nil || loyaltyPointValue.IsNegative() || loyaltyPointValue.IsZero() {
+		log.Fatalf("ERROR: LOYALTY_POINT_VALUE must be a positive amount like 0.01")
+	}
+	loyaltyExpireAfter := getEnvDuration("LOYALTY_EXPIRE_AFTER", "8760h")
 
 	// Parse ALLOWED_ORIGINS
 	allowedOrigins := strings.Split(allowedOriginsStr, ",")
@@ -40,6 +142,9 @@ func LoadConfig() *Config {
 		allowedOrigins[i] = strings.TrimSpace(allowedOrigins[i])
 	}
 
+	frontendURL := strings.TrimRight(getEnvDefault("FRONTEND_URL", allowedOrigins[0]), "/")
+	oidcProviders := loadOIDCProviders(frontendURL)
+
 	log.Printf("✓ Config loaded - Environment: %s, Port: %s", environment, port)
 
 	return &Config{
@@ -48,9 +153,89 @@ func LoadConfig() *Config {
 		Port:           port,
 		AllowedOrigins: allowedOrigins,
 		Environment:    environment,
+
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+
+		PreAuthTokenTTL: preAuthTokenTTL,
+		TOTPIssuer:      totpIssuer,
+
+		PasswordResetTTL: passwordResetTTL,
+
+		EmailVerificationTTL:     emailVerificationTTL,
+		RequireEmailVerification: requireEmailVerification,
+
+		LoginMaxFailures:   loginMaxFailures,
+		LoginMaxIPFailures: loginMaxIPFailures,
+		LoginLockout:       loginLockout,
+
+		MailDriver: mailDriver,
+		MailDir:    mailDir,
+
+		FrontendURL: frontendURL,
+
+		OIDCProviders: oidcProviders,
+
+		AbandonedCartAfter:         abandonedCartAfter,
+		AbandonedCartCheckInterval: abandonedCartCheckInterval,
+
+		PricesIncludeTax: pricesIncludeTax,
+		BaseCurrency:     baseCurrency,
+
+		LoyaltyPointsPerUnit: loyaltyPointsPerUnit,
+		LoyaltyPointValue:    loyaltyPointValue,
+		LoyaltyExpireAfter:   loyaltyExpireAfter,
 	}
 }
 
+// loadOIDCProviders reads the providers listed in OIDC_PROVIDERS (e.g. "google,apple")
+func loadOIDCProviders(frontendURL string) []OIDCProvider {
+	providers := []OIDCProvider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		for _, c := range name {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+				log.Fatalf("ERROR: OIDC provider names may only contain letters and digits, got %q", name)
+			}
+		}
+
+		defaults, known := oidcDefaults[name]
+		if !known {
+			defaults = OIDCProvider{Scopes: "openid email profile", ResponseMode: "query"}
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := OIDCProvider{
+			Name:         name,
+			Issuer:       getEnvDefault(prefix+"ISSUER", defaults.Issuer),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       getEnvDefault(prefix+"SCOPES", defaults.Scopes),
+			ResponseMode: getEnvDefault(prefix+"RESPONSE_MODE", defaults.ResponseMode),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Fatalf("ERROR: %sISSUER and %sCLIENT_ID are required for OIDC provider %q", prefix, prefix, name)
+		}
+		switch p.ResponseMode {
+		case "query":
+			if p.RedirectURL == "" {
+				p.RedirectURL = frontendURL + "/oidc/callback/" + name
+			}
+		case "form_post":
+			if p.RedirectURL == "" {
+				log.Fatalf("ERROR: %sREDIRECT_URL must point at /api/auth/oidc/%s/form-post for form_post", prefix, name)
+			}
+		default:
+			log.Fatalf("ERROR: %sRESPONSE_MODE must be query or form_post, got %q", prefix, p.ResponseMode)
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
+
 // getEnvRequired gets env var or fails
 func getEnvRequired(key string) string {
 	value := os.Getenv(key)
@@ -68,3 +253,39 @@ func getEnvDefault(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvDuration gets a duration env var (e.g. "24h") or returns default
+func getEnvDuration(key, defaultValue string) time.Duration {
+	value := getEnvDefault(key, defaultValue)
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("ERROR: %s must be a positive duration like 24h, got %q", key, value)
+	}
+	return d
+}
+
+// getEnvBool gets a boolean env var (true/false, 1/0) or returns default
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("ERROR: %s must be true or false, got %q", key, value)
+	}
+	return b
+}
+
+// getEnvInt gets a non-negative integer env var or returns default
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("ERROR: %s must be a non-negative integer, got %q", key, value)
+	}
+	return n
+}
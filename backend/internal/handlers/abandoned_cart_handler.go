@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type AbandonedCartHandler struct {
+	abandonedCartService *services.AbandonedCartService
+}
+
+func NewAbandonedCartHandler(abandonedCartService *services.AbandonedCartService) *AbandonedCartHandler {
+	return &AbandonedCartHandler{abandonedCartService: abandonedCartService}
+}
+
+// RecoverCart restores an abandoned cart from a recovery email link
+// Accepts ?token= or a JSON body with the token.
+func (h *AbandonedCartHandler) RecoverCart(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" && r.Method == http.MethodPost {
+		var req models.RecoverCartRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.Error(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		token = req.Token
+	}
+	if token == "" {
+		utils.Error(w, http.StatusBadRequest, "Missing token")
+		return
+	}
+
+	result, err := h.abandonedCartService.Recover(token)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, result)
+}
+
+// GetStats returns abandoned cart recovery stats (admin only)
+func (h *AbandonedCartHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.abandonedCartService.GetStats()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch abandoned cart stats")
+		return
+	}
+
+	utils.Success(w, stats)
+}
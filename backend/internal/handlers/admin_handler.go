@@ -7,8 +7,8 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"ecommerce-backend/internal/models"
 	"ecommerce-backend/internal/repository"
-	//"ecommerce-backend/internal/models"
 	"ecommerce-backend/internal/services"
 	"ecommerce-backend/internal/utils"
 )
@@ -66,6 +66,51 @@ func (h *AdminHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// GetOrder returns any order with items, discounts and refunds (admin only)
+func (h *AdminHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	order, err := h.orderService.GetOrder(orderID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to get order")
+		return
+	}
+	if order == nil {
+		utils.Error(w, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	utils.Success(w, order)
+}
+
+// RefundOrder refunds units of an order's lines, with their tax (admin only)
+func (h *AdminHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminID, _ := r.Context().Value("user_id").(int)
+	refund, err := h.orderService.RefundOrder(orderID, adminID, requestAPIKeyID(r), &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, refund)
+}
+
 // GetStats returns basic admin statistics
 func (h *AdminHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement real stats from database
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// GetAPIKeys lists all API keys (admin only)
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyService.GetKeys()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch API keys")
+		return
+	}
+	utils.Success(w, keys)
+}
+
+// CreateAPIKey issues an API key; the key is only shown in this response (admin only)
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminID, _ := r.Context().Value("user_id").(int)
+	key, err := h.apiKeyService.Create(adminID, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, key)
+}
+
+// RevokeAPIKey stops an API key from working (admin only)
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	adminID, _ := r.Context().Value("user_id").(int)
+	if err := h.apiKeyService.Revoke(adminID, id); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "API key revoked"})
+}
+
+// GetAPIKeyRequests lists the latest requests made with an API key (admin only)
+func (h *APIKeyHandler) GetAPIKeyRequests(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	requests, err := h.apiKeyService.GetRequests(id, limit)
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	utils.Success(w, requests)
+}
+
+// requestAPIKeyID returns the API key a request was made with, or nil for a
+// signed-in user, so changes made through a key are recorded against it
+func requestAPIKeyID(r *http.Request) *int {
+	if keyID, ok := r.Context().Value("api_key_id").(int); ok {
+		return &keyID
+	}
+	return nil
+}
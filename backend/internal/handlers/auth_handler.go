@@ -2,7 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
 
 	"ecommerce-backend/internal/models"
 	"ecommerce-backend/internal/services"
@@ -10,11 +16,48 @@ import (
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService  *services.AuthService
+	cartService  *services.CartService
+	orderService *services.OrderService
+}
+
+func NewAuthHandler(authService *services.AuthService, cartService *services.CartService, orderService *services.OrderService) *AuthHandler {
+	return &AuthHandler{
+		authService:  authService,
+		cartService:  cartService,
+		orderService: orderService,
+	}
+}
+
+// mergeGuestCart moves the visitor's guest cart (if any) into their account.
+// A failed merge never blocks login.
+func (h *AuthHandler) mergeGuestCart(r *http.Request, userID int) {
+	token := r.Header.Get(CartTokenHeader)
+	if token == "" {
+		return
+	}
+	if err := h.cartService.MergeGuestCart(token, userID); err != nil {
+		log.Printf("Failed to merge guest cart for user %d: %v", userID, err)
+	}
+}
+
+// clientInfo identifies the device a login comes from
+func clientInfo(r *http.Request) models.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return models.ClientInfo{UserAgent: r.UserAgent(), IPAddress: ip}
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+// loginError writes the response for a failed login step: 429 with
+// Retry-After when throttled, otherwise status
+func loginError(w http.ResponseWriter, err error, status int) {
+	if throttled, ok := err.(*services.LoginThrottledError); ok {
+		utils.TooManyRequests(w, throttled.RetryAfter, err.Error())
+		return
+	}
+	utils.Error(w, status, err.Error())
 }
 
 // Register handles user registration
@@ -25,12 +68,21 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authResp, err := h.authService.Register(&req)
+	authResp, err := h.authService.Register(&req, clientInfo(r))
 	if err != nil {
 		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	h.mergeGuestCart(r, authResp.User.ID)
+
+	// Attach past guest orders when registering from a guest order link
+	if req.OrderToken != "" {
+		if _, err := h.orderService.ClaimGuestOrders(req.OrderToken, authResp.User.ID, authResp.User.Email); err != nil {
+			log.Printf("Failed to attach guest orders for user %d: %v", authResp.User.ID, err)
+		}
+	}
+
 	utils.Success(w, authResp)
 }
 
@@ -42,7 +94,114 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authResp, err := h.authService.Login(&req)
+	authResp, err := h.authService.Login(&req, clientInfo(r))
+	if err != nil {
+		loginError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	// Not logged in yet; the cart is merged once the code is verified
+	if authResp.TwoFactorRequired {
+		utils.Success(w, authResp)
+		return
+	}
+
+	h.mergeGuestCart(r, authResp.User.ID)
+
+	utils.Success(w, authResp)
+}
+
+// VerifyTwoFactor completes a login with the pre-auth token and a two-factor code
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	authResp, err := h.authService.VerifyTwoFactor(&req, clientInfo(r))
+	if err != nil {
+		loginError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	h.mergeGuestCart(r, authResp.User.ID)
+
+	utils.Success(w, authResp)
+}
+
+// GetOIDCProviders lists the social sign-in providers
+func (h *AuthHandler) GetOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	utils.Success(w, h.authService.OIDCProviders())
+}
+
+// StartOIDCLogin returns the provider URL to send the browser to, and the
+// login token to send back with the provider's response
+func (h *AuthHandler) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authorization, err := h.authService.StartOIDCLogin(mux.Vars(r)["provider"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	utils.Success(w, authorization)
+}
+
+// OIDCLogin completes a social sign-in with the code and state from the provider
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	authResp, err := h.authService.OIDCLogin(mux.Vars(r)["provider"], &req, clientInfo(r))
+	if err != nil {
+		loginError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	// Not logged in yet; the cart is merged once the code is verified
+	if authResp.TwoFactorRequired {
+		utils.Success(w, authResp)
+		return
+	}
+
+	h.mergeGuestCart(r, authResp.User.ID)
+
+	utils.Success(w, authResp)
+}
+
+// OIDCFormPost receives a provider's form_post response (e.g. Apple) and
+// forwards the browser to the frontend callback page with it in the query
+func (h *AuthHandler) OIDCFormPost(w http.ResponseWriter, r *http.Request) {
+	callbackURL := h.authService.OIDCFrontendCallbackURL(mux.Vars(r)["provider"])
+	if callbackURL == "" {
+		utils.Error(w, http.StatusNotFound, "Unknown sign-in provider")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid form")
+		return
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"code", "state", "error"} {
+		if value := r.PostForm.Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	http.Redirect(w, r, callbackURL+"?"+query.Encode(), http.StatusSeeOther)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	authResp, err := h.authService.Refresh(req.RefreshToken)
 	if err != nil {
 		utils.Error(w, http.StatusUnauthorized, err.Error())
 		return
@@ -51,6 +210,124 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, authResp)
 }
 
+// Logout ends the session of a refresh token (or all the user's sessions),
+// revoking its access tokens too
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.authService.Logout(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Logged out"})
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not an account exists for the email.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.authService.ForgotPassword(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{
+		"message": "If an account exists for that email, a password reset link has been sent to it",
+	})
+}
+
+// ResetPassword sets a new password with the token from a reset link
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.authService.ResetPassword(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Password has been reset. Please log in with your new password."})
+}
+
+// VerifyEmail confirms an email address with the token from the verification link
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.authService.VerifyEmail(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, user)
+}
+
+// ResendVerification emails the logged-in user a new verification link
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.authService.ResendVerification(userID); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Verification email sent"})
+}
+
+// RevokeUserSessions logs a user out on all devices (admin only)
+func (h *AuthHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	revoked, err := h.authService.RevokeSessions(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	utils.Success(w, map[string]interface{}{
+		"message": "Sessions revoked",
+		"revoked": revoked,
+	})
+}
+
+// GetLoginFailures lists failed logins, optionally filtered by ?email= and ?ip= (admin only)
+func (h *AuthHandler) GetLoginFailures(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	failures, err := h.authService.GetLoginFailures(query.Get("email"), query.Get("ip"), limit)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch login failures")
+		return
+	}
+	utils.Success(w, failures)
+}
+
 // Me returns current user info (requires JWT)
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
@@ -13,35 +13,82 @@ import (
 )
 
 type CartHandler struct {
-	cartService *services.CartService
+	cartService     *services.CartService
+	currencyService *services.CurrencyService
 }
 
-func NewCartHandler(cartService *services.CartService) *CartHandler {
-	return &CartHandler{cartService: cartService}
+func NewCartHandler(cartService *services.CartService, currencyService *services.CurrencyService) *CartHandler {
+	return &CartHandler{cartService: cartService, currencyService: currencyService}
 }
 
-// GetCart returns user's cart
-func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(int)
-	if !ok {
-		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+// CartTokenHeader carries the signed token of an anonymous (guest) cart
+const CartTokenHeader = "X-Cart-Token"
+
+// cartOwner resolves whose cart a request refers to: the logged-in user
+// (set by OptionalAuthMiddleware) or the guest identified by X-Cart-Token.
+// Returns ok=false if the request has neither; err is set if the cart token is invalid.
+func (h *CartHandler) cartOwner(r *http.Request) (owner models.CartOwner, token string, ok bool, err error) {
+	if userID, isUser := r.Context().Value("user_id").(int); isUser {
+		return models.CartOwner{UserID: userID}, "", true, nil
+	}
+
+	token = r.Header.Get(CartTokenHeader)
+	if token == "" {
+		return models.CartOwner{}, "", false, nil
+	}
+
+	owner, err = h.cartService.ResolveCartToken(token)
+	if err != nil {
+		return models.CartOwner{}, "", false, err
+	}
+	return owner, token, true, nil
+}
+
+// respondWithCart sends the current cart with prices in ?currency=,
+// echoing the cart token for guests
+func (h *CartHandler) respondWithCart(w http.ResponseWriter, r *http.Request, owner models.CartOwner, token string) {
+	converter, err := h.currencyService.Converter(r.URL.Query().Get("currency"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	cart, err := h.cartService.GetCart(userID)
+	cart, err := h.cartService.GetCart(owner)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to get cart")
 		return
 	}
+	converter.Cart(cart)
+
+	if owner.IsGuest() {
+		cart.CartToken = token
+		w.Header().Set(CartTokenHeader, token)
+	}
 
 	utils.Success(w, cart)
 }
 
-// AddToCart adds item to cart
-func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(int)
+// GetCart returns user's or guest's cart
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
 	if !ok {
-		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		// New visitor without a cart yet
+		utils.Success(w, &models.CartResponse{Items: []models.CartItem{}, SavedItems: []models.CartItem{}})
+		return
+	}
+
+	h.respondWithCart(w, r, owner, token)
+}
+
+// AddToCart adds item to cart, starting a guest cart if needed
+func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 
@@ -51,25 +98,57 @@ func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.cartService.AddToCart(userID, &req); err != nil {
+	if !ok {
+		owner, token, err = h.cartService.NewGuestCart()
+		if err != nil {
+			utils.Error(w, http.StatusInternalServerError, "Failed to create cart")
+			return
+		}
+	}
+
+	if err := h.cartService.AddToCart(owner, &req); err != nil {
 		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Return updated cart
-	cart, err := h.cartService.GetCart(userID)
+	h.respondWithCart(w, r, owner, token)
+}
+
+// UpdateCartItem updates cart item quantity
+func (h *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	cartItemID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get cart")
+		utils.Error(w, http.StatusBadRequest, "Invalid cart item ID")
 		return
 	}
 
-	utils.Success(w, cart)
+	var req models.UpdateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.cartService.UpdateCartItem(cartItemID, owner, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Return updated cart
+	h.respondWithCart(w, r, owner, token)
 }
 
-// UpdateCartItem updates cart item quantity
-func (h *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(int)
-	if !ok {
+// AcceptPrice accepts the new price of one cart line
+func (h *CartHandler) AcceptPrice(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
@@ -81,31 +160,117 @@ func (h *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req models.UpdateCartRequest
+	if err := h.cartService.AcceptNewPrices(owner, cartItemID); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Return updated cart
+	h.respondWithCart(w, r, owner, token)
+}
+
+// AcceptAllPrices accepts the new prices of all changed cart lines
+func (h *CartHandler) AcceptAllPrices(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.cartService.AcceptNewPrices(owner, 0); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update prices")
+		return
+	}
+
+	// Return updated cart
+	h.respondWithCart(w, r, owner, token)
+}
+
+// ApplyCoupon applies a coupon code to the cart
+func (h *CartHandler) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.ApplyCouponRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if err := h.cartService.UpdateCartItem(cartItemID, userID, &req); err != nil {
+	if err := h.cartService.ApplyCoupon(owner, req.Code); err != nil {
 		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Return updated cart
-	cart, err := h.cartService.GetCart(userID)
+	h.respondWithCart(w, r, owner, token)
+}
+
+// GetShippingQuotes lists the shipping methods for the cart to ?country= and ?city=,
+// with how much more to spend for free shipping, in ?currency=
+func (h *CartHandler) GetShippingQuotes(w http.ResponseWriter, r *http.Request) {
+	owner, _, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	country := r.URL.Query().Get("country")
+	if country == "" {
+		utils.Error(w, http.StatusBadRequest, "country is required")
+		return
+	}
+
+	converter, err := h.currencyService.Converter(r.URL.Query().Get("currency"))
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get cart")
+		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	utils.Success(w, cart)
+	quotes, err := h.cartService.ShippingQuotes(owner, country, r.URL.Query().Get("city"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	converter.ShippingQuotes(quotes)
+
+	utils.Success(w, quotes)
 }
 
-// RemoveFromCart removes item from cart
-func (h *CartHandler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(int)
-	if !ok {
+// RemoveCoupon removes the coupon from the cart
+func (h *CartHandler) RemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.cartService.RemoveCoupon(owner); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to remove coupon")
+		return
+	}
+
+	// Return updated cart
+	h.respondWithCart(w, r, owner, token)
+}
+
+// SaveForLater moves a cart line to the saved-for-later list
+func (h *CartHandler) SaveForLater(w http.ResponseWriter, r *http.Request) {
+	h.moveItem(w, r, h.cartService.SaveForLater)
+}
+
+// MoveToCart moves a saved-for-later line back into the cart
+func (h *CartHandler) MoveToCart(w http.ResponseWriter, r *http.Request) {
+	h.moveItem(w, r, h.cartService.MoveToCart)
+}
+
+// moveItem runs a move action on the cart line from the URL and returns the cart
+func (h *CartHandler) moveItem(w http.ResponseWriter, r *http.Request, move func(int, models.CartOwner) error) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
@@ -117,30 +282,48 @@ func (h *CartHandler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.cartService.RemoveFromCart(cartItemID, userID); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to remove item")
+	if err := move(cartItemID, owner); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Return updated cart
-	cart, err := h.cartService.GetCart(userID)
+	h.respondWithCart(w, r, owner, token)
+}
+
+// RemoveFromCart removes item from cart
+func (h *CartHandler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
+	owner, token, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	cartItemID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get cart")
+		utils.Error(w, http.StatusBadRequest, "Invalid cart item ID")
 		return
 	}
 
-	utils.Success(w, cart)
+	if err := h.cartService.RemoveFromCart(cartItemID, owner); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to remove item")
+		return
+	}
+
+	// Return updated cart
+	h.respondWithCart(w, r, owner, token)
 }
 
 // ClearCart removes all items from cart
 func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(int)
-	if !ok {
+	owner, _, ok, err := h.cartOwner(r)
+	if err != nil || !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	if err := h.cartService.ClearCart(userID); err != nil {
+	if err := h.cartService.ClearCart(owner); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to clear cart")
 		return
 	}
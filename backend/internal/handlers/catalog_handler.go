@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+// maxCatalogUploadSize limits catalog CSV uploads (32 MB)
+const maxCatalogUploadSize = 32 << 20
+
+type CatalogHandler struct {
+	catalogService *services.CatalogService
+}
+
+func NewCatalogHandler(catalogService *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{catalogService: catalogService}
+}
+
+// ImportCatalog imports products from a CSV upload (admin only)
+// Accepts either a multipart form with a "file" field or a raw text/csv body.
+// Pass ?dry_run=true to validate without saving.
+func (h *CatalogHandler) ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxCatalogUploadSize)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, "Missing CSV file")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, err := h.catalogService.Import(body, dryRun)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Nothing was saved if any row failed
+	if len(result.Errors) > 0 {
+		utils.JSON(w, http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	utils.Success(w, result)
+}
+
+// ExportCatalog downloads the full catalog as CSV (admin only)
+func (h *CatalogHandler) ExportCatalog(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := h.catalogService.Export(&buf); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to export catalog")
+		return
+	}
+
+	filename := "catalog-" + time.Now().Format("20060102") + ".csv"
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
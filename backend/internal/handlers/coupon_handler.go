@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type CouponHandler struct {
+	couponService *services.CouponService
+}
+
+func NewCouponHandler(couponService *services.CouponService) *CouponHandler {
+	return &CouponHandler{couponService: couponService}
+}
+
+// GetCoupons lists all coupons (admin only)
+func (h *CouponHandler) GetCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.couponService.GetAllCoupons()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch coupons")
+		return
+	}
+	utils.Success(w, coupons)
+}
+
+// GetCoupon returns a single coupon (admin only)
+func (h *CouponHandler) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	coupon, err := h.couponService.GetCoupon(id)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch coupon")
+		return
+	}
+	if coupon == nil {
+		utils.Error(w, http.StatusNotFound, "Coupon not found")
+		return
+	}
+
+	utils.Success(w, coupon)
+}
+
+// CreateCoupon creates a coupon (admin only)
+func (h *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var req models.CouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, coupon)
+}
+
+// UpdateCoupon updates a coupon (admin only)
+func (h *CouponHandler) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	var req models.CouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, coupon)
+}
+
+// DeleteCoupon deletes a coupon that was never redeemed (admin only)
+func (h *CouponHandler) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	if err := h.couponService.DeleteCoupon(id); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Coupon deleted"})
+}
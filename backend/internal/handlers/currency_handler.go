@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type CurrencyHandler struct {
+	currencyService *services.CurrencyService
+}
+
+func NewCurrencyHandler(currencyService *services.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{currencyService: currencyService}
+}
+
+// GetCurrencies lists the base currency and the currencies prices can be shown in
+func (h *CurrencyHandler) GetCurrencies(w http.ResponseWriter, r *http.Request) {
+	currencies, err := h.currencyService.GetCurrencies()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch currencies")
+		return
+	}
+	utils.Success(w, currencies)
+}
+
+// SetExchangeRate creates or updates the rate of a currency (admin only)
+func (h *CurrencyHandler) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var req models.ExchangeRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rate, err := h.currencyService.SetRate(mux.Vars(r)["currency"], &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, rate)
+}
+
+// DeleteExchangeRate removes a currency (admin only)
+func (h *CurrencyHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	if err := h.currencyService.DeleteRate(mux.Vars(r)["currency"]); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Exchange rate deleted"})
+}
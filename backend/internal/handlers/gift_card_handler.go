@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type GiftCardHandler struct {
+	giftCardService *services.GiftCardService
+}
+
+func NewGiftCardHandler(giftCardService *services.GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{giftCardService: giftCardService}
+}
+
+// GetBalance returns the balance of a gift card code
+func (h *GiftCardHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	balance, err := h.giftCardService.CheckBalance(mux.Vars(r)["code"])
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	utils.Success(w, balance)
+}
+
+// PurchaseGiftCard creates a gift card for the user to pay for through /payment
+func (h *GiftCardHandler) PurchaseGiftCard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.PurchaseGiftCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	card, err := h.giftCardService.Purchase(userID, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, card)
+}
+
+// GetMyGiftCards lists the gift cards the user bought
+func (h *GiftCardHandler) GetMyGiftCards(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	cards, err := h.giftCardService.GetPurchased(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch gift cards")
+		return
+	}
+	utils.Success(w, cards)
+}
+
+// GetStoreCredit returns the user's store credit balance and history
+func (h *GiftCardHandler) GetStoreCredit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	credit, err := h.giftCardService.GetStoreCredit(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch store credit")
+		return
+	}
+	utils.Success(w, credit)
+}
+
+// GetGiftCards lists all gift cards (admin only)
+func (h *GiftCardHandler) GetGiftCards(w http.ResponseWriter, r *http.Request) {
+	cards, err := h.giftCardService.GetAll()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch gift cards")
+		return
+	}
+	utils.Success(w, cards)
+}
+
+// IssueGiftCard issues an active gift card (admin only)
+func (h *GiftCardHandler) IssueGiftCard(w http.ResponseWriter, r *http.Request) {
+	var req models.IssueGiftCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminID, _ := r.Context().Value("user_id").(int)
+	card, err := h.giftCardService.Issue(adminID, requestAPIKeyID(r), &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, card)
+}
+
+// UpdateGiftCard deactivates, reactivates or extends a gift card (admin only)
+func (h *GiftCardHandler) UpdateGiftCard(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid gift card ID")
+		return
+	}
+
+	var req models.UpdateGiftCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	card, err := h.giftCardService.Update(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, card)
+}
+
+// AdjustStoreCredit adds or removes a user's store credit (admin only)
+func (h *GiftCardHandler) AdjustStoreCredit(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.StoreCreditAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminID, _ := r.Context().Value("user_id").(int)
+	transaction, err := h.giftCardService.AdjustStoreCredit(userID, adminID, requestAPIKeyID(r), &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, transaction)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type InventoryHandler struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryHandler(inventoryService *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{inventoryService: inventoryService}
+}
+
+// SyncStock updates stock and price adjustments by SKU (admin only)
+// Accepts a JSON body or a text/csv body with sku, stock_quantity, price_adjustment.
+func (h *InventoryHandler) SyncStock(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCatalogUploadSize)
+
+	var items []models.StockSyncItem
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		items, err = services.ParseStockSyncCSV(r.Body)
+	} else {
+		items, err = services.ParseStockSyncJSON(r.Body)
+	}
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	source := "api"
+	var changedBy, apiKeyID *int
+	if userID, ok := r.Context().Value("user_id").(int); ok {
+		changedBy = &userID
+	}
+	if keyID, ok := r.Context().Value("api_key_id").(int); ok {
+		source = "api_key"
+		apiKeyID = &keyID
+	}
+
+	result, err := h.inventoryService.SyncStock(items, source, changedBy, apiKeyID)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, result)
+}
+
+// GetStockAudit returns recent stock changes (admin only)
+func (h *InventoryHandler) GetStockAudit(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	entries, err := h.inventoryService.GetAuditLog(r.URL.Query().Get("sku"), limit)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch stock audit log")
+		return
+	}
+
+	utils.Success(w, entries)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type LoyaltyHandler struct {
+	loyaltyService *services.LoyaltyService
+}
+
+func NewLoyaltyHandler(loyaltyService *services.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{loyaltyService: loyaltyService}
+}
+
+// GetLoyalty returns the user's points balance and history
+func (h *LoyaltyHandler) GetLoyalty(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	account, err := h.loyaltyService.GetAccount(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch loyalty points")
+		return
+	}
+	utils.Success(w, account)
+}
+
+// GetUserLoyalty returns a user's points balance and history (admin only)
+func (h *LoyaltyHandler) GetUserLoyalty(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	account, err := h.loyaltyService.GetAccount(userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch loyalty points")
+		return
+	}
+	utils.Success(w, account)
+}
+
+// AdjustLoyalty adds or removes a user's points (admin only)
+func (h *LoyaltyHandler) AdjustLoyalty(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.LoyaltyAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminID, _ := r.Context().Value("user_id").(int)
+	transaction, err := h.loyaltyService.Adjust(userID, adminID, requestAPIKeyID(r), &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, transaction)
+}
@@ -14,10 +14,14 @@ import (
 
 type OrderHandler struct {
 	orderService *services.OrderService
+	cartService  *services.CartService
 }
 
-func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
-	return &OrderHandler{orderService: orderService}
+func NewOrderHandler(orderService *services.OrderService, cartService *services.CartService) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+		cartService:  cartService,
+	}
 }
 
 // CreateOrder creates a new order
@@ -33,6 +37,9 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		req.Currency = currency
+	}
 
 	order, err := h.orderService.CreateOrder(userID, &req)
 	if err != nil {
@@ -43,6 +50,93 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, order)
 }
 
+// CreateGuestOrder checks out a guest cart (X-Cart-Token) without an account
+func (h *OrderHandler) CreateGuestOrder(w http.ResponseWriter, r *http.Request) {
+	owner, err := h.cartService.ResolveCartToken(r.Header.Get(CartTokenHeader))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Missing or invalid cart token")
+		return
+	}
+
+	var req models.GuestCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		req.Currency = currency
+	}
+
+	order, err := h.orderService.CreateGuestOrder(owner, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, order)
+}
+
+// LookupOrder returns a guest order from a signed lookup link (?token=)
+// or from an order number plus email (POST body)
+func (h *OrderHandler) LookupOrder(w http.ResponseWriter, r *http.Request) {
+	var order *models.Order
+	var err error
+
+	if r.Method == http.MethodGet {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			utils.Error(w, http.StatusBadRequest, "Missing token")
+			return
+		}
+		order, err = h.orderService.LookupGuestOrder(token)
+	} else {
+		var req models.OrderLookupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.Error(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		order, err = h.orderService.LookupGuestOrderByNumber(&req)
+	}
+
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if order == nil {
+		utils.Error(w, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	utils.Success(w, order)
+}
+
+// ClaimOrders attaches past guest orders to the logged-in account
+func (h *OrderHandler) ClaimOrders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	email, _ := r.Context().Value("user_email").(string)
+
+	var req models.ClaimOrdersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	claimed, err := h.orderService.ClaimGuestOrders(req.OrderToken, userID, email)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]interface{}{
+		"message":        "Guest orders attached to your account",
+		"orders_claimed": claimed,
+	})
+}
+
 // GetOrders retrieves user's orders
 func (h *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("user_id").(int)
@@ -5,7 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/services"
 	"ecommerce-backend/internal/utils"
 
 	"github.com/stripe/stripe-go/v76"
@@ -13,21 +18,23 @@ import (
 )
 
 type PaymentHandler struct {
-	db *sql.DB
+	db              *sql.DB
+	giftCardService *services.GiftCardService
 }
 
-func NewPaymentHandler(db *sql.DB) *PaymentHandler {
+func NewPaymentHandler(db *sql.DB, giftCardService *services.GiftCardService) *PaymentHandler {
 	// Set Stripe API key from environment
 	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
 	
-	return &PaymentHandler{db: db}
+	return &PaymentHandler{db: db, giftCardService: giftCardService}
 }
 
 // CreatePaymentIntentRequest is the request body for creating a payment intent
 type CreatePaymentIntentRequest struct {
-	Amount   int64  `json:"amount"`    // Amount in cents (e.g., 10000 = $100.00)
-	Currency string `json:"currency"`  // e.g., "usd", "try"
-	OrderID  int    `json:"order_id"`  // Optional: link to order
+	Amount     int64  `json:"amount"`       // Amount in cents (e.g., 10000 = $100.00); ignored when OrderID or GiftCardID is set
+	Currency   string `json:"currency"`     // e.g., "usd", "try"; defaults to the base currency
+	OrderID    int    `json:"order_id"`     // Optional: link to order
+	GiftCardID int    `json:"gift_card_id"` // Optional: a purchased gift card to pay for
 }
 
 // CreatePaymentIntent creates a Stripe payment intent
@@ -38,15 +45,56 @@ func (h *PaymentHandler) CreatePaymentIntent(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// An order is charged what gift cards and store credit didn't cover,
+	// in minor units of its currency
+	if req.OrderID > 0 {
+		var due money.Money
+		var currency string
+		err := h.db.QueryRow(`
+			SELECT COALESCE(SUM(op.amount) FILTER (WHERE op.status = $2), 0), o.currency
+			FROM orders o
+			LEFT JOIN order_payments op ON op.order_id = o.id
+			WHERE o.id = $1
+			GROUP BY o.id
+		`, req.OrderID, models.OrderPaymentPending).Scan(&due, &currency)
+		if err == sql.ErrNoRows {
+			utils.Error(w, http.StatusNotFound, "Order not found")
+			return
+		}
+		if err != nil {
+			utils.Error(w, http.StatusInternalServerError, "Failed to get order")
+			return
+		}
+		if due.IsZero() {
+			utils.Error(w, http.StatusBadRequest, "Order has nothing left to pay")
+			return
+		}
+		req.Amount = due.Amount
+		req.Currency = strings.ToLower(currency)
+	} else if req.GiftCardID > 0 {
+		// A purchased gift card is charged its value in the base currency
+		card, err := h.giftCardService.GetForPayment(req.GiftCardID)
+		if err != nil {
+			utils.Error(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if userID, _ := r.Context().Value("user_id").(int); *card.PurchaserUserID != userID {
+			utils.Error(w, http.StatusNotFound, "gift card not found or already paid")
+			return
+		}
+		req.Amount = card.InitialBalance.Amount
+		req.Currency = strings.ToLower(money.DefaultCurrency)
+	}
+
 	// Validate amount
 	if req.Amount <= 0 {
 		utils.Error(w, http.StatusBadRequest, "Amount must be greater than 0")
 		return
 	}
 
-	// Default currency to USD if not provided
+	// Default to the store's base currency
 	if req.Currency == "" {
-		req.Currency = "usd"
+		req.Currency = strings.ToLower(money.DefaultCurrency)
 	}
 
 	// Create payment intent parameters
@@ -58,10 +106,14 @@ func (h *PaymentHandler) CreatePaymentIntent(w http.ResponseWriter, r *http.Requ
 		},
 	}
 
-	// Add metadata if order ID is provided
+	// Add metadata if order ID or gift card ID is provided
 	if req.OrderID > 0 {
 		params.Metadata = map[string]string{
-			"order_id": string(rune(req.OrderID)),
+			"order_id": strconv.Itoa(req.OrderID),
+		}
+	} else if req.GiftCardID > 0 {
+		params.Metadata = map[string]string{
+			"gift_card_id": strconv.Itoa(req.GiftCardID),
 		}
 	}
 
@@ -83,9 +135,10 @@ func (h *PaymentHandler) CreatePaymentIntent(w http.ResponseWriter, r *http.Requ
 type ConfirmPaymentRequest struct {
 	PaymentIntentID string `json:"payment_intent_id"`
 	OrderID         int    `json:"order_id"`
+	GiftCardID      int    `json:"gift_card_id"`
 }
 
-// ConfirmPayment updates order status after successful payment
+// ConfirmPayment updates order status, or activates a purchased gift card, after successful payment
 func (h *PaymentHandler) ConfirmPayment(w http.ResponseWriter, r *http.Request) {
 	var req ConfirmPaymentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -106,22 +159,71 @@ func (h *PaymentHandler) ConfirmPayment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Update order status to 'confirmed' and add payment info
+	// A purchased gift card becomes usable once its own payment succeeded
+	if req.GiftCardID > 0 {
+		card, err := h.giftCardService.GetForPayment(req.GiftCardID)
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if pi.Metadata["gift_card_id"] != strconv.Itoa(req.GiftCardID) || pi.Amount != card.InitialBalance.Amount {
+			utils.Error(w, http.StatusBadRequest, "Payment does not match the gift card")
+			return
+		}
+		if err := h.giftCardService.ActivatePurchased(req.GiftCardID, pi.ID); err != nil {
+			utils.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.Success(w, map[string]interface{}{
+			"message":      "Gift card activated",
+			"gift_card_id": req.GiftCardID,
+		})
+		return
+	}
+
+	if pi.Metadata["order_id"] != strconv.Itoa(req.OrderID) {
+		utils.Error(w, http.StatusBadRequest, "Payment does not match the order")
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update order")
+		return
+	}
+	defer tx.Rollback()
+
+	// Capture the pending part of the order's payment with the Stripe transaction
+	_, err = tx.Exec(`
+		UPDATE order_payments
+		SET status = $1, method = $2, transaction_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $4 AND status = $5
+	`, models.OrderPaymentCaptured, models.PaymentMethodStripe, pi.ID, req.OrderID, models.OrderPaymentPending)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update order")
+		return
+	}
+
+	// Update order status to 'confirmed'
 	query := `
 		UPDATE orders 
 		SET status = 'confirmed',
 		    payment_status = 'paid',
 		    payment_method = 'stripe',
-		    payment_transaction_id = $1,
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2
+		WHERE id = $1
 	`
 
-	_, err = h.db.Exec(query, req.PaymentIntentID, req.OrderID)
+	_, err = tx.Exec(query, req.OrderID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to update order")
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update order")
+		return
+	}
 
 	utils.Success(w, map[string]interface{}{
 		"message": "Payment confirmed successfully",
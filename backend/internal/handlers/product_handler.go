@@ -7,25 +7,34 @@ import (
 	"github.com/gorilla/mux"
 	
 	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
 	"ecommerce-backend/internal/services"
 	"ecommerce-backend/internal/utils"
 )
 
 type ProductHandler struct {
-	productService *services.ProductService
+	productService  *services.ProductService
+	currencyService *services.CurrencyService
 }
 
-func NewProductHandler(productService *services.ProductService) *ProductHandler {
-	return &ProductHandler{productService: productService}
+func NewProductHandler(productService *services.ProductService, currencyService *services.CurrencyService) *ProductHandler {
+	return &ProductHandler{productService: productService, currencyService: currencyService}
 }
 
-// GetProducts handles product listing with filters
+// GetProducts handles product listing with filters.
+// With ?currency= prices (and the price filters) are in that currency.
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	query := &models.ProductListQuery{}
 
 	// Parse query parameters
 	queryParams := r.URL.Query()
 
+	converter, err := h.currencyService.Converter(queryParams.Get("currency"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if categoryID := queryParams.Get("category"); categoryID != "" {
 		if id, err := strconv.Atoi(categoryID); err == nil {
 			query.CategoryID = &id
@@ -39,13 +48,15 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if minPrice := queryParams.Get("min_price"); minPrice != "" {
-		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
+		if price, err := money.Parse(minPrice); err == nil {
+			price = converter.ToBase(price)
 			query.MinPrice = &price
 		}
 	}
 
 	if maxPrice := queryParams.Get("max_price"); maxPrice != "" {
-		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
+		if price, err := money.Parse(maxPrice); err == nil {
+			price = converter.ToBase(price)
 			query.MaxPrice = &price
 		}
 	}
@@ -53,6 +64,7 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	query.Size = queryParams.Get("size")
 	query.Color = queryParams.Get("color")
 	query.Search = queryParams.Get("search")
+	query.OnSale, _ = strconv.ParseBool(queryParams.Get("sale"))
 
 	if page := queryParams.Get("page"); page != "" {
 		if p, err := strconv.Atoi(page); err == nil {
@@ -71,11 +83,14 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve products")
 		return
 	}
+	for i := range products {
+		converter.Product(&products[i])
+	}
 
 	utils.Success(w, products)
 }
 
-// GetProduct handles getting a single product
+// GetProduct handles getting a single product, with prices in ?currency=
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -84,6 +99,12 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	converter, err := h.currencyService.Converter(r.URL.Query().Get("currency"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	product, err := h.productService.GetProductByID(id)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve product")
@@ -94,6 +115,7 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		utils.Error(w, http.StatusNotFound, "Product not found")
 		return
 	}
+	converter.Product(product)
 
 	utils.Success(w, product)
 }
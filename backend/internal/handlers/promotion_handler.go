@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type PromotionHandler struct {
+	promotionService *services.PromotionService
+}
+
+func NewPromotionHandler(promotionService *services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+// GetPromotions lists all promotions (admin only)
+func (h *PromotionHandler) GetPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.promotionService.GetAllPromotions()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch promotions")
+		return
+	}
+	utils.Success(w, promotions)
+}
+
+// GetPromotion returns a single promotion (admin only)
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	promotion, err := h.promotionService.GetPromotion(id)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch promotion")
+		return
+	}
+	if promotion == nil {
+		utils.Error(w, http.StatusNotFound, "Promotion not found")
+		return
+	}
+
+	utils.Success(w, promotion)
+}
+
+// CreatePromotion creates a promotion (admin only)
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req models.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	promotion, err := h.promotionService.CreatePromotion(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, promotion)
+}
+
+// UpdatePromotion updates a promotion (admin only)
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	promotion, err := h.promotionService.UpdatePromotion(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, promotion)
+}
+
+// DeletePromotion deletes a promotion (admin only)
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	if err := h.promotionService.DeletePromotion(id); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Promotion deleted"})
+}
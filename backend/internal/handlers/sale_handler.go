@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type SaleHandler struct {
+	saleService *services.SaleService
+}
+
+func NewSaleHandler(saleService *services.SaleService) *SaleHandler {
+	return &SaleHandler{saleService: saleService}
+}
+
+// GetSales lists all scheduled and flash sales (admin only)
+func (h *SaleHandler) GetSales(w http.ResponseWriter, r *http.Request) {
+	sales, err := h.saleService.GetAllSales()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch sales")
+		return
+	}
+	utils.Success(w, sales)
+}
+
+// CreateSale schedules a sale on a product or variant (admin only)
+func (h *SaleHandler) CreateSale(w http.ResponseWriter, r *http.Request) {
+	var req models.SalePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sale, err := h.saleService.CreateSale(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, sale)
+}
+
+// UpdateSale updates a sale (admin only)
+func (h *SaleHandler) UpdateSale(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid sale ID")
+		return
+	}
+
+	var req models.SalePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sale, err := h.saleService.UpdateSale(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, sale)
+}
+
+// DeleteSale deletes a sale (admin only)
+func (h *SaleHandler) DeleteSale(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid sale ID")
+		return
+	}
+
+	if err := h.saleService.DeleteSale(id); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Sale deleted"})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type ShippingHandler struct {
+	shippingService *services.ShippingService
+}
+
+func NewShippingHandler(shippingService *services.ShippingService) *ShippingHandler {
+	return &ShippingHandler{shippingService: shippingService}
+}
+
+// GetShippingMethods lists all shipping methods with their rates (admin only)
+func (h *ShippingHandler) GetShippingMethods(w http.ResponseWriter, r *http.Request) {
+	methods, err := h.shippingService.GetMethods()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch shipping methods")
+		return
+	}
+	utils.Success(w, methods)
+}
+
+// CreateShippingMethod creates a shipping method (admin only)
+func (h *ShippingHandler) CreateShippingMethod(w http.ResponseWriter, r *http.Request) {
+	var req models.ShippingMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	method, err := h.shippingService.CreateMethod(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, method)
+}
+
+// UpdateShippingMethod updates a shipping method (admin only)
+func (h *ShippingHandler) UpdateShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid shipping method ID")
+		return
+	}
+
+	var req models.ShippingMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	method, err := h.shippingService.UpdateMethod(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, method)
+}
+
+// CreateShippingRate adds a destination rate to a shipping method (admin only)
+func (h *ShippingHandler) CreateShippingRate(w http.ResponseWriter, r *http.Request) {
+	var req models.ShippingRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rate, err := h.shippingService.CreateRate(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, rate)
+}
+
+// UpdateShippingRate updates a shipping rate (admin only)
+func (h *ShippingHandler) UpdateShippingRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid shipping rate ID")
+		return
+	}
+
+	var req models.ShippingRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rate, err := h.shippingService.UpdateRate(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, rate)
+}
+
+// DeleteShippingRate deletes a shipping rate (admin only)
+func (h *ShippingHandler) DeleteShippingRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid shipping rate ID")
+		return
+	}
+
+	if err := h.shippingService.DeleteRate(id); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Shipping rate deleted"})
+}
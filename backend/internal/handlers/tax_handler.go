@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type TaxHandler struct {
+	taxService *services.TaxService
+}
+
+func NewTaxHandler(taxService *services.TaxService) *TaxHandler {
+	return &TaxHandler{taxService: taxService}
+}
+
+// GetTaxRates lists all tax rates (admin only)
+func (h *TaxHandler) GetTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.taxService.GetAllRates()
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch tax rates")
+		return
+	}
+	utils.Success(w, rates)
+}
+
+// CreateTaxRate adds a tax rate for a country or category (admin only)
+func (h *TaxHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var req models.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rate, err := h.taxService.CreateRate(&req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.JSON(w, http.StatusCreated, rate)
+}
+
+// UpdateTaxRate updates a tax rate (admin only)
+func (h *TaxHandler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid tax rate ID")
+		return
+	}
+
+	var req models.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rate, err := h.taxService.UpdateRate(id, &req)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, rate)
+}
+
+// DeleteTaxRate deletes a tax rate (admin only)
+func (h *TaxHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid tax rate ID")
+		return
+	}
+
+	if err := h.taxService.DeleteRate(id); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.Success(w, map[string]string{"message": "Tax rate deleted"})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/services"
+	"ecommerce-backend/internal/utils"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// GetStatus returns whether the user has two-factor authentication on
+func (h *TwoFactorHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	sessionID, _ := r.Context().Value("session_id").(int)
+
+	status, err := h.twoFactorService.Status(userID, sessionID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch two-factor status")
+		return
+	}
+	utils.Success(w, status)
+}
+
+// Setup returns a new secret and otpauth:// URI for the user's authenticator app
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(userID)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	utils.Success(w, setup)
+}
+
+// Enable turns on two-factor authentication with a code from the app and
+// returns the recovery codes (shown only this once)
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	sessionID, _ := r.Context().Value("session_id").(int)
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(userID, sessionID, &req, clientInfo(r))
+	if err != nil {
+		loginError(w, err, http.StatusBadRequest)
+		return
+	}
+	utils.Success(w, codes)
+}
+
+// Disable turns off two-factor authentication (not allowed for staff)
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.TwoFactorConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID, &req, clientInfo(r)); err != nil {
+		loginError(w, err, http.StatusBadRequest)
+		return
+	}
+	utils.Success(w, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes (shown only this once)
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int)
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.TwoFactorConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, &req, clientInfo(r))
+	if err != nil {
+		loginError(w, err, http.StatusBadRequest)
+		return
+	}
+	utils.Success(w, codes)
+}
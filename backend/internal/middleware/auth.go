@@ -2,14 +2,70 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net"
 	"net/http"
 	"strings"
 
 	"ecommerce-backend/internal/utils"
 )
 
+// SessionChecker reports whether the login session an access token was issued
+// for is still active, so logged-out and revoked tokens stop working at once,
+// and whether the login passed a second factor
+type SessionChecker interface {
+	IsSessionActive(sessionID, userID int) (bool, bool, error)
+}
+
+// errSessionCheck means the session couldn't be looked up (not the client's fault)
+var errSessionCheck = errors.New("session check failed")
+
+// authenticate validates a "Bearer <token>" header and returns the request
+// context with the user's info
+func authenticate(r *http.Request, authHeader, jwtSecret string, sessions SessionChecker) (context.Context, error) {
+	// Expected format: "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, errors.New("Invalid authorization header format")
+	}
+
+	// Validate token
+	claims, err := utils.ValidateJWT(parts[1], jwtSecret)
+	if err != nil {
+		return nil, errors.New("Invalid or expired token")
+	}
+
+	// The session must not have been logged out or revoked
+	active, twoFactor, err := sessions.IsSessionActive(claims.SessionID, claims.UserID)
+	if err != nil {
+		log.Printf("Failed to check session %d: %v", claims.SessionID, err)
+		return nil, errSessionCheck
+	}
+	if !active {
+		return nil, errors.New("Session has ended, please log in again")
+	}
+
+	// Add user info to context
+	ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+	ctx = context.WithValue(ctx, "user_email", claims.Email)
+	ctx = context.WithValue(ctx, "user_role", claims.Role)
+	ctx = context.WithValue(ctx, "session_id", claims.SessionID)
+	ctx = context.WithValue(ctx, "two_factor", twoFactor)
+	return ctx, nil
+}
+
+// authError writes the response for a failed authenticate
+func authError(w http.ResponseWriter, err error) {
+	if err == errSessionCheck {
+		utils.Error(w, http.StatusInternalServerError, "Failed to check session")
+		return
+	}
+	utils.Error(w, http.StatusUnauthorized, err.Error())
+}
+
 // AuthMiddleware validates JWT token
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+func AuthMiddleware(jwtSecret string, sessions SessionChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get token from Authorization header
@@ -19,40 +75,172 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Expected format: "Bearer <token>"
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				utils.Error(w, http.StatusUnauthorized, "Invalid authorization header format")
+			ctx, err := authenticate(r, authHeader, jwtSecret, sessions)
+			if err != nil {
+				authError(w, err)
 				return
 			}
 
-			token := parts[1]
+			// Call next handler with updated context
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-			// Validate token
-			claims, err := utils.ValidateJWT(token, jwtSecret)
+// APIKeyAuthenticator checks API keys and keeps the audit trail of their requests
+type APIKeyAuthenticator interface {
+	// AuthenticateAPIKey returns the key's ID (zero if it isn't usable) and scopes
+	AuthenticateAPIKey(key, ip string) (int, []string, error)
+	LogAPIKeyRequest(keyID int, method, path string, status int, ip string)
+}
+
+// statusRecorder remembers the status code a handler wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// AuthOrAPIKeyMiddleware works like AuthMiddleware but also accepts an
+// "ApiKey <key>" header. Key requests carry the key's ID and scopes in the
+// context instead of a user, and are logged with their response status.
+func AuthOrAPIKeyMiddleware(jwtSecret string, sessions SessionChecker, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		userAuth := AuthMiddleware(jwtSecret, sessions)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey ")
+			if !ok {
+				userAuth.ServeHTTP(w, r)
+				return
+			}
+
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+			keyID, scopes, err := apiKeys.AuthenticateAPIKey(strings.TrimSpace(key), ip)
 			if err != nil {
-				utils.Error(w, http.StatusUnauthorized, "Invalid or expired token")
+				log.Printf("Failed to check API key: %v", err)
+				utils.Error(w, http.StatusInternalServerError, "Failed to check API key")
+				return
+			}
+			if keyID == 0 {
+				utils.Error(w, http.StatusUnauthorized, "Invalid, expired or revoked API key")
 				return
 			}
 
-			// Add user info to context
-			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-			ctx = context.WithValue(ctx, "user_email", claims.Email)
-			ctx = context.WithValue(ctx, "user_role", claims.Role)
+			ctx := context.WithValue(r.Context(), "api_key_id", keyID)
+			ctx = context.WithValue(ctx, "api_key_scopes", scopes)
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			apiKeys.LogAPIKeyRequest(keyID, r.Method, r.URL.Path, rec.status, ip)
+		})
+	}
+}
+
+// OptionalAuthMiddleware adds user info to the context when a valid JWT is sent,
+// but lets anonymous requests through (e.g. guest carts)
+func OptionalAuthMiddleware(jwtSecret string, sessions SessionChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// A token that is sent must still be valid
+			ctx, err := authenticate(r, authHeader, jwtSecret, sessions)
+			if err != nil {
+				authError(w, err)
+				return
+			}
 
-			// Call next handler with updated context
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// AdminMiddleware ensures user is admin
-func AdminMiddleware() func(http.Handler) http.Handler {
+// EmailVerificationChecker reports whether a user has verified their email address
+type EmailVerificationChecker interface {
+	IsEmailVerified(userID int) (bool, error)
+}
+
+// VerifiedEmailMiddleware lets only users with a verified email address through.
+// Runs after AuthMiddleware; when required is false every request passes.
+func VerifiedEmailMiddleware(users EmailVerificationChecker, required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !required {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value("user_id").(int)
+			if !ok {
+				utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			verified, err := users.IsEmailVerified(userID)
+			if err != nil {
+				log.Printf("Failed to check email verification of user %d: %v", userID, err)
+				utils.Error(w, http.StatusInternalServerError, "Failed to check email verification")
+				return
+			}
+			if !verified {
+				utils.Error(w, http.StatusForbidden, "Please verify your email address first")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PermissionChecker reports whether a user's role has a permission
+type PermissionChecker interface {
+	HasPermission(userID int, permission string) (bool, error)
+}
+
+// RequirePermission lets through only users whose role has the permission and
+// who logged in with two-factor authentication (required for staff), or API
+// keys with the permission among their scopes. Runs after AuthMiddleware or
+// AuthOrAPIKeyMiddleware; the role is read on every request so changes apply at once.
+func RequirePermission(roles PermissionChecker, permission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			role, ok := r.Context().Value("user_role").(string)
-			if !ok || role != "admin" {
-				utils.Error(w, http.StatusForbidden, "Admin access required")
+			if _, ok := r.Context().Value("api_key_id").(int); ok {
+				scopes, _ := r.Context().Value("api_key_scopes").([]string)
+				for _, scope := range scopes {
+					if scope == permission {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+				utils.Error(w, http.StatusForbidden, "API key scope required: "+permission)
+				return
+			}
+
+			userID, ok := r.Context().Value("user_id").(int)
+			if !ok {
+				utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+
+			allowed, err := roles.HasPermission(userID, permission)
+			if err != nil {
+				log.Printf("Failed to check permission %s of user %d: %v", permission, userID, err)
+				utils.Error(w, http.StatusInternalServerError, "Failed to check permissions")
+				return
+			}
+			if !allowed {
+				utils.Error(w, http.StatusForbidden, "Permission required: "+permission)
+				return
+			}
+			if twoFactor, _ := r.Context().Value("two_factor").(bool); !twoFactor {
+				utils.Error(w, http.StatusForbidden, "Two-factor authentication is required for staff accounts")
 				return
 			}
 			next.ServeHTTP(w, r)
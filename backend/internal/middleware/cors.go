@@ -20,7 +20,8 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 			}
 
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Cart-Token")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Cart-Token, Retry-After")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 			// Handle preflight
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// AbandonedCart is a cart that sat idle past the configured window
+type AbandonedCart struct {
+	ID               int                 `json:"id"`
+	UserID           int                 `json:"user_id"`
+	LastActivityAt   time.Time           `json:"last_activity_at"`
+	Items            []AbandonedCartLine `json:"items"`
+	CartTotal        money.Money         `json:"cart_total"`
+	NotificationID   *int                `json:"notification_id,omitempty"`
+	NotifiedAt       *time.Time          `json:"notified_at,omitempty"`
+	ClickedAt        *time.Time          `json:"clicked_at,omitempty"`
+	RecoveredOrderID *int                `json:"recovered_order_id,omitempty"`
+	RecoveredAt      *time.Time          `json:"recovered_at,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+
+	// Populated for detection only
+	Email     string `json:"-"`
+	FirstName string `json:"-"`
+}
+
+// AbandonedCartLine is one line of an abandoned cart snapshot
+type AbandonedCartLine struct {
+	ProductVariantID int `json:"product_variant_id"`
+	Quantity         int `json:"quantity"`
+}
+
+// AbandonedCartStats summarizes recovery email performance (admin)
+type AbandonedCartStats struct {
+	Detected         int         `json:"detected"`
+	Notified         int         `json:"notified"`
+	Clicked          int         `json:"clicked"`
+	Recovered        int         `json:"recovered"`
+	RecoveryRate     float64     `json:"recovery_rate"` // recovered / notified
+	RecoveredRevenue money.Money `json:"recovered_revenue"`
+}
+
+// RecoverCartRequest carries the token from a recovery email link
+type RecoverCartRequest struct {
+	Token string `json:"token"`
+}
+
+// RecoverCartResponse is returned when a recovery link is opened
+type RecoverCartResponse struct {
+	RestoredItems int    `json:"restored_items"`
+	Email         string `json:"email"`
+	Message       string `json:"message"`
+}
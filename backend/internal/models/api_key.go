@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// APIKey lets an integration (ERP, marketplace connector) call the admin API
+// without a user's login. It may use only the permissions in its scopes.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Start of the key, to tell keys apart
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"created_by,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  *int       `json:"revoked_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once when a key is created; only its hash is kept
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"` // Send as "Authorization: ApiKey <key>"
+}
+
+// CreateAPIKeyRequest creates an API key (admin)
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Optional; never expires if empty
+}
+
+// APIKeyRequestLog is an audit record of a request made with an API key
+type APIKeyRequestLog struct {
+	ID        int64     `json:"id"`
+	APIKeyID  int       `json:"api_key_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
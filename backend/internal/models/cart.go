@@ -1,19 +1,61 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
 
 // CartItem represents an item in user's cart
 type CartItem struct {
-	ID               int             `json:"id"`
-	UserID           int             `json:"user_id"`
-	ProductVariantID int             `json:"product_variant_id"`
-	Quantity         int             `json:"quantity"`
-	CreatedAt        time.Time       `json:"created_at"`
-	UpdatedAt        time.Time       `json:"updated_at"`
-	
+	ID               int          `json:"id"`
+	UserID           int          `json:"user_id"`
+	GuestID          string       `json:"-"`
+	ProductVariantID int          `json:"product_variant_id"`
+	Quantity         int          `json:"quantity"`
+	AddedUnitPrice   *money.Money `json:"added_unit_price,omitempty"` // Unit price when the item was added
+	SavedForLater    bool         `json:"saved_for_later"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+
 	// Populated fields (from joins)
-	Product *Product         `json:"product,omitempty"`
-	Variant *ProductVariant  `json:"variant,omitempty"`
+	Product *Product        `json:"product,omitempty"`
+	Variant *ProductVariant `json:"variant,omitempty"`
+
+	// Share of the cart's promotion and coupon discounts
+	Discount money.Money `json:"discount"`
+
+	// Problems found when the cart was revalidated
+	Issues []CartItemIssue `json:"issues,omitempty"`
+}
+
+// Cart line issue types
+const (
+	CartIssueUnavailable  = "unavailable"          // Variant or product no longer exists
+	CartIssueInactive     = "product_inactive"     // Product was deactivated
+	CartIssueOutOfStock   = "out_of_stock"         // Variant has no stock left
+	CartIssueReducedStock = "reduced_availability" // Fewer in stock than the line quantity
+	CartIssuePriceChanged = "price_changed"        // Price differs from when the item was added
+)
+
+// CartItemIssue describes a problem with a cart line
+type CartItemIssue struct {
+	Type      string       `json:"type"`
+	Message   string       `json:"message"`
+	Available *int         `json:"available,omitempty"`
+	OldPrice  *money.Money `json:"old_price,omitempty"`
+	NewPrice  *money.Money `json:"new_price,omitempty"`
+}
+
+// CartOwner identifies whose cart is being used: a logged-in user or an anonymous guest
+type CartOwner struct {
+	UserID  int
+	GuestID string
+}
+
+// IsGuest reports whether the cart belongs to an anonymous visitor
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
 }
 
 // AddToCartRequest is the request to add item to cart
@@ -29,7 +71,23 @@ type UpdateCartRequest struct {
 
 // CartResponse is the full cart with all items
 type CartResponse struct {
-	Items      []CartItem `json:"items"`
-	TotalItems int        `json:"total_items"`
-	TotalPrice float64    `json:"total_price"`
+	Items      []CartItem  `json:"items"`
+	TotalItems int         `json:"total_items"`
+	TotalPrice money.Money `json:"total_price"`
+	HasIssues  bool        `json:"has_issues"`
+
+	// Lines moved to "saved for later"; not counted in the totals above
+	SavedItems []CartItem `json:"saved_items"`
+
+	// Number of lines whose price changed since they were added (accept via /cart/accept-prices)
+	PriceChanges int `json:"price_changes"`
+
+	// Automatic promotions and the coupon applied to the cart; Total is TotalPrice minus Discount
+	Promotions []AppliedPromotion `json:"promotions"`
+	Coupon     *AppliedCoupon     `json:"coupon,omitempty"`
+	Discount   money.Money        `json:"discount"`
+	Total      money.Money        `json:"total"`
+	Currency   string             `json:"currency"`
+
+	CartToken string `json:"cart_token,omitempty"` // Only for guest carts
 }
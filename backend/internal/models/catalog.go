@@ -0,0 +1,50 @@
+package models
+
+import "ecommerce-backend/internal/money"
+
+// CatalogColumns is the CSV header used for catalog import and export.
+// Each row describes one product variant; product fields are repeated per variant.
+var CatalogColumns = []string{
+	"product_slug", "product_name", "description", "brand_slug", "category_slug",
+	"base_price", "is_active", "sku", "size", "color", "color_hex",
+	"stock_quantity", "price_adjustment", "weight_grams", "image_urls",
+}
+
+// CatalogRow is a single parsed row of a catalog CSV
+type CatalogRow struct {
+	Line            int         `json:"line"`
+	ProductSlug     string      `json:"product_slug"`
+	ProductName     string      `json:"product_name"`
+	Description     string      `json:"description"`
+	BrandSlug       string      `json:"brand_slug"`
+	CategorySlug    string      `json:"category_slug"`
+	BasePrice       money.Money `json:"base_price"`
+	IsActive        bool        `json:"is_active"`
+	SKU             string      `json:"sku"`
+	Size            string      `json:"size"`
+	Color           string      `json:"color"`
+	ColorHex        string      `json:"color_hex"`
+	StockQuantity   int         `json:"stock_quantity"`
+	PriceAdjustment money.Money `json:"price_adjustment"`
+	WeightGrams     int         `json:"weight_grams"`
+	ImageURLs       []string    `json:"image_urls"` // "|" separated in the CSV
+}
+
+// CatalogRowError describes why a CSV row was rejected
+type CatalogRowError struct {
+	Line    int    `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+// CatalogImportResult summarizes a catalog import
+type CatalogImportResult struct {
+	DryRun          bool              `json:"dry_run"`
+	TotalRows       int               `json:"total_rows"`
+	ProductsCreated int               `json:"products_created"`
+	ProductsUpdated int               `json:"products_updated"`
+	VariantsCreated int               `json:"variants_created"`
+	VariantsUpdated int               `json:"variants_updated"`
+	ImagesReplaced  int               `json:"images_replaced"`
+	Errors          []CatalogRowError `json:"errors"`
+}
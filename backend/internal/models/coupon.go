@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// Coupon discount types
+const (
+	CouponPercentage = "percentage"
+	CouponFixed      = "fixed"
+)
+
+// Coupon is a discount code customers can apply to their cart
+type Coupon struct {
+	ID            int          `json:"id"`
+	Code          string       `json:"code"`
+	Description   string       `json:"description"`
+	DiscountType  string       `json:"discount_type"`  // percentage, fixed
+	DiscountValue float64      `json:"discount_value"` // Percent or amount off
+	MaxDiscount   *money.Money `json:"max_discount,omitempty"`
+	MinSubtotal   money.Money  `json:"min_subtotal"`
+
+	// Restrictions (empty = any brand / category)
+	BrandIDs    []int64 `json:"brand_ids"`
+	CategoryIDs []int64 `json:"category_ids"`
+
+	// Usage limits (nil = unlimited)
+	UsageLimit   *int `json:"usage_limit,omitempty"`
+	PerUserLimit *int `json:"per_user_limit,omitempty"`
+	TimesUsed    int  `json:"times_used"`
+
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CouponRequest is the admin request to create or update a coupon
+type CouponRequest struct {
+	Code          string       `json:"code"`
+	Description   string       `json:"description"`
+	DiscountType  string       `json:"discount_type"`
+	DiscountValue float64      `json:"discount_value"`
+	MaxDiscount   *money.Money `json:"max_discount"`
+	MinSubtotal   money.Money  `json:"min_subtotal"`
+	BrandIDs      []int64      `json:"brand_ids"`
+	CategoryIDs   []int64      `json:"category_ids"`
+	UsageLimit    *int         `json:"usage_limit"`
+	PerUserLimit  *int         `json:"per_user_limit"`
+	StartsAt      *time.Time   `json:"starts_at"`
+	ExpiresAt     *time.Time   `json:"expires_at"`
+	IsActive      *bool        `json:"is_active"` // Defaults to true
+}
+
+// ApplyCouponRequest is the request to apply a coupon to the cart
+type ApplyCouponRequest struct {
+	Code string `json:"code"`
+}
+
+// AppliedCoupon is the coupon on a cart and what it currently takes off
+type AppliedCoupon struct {
+	Code        string      `json:"code"`
+	Description string      `json:"description"`
+	Discount    money.Money `json:"discount"`
+	Error       string      `json:"error,omitempty"` // Why the coupon doesn't apply right now
+}
+
+// Order discount line types
+const (
+	DiscountTypeCoupon    = "coupon"
+	DiscountTypePromotion = "promotion"
+)
+
+// OrderDiscount is a discount line of an order
+type OrderDiscount struct {
+	ID          int         `json:"id"`
+	OrderID     int         `json:"order_id"`
+	Type        string      `json:"type"` // coupon, promotion
+	PromotionID *int        `json:"promotion_id,omitempty"`
+	Code        string      `json:"code,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Amount      money.Money `json:"amount"`
+}
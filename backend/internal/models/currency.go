@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ExchangeRate is how many units of a currency one unit of the base currency buys
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeRateRequest is the admin request to set a currency's rate
+type ExchangeRateRequest struct {
+	Rate float64 `json:"rate"`
+}
+
+// CurrencyList is the base currency and the currencies prices can be shown in
+type CurrencyList struct {
+	Base  string         `json:"base"`
+	Rates []ExchangeRate `json:"rates"`
+}
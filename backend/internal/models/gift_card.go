@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// GiftCard is a prepaid balance usable at checkout by whoever has the code
+type GiftCard struct {
+	ID             int         `json:"id"`
+	Code           string      `json:"code"`
+	InitialBalance money.Money `json:"initial_balance"`
+	Balance        money.Money `json:"balance"`
+	RecipientEmail string      `json:"recipient_email,omitempty"`
+	Message        string      `json:"message,omitempty"`
+
+	// Set for purchased cards, which become active once paid
+	PurchaserUserID      *int   `json:"purchaser_user_id,omitempty"`
+	PaymentTransactionID string `json:"-"`
+
+	// Set for cards issued by an admin, or through an API key
+	IssuedBy *int `json:"issued_by,omitempty"`
+	APIKeyID *int `json:"api_key_id,omitempty"`
+
+	IsActive  bool       `json:"is_active"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// GiftCardBalance is what anyone holding a code can see about it
+type GiftCardBalance struct {
+	Code      string      `json:"code"`
+	Balance   money.Money `json:"balance"`
+	Currency  string      `json:"currency"`
+	IsActive  bool        `json:"is_active"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+}
+
+// IssueGiftCardRequest is the admin request to issue a gift card
+type IssueGiftCardRequest struct {
+	Amount         money.Money `json:"amount"`
+	RecipientEmail string      `json:"recipient_email"`
+	Message        string      `json:"message"`
+	ExpiresAt      *time.Time  `json:"expires_at"`
+}
+
+// PurchaseGiftCardRequest is a customer's request to buy a gift card.
+// The card is paid through /payments with its gift_card_id.
+type PurchaseGiftCardRequest struct {
+	Amount         money.Money `json:"amount"`
+	RecipientEmail string      `json:"recipient_email"`
+	Message        string      `json:"message"`
+}
+
+// UpdateGiftCardRequest is the admin request to deactivate or extend a gift card
+type UpdateGiftCardRequest struct {
+	IsActive  bool       `json:"is_active"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// StoreCredit is a customer's wallet balance and its history
+type StoreCredit struct {
+	Balance      money.Money              `json:"balance"`
+	Currency     string                   `json:"currency"`
+	Transactions []StoreCreditTransaction `json:"transactions"`
+}
+
+// StoreCreditTransaction is a change to a customer's store credit
+type StoreCreditTransaction struct {
+	ID        int         `json:"id"`
+	UserID    int         `json:"user_id"`
+	Amount    money.Money `json:"amount"` // Negative when spent
+	Reason    string      `json:"reason"`
+	OrderID   *int        `json:"order_id,omitempty"`
+	RefundID  *int        `json:"refund_id,omitempty"`
+	CreatedBy *int        `json:"created_by,omitempty"`
+	APIKeyID  *int        `json:"api_key_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// StoreCreditAdjustmentRequest is the admin request to add (or remove) store credit
+type StoreCreditAdjustmentRequest struct {
+	Amount money.Money `json:"amount"`
+	Reason string      `json:"reason"`
+}
+
+// Order payment methods besides the customer's chosen card/cash method
+const (
+	PaymentMethodGiftCard    = "gift_card"
+	PaymentMethodStoreCredit = "store_credit"
+	PaymentMethodStripe      = "stripe"
+)
+
+// Order payment statuses
+const (
+	OrderPaymentPending  = "pending"
+	OrderPaymentCaptured = "captured"
+	OrderPaymentFailed   = "failed"
+)
+
+// OrderPayment is one tender of an order: a gift card, store credit, or the
+// rest by the chosen payment method
+type OrderPayment struct {
+	ID            int         `json:"id"`
+	OrderID       int         `json:"order_id"`
+	Method        string      `json:"method"`
+	Amount        money.Money `json:"amount"`
+	Status        string      `json:"status"`
+	GiftCardID    *int        `json:"gift_card_id,omitempty"`
+	GiftCardCode  string      `json:"gift_card_code,omitempty"` // Last 4 characters only
+	TransactionID string      `json:"transaction_id,omitempty"`
+	Refunded      money.Money `json:"refunded"` // Given back by refunds and cancellation
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// StockSyncItem is one SKU line of a stock/price sync file.
+// Omitted fields are left unchanged.
+type StockSyncItem struct {
+	SKU             string       `json:"sku"`
+	StockQuantity   *int         `json:"stock_quantity"`
+	PriceAdjustment *money.Money `json:"price_adjustment"`
+}
+
+// StockSyncRequest is the JSON body for a stock sync
+type StockSyncRequest struct {
+	Items []StockSyncItem `json:"items"`
+}
+
+// StockSyncResult summarizes a stock sync
+type StockSyncResult struct {
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	Unknown   []string `json:"unknown"`
+}
+
+// StockAuditEntry records a single stock/price change
+type StockAuditEntry struct {
+	ID                 int         `json:"id"`
+	ProductVariantID   int         `json:"product_variant_id"`
+	SKU                string      `json:"sku"`
+	OldStockQuantity   int         `json:"old_stock_quantity"`
+	NewStockQuantity   int         `json:"new_stock_quantity"`
+	OldPriceAdjustment money.Money `json:"old_price_adjustment"`
+	NewPriceAdjustment money.Money `json:"new_price_adjustment"`
+	Source             string      `json:"source"` // api, api_key, cli
+	ChangedBy          *int        `json:"changed_by,omitempty"`
+	APIKeyID           *int        `json:"api_key_id,omitempty"`
+	CreatedAt          time.Time   `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Login throttle scopes: failures are counted per account email and per client IP
+const (
+	LoginScopeAccount = "account"
+	LoginScopeIP      = "ip"
+)
+
+// Why a login failed
+const (
+	LoginFailureUnknownEmail  = "unknown_email"
+	LoginFailureWrongPassword = "wrong_password"
+	LoginFailureWrongCode     = "wrong_code" // Two-factor code
+	LoginFailureThrottled     = "throttled"  // Rejected before the password was checked
+)
+
+// LoginFailure is an audit record of a failed login
+type LoginFailure struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	UserID    *int      `json:"user_id,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// Loyalty transaction types
+const (
+	LoyaltyEarn    = "earn"    // Points for a delivered order
+	LoyaltyRedeem  = "redeem"  // Points spent at checkout
+	LoyaltyReverse = "reverse" // Earned points taken back for a cancelled or refunded order
+	LoyaltyReturn  = "return"  // Spent points given back for a cancelled or refunded order
+	LoyaltyExpire  = "expire"  // Points lost to inactivity
+	LoyaltyAdjust  = "adjust"  // Manual change by an admin
+)
+
+// PaymentMethodLoyaltyPoints is the order payment of points redeemed at checkout
+const PaymentMethodLoyaltyPoints = "loyalty_points"
+
+// LoyaltyAccount is a customer's points balance, what it is worth and its history
+type LoyaltyAccount struct {
+	Balance       int         `json:"balance"`
+	Value         money.Money `json:"value"`           // What the balance pays for at checkout
+	PointValue    money.Money `json:"point_value"`     // What one point pays for
+	PointsPerUnit int         `json:"points_per_unit"` // Points earned per unit of Currency spent
+	Currency      string      `json:"currency"`
+
+	// When the balance expires unless the customer earns or redeems points
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	Transactions []LoyaltyTransaction `json:"transactions"`
+}
+
+// LoyaltyTransaction is a change to a customer's points balance
+type LoyaltyTransaction struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Type      string    `json:"type"`
+	Points    int       `json:"points"` // Negative when taken from the balance
+	OrderID   *int      `json:"order_id,omitempty"`
+	RefundID  *int      `json:"refund_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy *int      `json:"created_by,omitempty"`
+	APIKeyID  *int      `json:"api_key_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoyaltyAdjustmentRequest is the admin request to add (or remove) points
+type LoyaltyAdjustmentRequest struct {
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
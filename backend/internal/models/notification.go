@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Notification is an outgoing message waiting in (or sent from) the queue
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    *int       `json:"user_id,omitempty"`
+	Channel   string     `json:"channel"` // email
+	Type      string     `json:"type"`    // abandoned_cart, ...
+	Recipient string     `json:"recipient"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	Status    string     `json:"status"` // pending, sent, failed
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to their account at an OpenID Connect provider
+type UserIdentity struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"-"` // The provider's user ID
+	Email       string    `json:"email,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// OIDCAuthorization starts a social sign-in. The browser is sent to
+// AuthorizationURL; the LoginToken stays with the client and is sent back
+// with the code and state the provider returns.
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	LoginToken       string `json:"login_token"`
+	ExpiresIn        int    `json:"expires_in"` // Seconds until LoginToken expires
+}
+
+// OIDCCallbackRequest completes a social sign-in
+type OIDCCallbackRequest struct {
+	Code       string `json:"code"`
+	State      string `json:"state"`
+	LoginToken string `json:"login_token"`
+}
@@ -1,11 +1,16 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
 
 // Order represents an order
 type Order struct {
 	ID           int         `json:"id"`
 	UserID       int         `json:"user_id"`
+	GuestEmail   string      `json:"guest_email,omitempty"` // Set for guest checkout orders
 	OrderNumber  string      `json:"order_number"`
 	
 	// Address snapshot (denormalized)
@@ -18,24 +23,44 @@ type Order struct {
 	ShippingFullName     string `json:"shipping_full_name"`
 	ShippingPhone        string `json:"shipping_phone"`
 	
+	// Chosen shipping method code (standard, express, same_day...)
+	ShippingMethod string `json:"shipping_method,omitempty"`
+	
 	// Order totals
-	Subtotal       float64 `json:"subtotal"`
-	ShippingCost   float64 `json:"shipping_cost"`
-	Tax            float64 `json:"tax"`
-	Total          float64 `json:"total"`
+	Subtotal     money.Money `json:"subtotal"`
+	Discount     money.Money `json:"discount"`
+	ShippingCost money.Money `json:"shipping_cost"`
+	Tax          money.Money `json:"tax"`
+	Total        money.Money `json:"total"`
+	Currency     string      `json:"currency"` // ISO 4217 code of all amounts
+
+	// Units of Currency per unit of the base currency when the order was placed
+	ExchangeRate float64 `json:"exchange_rate"`
+
+	// Whether item prices include Tax (KDV-inclusive catalog) or Tax was added on top
+	PricesIncludeTax bool `json:"prices_include_tax"`
 	
 	// Payment & Status
-	Status              string `json:"status"` // pending, confirmed, processing, shipped, delivered, cancelled
-	PaymentMethod       string `json:"payment_method"`
-	PaymentStatus       string `json:"payment_status"` // pending, paid, failed, refunded
-	PaymentTransactionID string `json:"payment_transaction_id,omitempty"`
+	Status        string `json:"status"`         // pending, confirmed, processing, shipped, delivered, cancelled
+	PaymentMethod string `json:"payment_method"` // Method for what gift cards and store credit don't cover
+	PaymentStatus string `json:"payment_status"` // pending, paid, failed, partially_refunded, refunded
 	
 	Notes     string    `json:"notes,omitempty"`
+
+	// Set when the order was placed after an abandoned cart recovery email
+	AbandonedCartID *int `json:"abandoned_cart_id,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	
 	// Related data
-	Items []OrderItem `json:"items,omitempty"`
+	Items     []OrderItem     `json:"items,omitempty"`
+	Discounts []OrderDiscount `json:"discounts,omitempty"`
+	Payments  []OrderPayment  `json:"payments,omitempty"`
+	Refunds   []OrderRefund   `json:"refunds,omitempty"`
+	
+	// Signed link token for guests to view the order (only returned at checkout)
+	LookupToken string `json:"lookup_token,omitempty"`
 }
 
 // OrderItem represents a single item in an order
@@ -50,18 +75,60 @@ type OrderItem struct {
 	Size        string `json:"size"`
 	Color       string `json:"color"`
 	
-	Quantity   int     `json:"quantity"`
-	UnitPrice  float64 `json:"unit_price"`
-	TotalPrice float64 `json:"total_price"`
+	Quantity   int         `json:"quantity"`
+	UnitPrice  money.Money `json:"unit_price"`
+	TotalPrice money.Money `json:"total_price"`
+	
+	// Share of the order's promotion and coupon discounts, for pro-rated refunds
+	DiscountAmount money.Money `json:"discount_amount"`
+	
+	// Sale the unit price came from, if any
+	SalePriceID *int `json:"sale_price_id,omitempty"`
+
+	// Tax on the line after discounts, at the rate of the destination and category
+	TaxRate   float64     `json:"tax_rate"`
+	TaxAmount money.Money `json:"tax_amount"`
+
+	// Units refunded so far
+	RefundedQuantity int `json:"refunded_quantity"`
 	
 	CreatedAt time.Time `json:"created_at"`
 }
 
 // CreateOrderRequest is the request to create an order
 type CreateOrderRequest struct {
-	AddressID     int    `json:"address_id"`
-	PaymentMethod string `json:"payment_method"` // credit_card, debit_card, cash_on_delivery
-	Notes         string `json:"notes"`
+	AddressID      int    `json:"address_id"`
+	PaymentMethod  string `json:"payment_method"`  // credit_card, debit_card, cash_on_delivery
+	ShippingMethod string `json:"shipping_method"` // Code from the shipping quote; defaults to standard
+	Currency       string `json:"currency"`        // Currency to charge in; defaults to the base currency
+	Notes          string `json:"notes"`
+
+	// Loyalty points, gift cards and store credit are applied first; the payment method covers the rest
+	GiftCardCodes  []string `json:"gift_card_codes"`
+	UseStoreCredit bool     `json:"use_store_credit"`
+	LoyaltyPoints  int      `json:"loyalty_points"` // Points to redeem; applied before gift cards
+}
+
+// GuestCheckoutRequest is the request to create an order without an account
+type GuestCheckoutRequest struct {
+	Email          string            `json:"email"`
+	Address        AddAddressRequest `json:"address"`
+	PaymentMethod  string            `json:"payment_method"`
+	ShippingMethod string            `json:"shipping_method"`
+	Currency       string            `json:"currency"`
+	Notes          string            `json:"notes"`
+	GiftCardCodes  []string          `json:"gift_card_codes"`
+}
+
+// OrderLookupRequest looks up a guest order by order number and email
+type OrderLookupRequest struct {
+	OrderNumber string `json:"order_number"`
+	Email       string `json:"email"`
+}
+
+// ClaimOrdersRequest attaches guest orders to the logged-in account
+type ClaimOrdersRequest struct {
+	OrderToken string `json:"order_token"`
 }
 
 // Address represents a shipping address
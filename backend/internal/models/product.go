@@ -1,19 +1,28 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
 
 // Product represents a product in the catalog
 type Product struct {
-	ID          int              `json:"id"`
-	Name        string           `json:"name"`
-	Slug        string           `json:"slug"`
-	Description string           `json:"description"`
-	BrandID     *int             `json:"brand_id"`
-	Brand       *Brand           `json:"brand,omitempty"`
-	CategoryID  *int             `json:"category_id"`
-	Category    *Category        `json:"category,omitempty"`
-	BasePrice   float64          `json:"base_price"`
-	IsActive    bool             `json:"is_active"`
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Slug        string      `json:"slug"`
+	Description string      `json:"description"`
+	BrandID     *int        `json:"brand_id"`
+	Brand       *Brand      `json:"brand,omitempty"`
+	CategoryID  *int        `json:"category_id"`
+	Category    *Category   `json:"category,omitempty"`
+	BasePrice   money.Money `json:"base_price"`
+	IsActive    bool        `json:"is_active"`
+	
+	// Lowest active sale price and the regular price it replaces (only while on sale)
+	SalePrice      *money.Money `json:"sale_price,omitempty"`
+	CompareAtPrice *money.Money `json:"compare_at_price,omitempty"`
+	
 	Variants    []ProductVariant `json:"variants,omitempty"`
 	Images      []ProductImage   `json:"images,omitempty"`
 	CreatedAt   time.Time        `json:"created_at"`
@@ -22,15 +31,23 @@ type Product struct {
 
 // ProductVariant represents a size/color combination
 type ProductVariant struct {
-	ID              int     `json:"id"`
-	ProductID       int     `json:"product_id"`
-	SKU             string  `json:"sku"`
-	Size            string  `json:"size"`
-	Color           string  `json:"color"`
-	ColorHex        string  `json:"color_hex"`
-	StockQuantity   int     `json:"stock_quantity"`
-	PriceAdjustment float64 `json:"price_adjustment"`
-	FinalPrice      float64 `json:"final_price"` // Calculated: base_price + price_adjustment
+	ID              int         `json:"id"`
+	ProductID       int         `json:"product_id"`
+	SKU             string      `json:"sku"`
+	Size            string      `json:"size"`
+	Color           string      `json:"color"`
+	ColorHex        string      `json:"color_hex"`
+	StockQuantity   int         `json:"stock_quantity"`
+	PriceAdjustment money.Money `json:"price_adjustment"`
+	WeightGrams     int         `json:"weight_grams"`
+	FinalPrice      money.Money `json:"final_price"` // Calculated: base_price + price_adjustment, or the sale price
+	
+	// Set while a sale applies: the regular price, when the sale ends and
+	// how many units are left in a flash sale
+	CompareAtPrice *money.Money `json:"compare_at_price,omitempty"`
+	SaleEndsAt     *time.Time   `json:"sale_ends_at,omitempty"`
+	SaleRemaining  *int         `json:"sale_remaining,omitempty"`
+	SalePriceID    *int         `json:"-"`
 }
 
 // ProductImage represents a product image
@@ -64,13 +81,14 @@ type Category struct {
 
 // ProductListQuery holds query parameters for product listing
 type ProductListQuery struct {
-	CategoryID *int    `json:"category_id"`
-	BrandID    *int    `json:"brand_id"`
-	MinPrice   *float64 `json:"min_price"`
-	MaxPrice   *float64 `json:"max_price"`
-	Size       string  `json:"size"`
-	Color      string  `json:"color"`
-	Search     string  `json:"search"`
-	Page       int     `json:"page"`
-	Limit      int     `json:"limit"`
+	CategoryID *int         `json:"category_id"`
+	BrandID    *int         `json:"brand_id"`
+	MinPrice   *money.Money `json:"min_price"`
+	MaxPrice   *money.Money `json:"max_price"`
+	Size       string       `json:"size"`
+	Color      string       `json:"color"`
+	Search     string       `json:"search"`
+	OnSale     bool         `json:"on_sale"` // Only products with an active sale
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
 }
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// Promotion types
+const (
+	PromotionBuyXGetY = "buy_x_get_y"
+	PromotionTiered   = "tiered"
+	PromotionBundle   = "bundle"
+)
+
+// Promotion is an automatic discount rule evaluated on every cart and order
+type Promotion struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"` // buy_x_get_y, tiered, bundle
+
+	// Which lines take part (empty = any brand / category)
+	BrandIDs    []int64 `json:"brand_ids"`
+	CategoryIDs []int64 `json:"category_ids"`
+
+	// buy_x_get_y: buy BuyQuantity, get GetQuantity at Percent off (100 = free)
+	BuyQuantity int `json:"buy_quantity,omitempty"`
+	GetQuantity int `json:"get_quantity,omitempty"`
+	// buy_x_get_y and bundle discount percent
+	Percent float64 `json:"percent,omitempty"`
+
+	Tiers        []PromotionTier `json:"tiers,omitempty"`
+	BundleGroups []BundleGroup   `json:"bundle_groups,omitempty"`
+
+	Priority  int        `json:"priority"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// PromotionTier gives Percent off once the eligible subtotal reaches MinSubtotal
+type PromotionTier struct {
+	MinSubtotal money.Money `json:"min_subtotal"`
+	Percent     float64     `json:"percent"`
+}
+
+// BundleGroup is one part of a bundle: Quantity units matching the brand/category lists
+type BundleGroup struct {
+	BrandIDs    []int64 `json:"brand_ids"`
+	CategoryIDs []int64 `json:"category_ids"`
+	Quantity    int     `json:"quantity"`
+}
+
+// PromotionRequest is the admin request to create or update a promotion
+type PromotionRequest struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Type         string          `json:"type"`
+	BrandIDs     []int64         `json:"brand_ids"`
+	CategoryIDs  []int64         `json:"category_ids"`
+	BuyQuantity  int             `json:"buy_quantity"`
+	GetQuantity  int             `json:"get_quantity"`
+	Percent      float64         `json:"percent"`
+	Tiers        []PromotionTier `json:"tiers"`
+	BundleGroups []BundleGroup   `json:"bundle_groups"`
+	Priority     int             `json:"priority"`
+	StartsAt     *time.Time      `json:"starts_at"`
+	EndsAt       *time.Time      `json:"ends_at"`
+	IsActive     *bool           `json:"is_active"` // Defaults to true
+}
+
+// AppliedPromotion is a promotion that discounts a cart or order
+type AppliedPromotion struct {
+	PromotionID int         `json:"promotion_id"`
+	Name        string      `json:"name"`
+	Discount    money.Money `json:"discount"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// OrderRefund records money given back on an order, for some of its lines
+type OrderRefund struct {
+	ID        int               `json:"id"`
+	OrderID   int               `json:"order_id"`
+	Amount    money.Money       `json:"amount"` // Total refunded, tax included
+	Tax       money.Money       `json:"tax"`    // Tax part of Amount
+	Reason    string            `json:"reason,omitempty"`
+	Method    string            `json:"method"` // RefundToOriginal or RefundToStoreCredit
+	CreatedBy *int              `json:"created_by,omitempty"`
+	APIKeyID  *int              `json:"api_key_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Items     []OrderRefundItem `json:"items"`
+
+	// Where the money went: the share given back through each of the order's payments
+	Payments []OrderRefundPayment `json:"payments"`
+}
+
+// OrderRefundItem is the refunded quantity of one order line
+type OrderRefundItem struct {
+	ID          int         `json:"id"`
+	RefundID    int         `json:"refund_id"`
+	OrderItemID int         `json:"order_item_id"`
+	Quantity    int         `json:"quantity"`
+	Amount      money.Money `json:"amount"`
+	TaxAmount   money.Money `json:"tax_amount"`
+}
+
+// OrderRefundPayment is the part of a refund given back through one of the
+// order's payments. Cancelling an order records what it gave back the same way,
+// without a refund.
+type OrderRefundPayment struct {
+	ID             int         `json:"id"`
+	RefundID       int         `json:"refund_id"` // 0 for a cancellation
+	OrderPaymentID int         `json:"order_payment_id"`
+	Amount         money.Money `json:"amount"`
+	Method         string      `json:"method"` // The payment's method, or store credit
+	Status         string      `json:"status"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// Refund payment statuses. Gift cards, store credit and points are given back
+// at once; what was paid by card has to be refunded through the payment provider.
+const (
+	RefundPaymentPending   = "pending"
+	RefundPaymentCompleted = "completed"
+)
+
+// RefundRequest is the admin request to refund some units of an order's lines
+type RefundRequest struct {
+	Items  []RefundItemRequest `json:"items"`
+	Reason string              `json:"reason"`
+
+	// Give the money back as store credit instead of to the original payment
+	ToStoreCredit bool `json:"to_store_credit"`
+}
+
+// Where a refund's money goes
+const (
+	RefundToOriginal    = "original"
+	RefundToStoreCredit = "store_credit"
+)
+
+// RefundItemRequest selects how many units of an order line to refund
+type RefundItemRequest struct {
+	OrderItemID int `json:"order_item_id"`
+	Quantity    int `json:"quantity"`
+}
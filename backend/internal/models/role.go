@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Permissions staff roles can be given; each admin route requires one
+const (
+	PermStatsRead      = "stats:read"
+	PermOrdersRead     = "orders:read"
+	PermOrdersWrite    = "orders:write"
+	PermOrdersRefund   = "orders:refund"
+	PermCatalogRead    = "catalog:read"
+	PermCatalogWrite   = "catalog:write"
+	PermCustomersRead  = "customers:read"
+	PermCustomersWrite = "customers:write"
+	PermMarketingRead  = "marketing:read"
+	PermMarketingWrite = "marketing:write"
+	PermSettingsRead   = "settings:read"
+	PermSettingsWrite  = "settings:write"
+	PermGiftCardsRead  = "gift_cards:read"
+	PermGiftCardsWrite = "gift_cards:write"
+	PermSecurityRead   = "security:read"
+	PermRolesRead      = "roles:read"
+	PermRolesWrite     = "roles:write"
+	PermAPIKeysRead    = "api_keys:read"
+	PermAPIKeysWrite   = "api_keys:write"
+)
+
+// RoleCustomer is the role new accounts get
+const RoleCustomer = "customer"
+
+// Permission is something a role can allow
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Role is a named set of permissions assigned to users. A user whose role has
+// any permission is staff: they can use the admin API and need two-factor
+// authentication.
+type Role struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	BuiltIn     bool      `json:"built_in"` // customer and admin can't be changed or deleted
+	Permissions []string  `json:"permissions"`
+	UserCount   int       `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RoleRequest creates or updates a role (the name can't change after creation)
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// AssignRoleRequest gives a user a role
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// SalePrice is a scheduled sale on a product or a single variant.
+// With a QuantityLimit it is a flash sale that ends once that many units are sold.
+type SalePrice struct {
+	ID               int         `json:"id"`
+	ProductID        *int        `json:"product_id,omitempty"`         // Replaces the product's base price
+	ProductVariantID *int        `json:"product_variant_id,omitempty"` // Sets the variant's final price
+	Price            money.Money `json:"price"`
+	StartsAt         time.Time   `json:"starts_at"`
+	EndsAt           time.Time   `json:"ends_at"`
+	QuantityLimit    *int        `json:"quantity_limit,omitempty"`
+	QuantitySold     int         `json:"quantity_sold"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// Remaining returns how many units are left in a flash sale (nil = unlimited)
+func (s *SalePrice) Remaining() *int {
+	if s.QuantityLimit == nil {
+		return nil
+	}
+	remaining := *s.QuantityLimit - s.QuantitySold
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// SalePriceRequest is the admin request to create or update a sale
+type SalePriceRequest struct {
+	ProductID        *int        `json:"product_id"`
+	ProductVariantID *int        `json:"product_variant_id"`
+	Price            money.Money `json:"price"`
+	StartsAt         time.Time   `json:"starts_at"`
+	EndsAt           time.Time   `json:"ends_at"`
+	QuantityLimit    *int        `json:"quantity_limit"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"ecommerce-backend/internal/money"
+)
+
+// ShippingMethod is a delivery option (standard, express, same day...)
+type ShippingMethod struct {
+	ID          int            `json:"id"`
+	Code        string         `json:"code"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	SortOrder   int            `json:"sort_order"`
+	IsActive    bool           `json:"is_active"`
+	Rates       []ShippingRate `json:"rates"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ShippingRate prices a method for a destination. Country and City narrow it
+// down; empty means any. The most specific matching rate is used.
+type ShippingRate struct {
+	ID               int          `json:"id"`
+	ShippingMethodID int          `json:"shipping_method_id"`
+	Country          string       `json:"country,omitempty"`
+	City             string       `json:"city,omitempty"`
+	Price            money.Money  `json:"price"`
+	PerItemPrice     money.Money  `json:"per_item_price"`
+	PerKgPrice       money.Money  `json:"per_kg_price"` // Per started kilogram
+	MaxWeightGrams   *int         `json:"max_weight_grams,omitempty"`
+	FreeThreshold    *money.Money `json:"free_shipping_threshold,omitempty"`
+	MinDays          int          `json:"min_days"`
+	MaxDays          int          `json:"max_days"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// ShippingMethodRequest is the admin request to create or update a shipping method
+type ShippingMethodRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sort_order"`
+	IsActive    *bool  `json:"is_active"` // Defaults to true
+}
+
+// ShippingRateRequest is the admin request to create or update a shipping rate
+type ShippingRateRequest struct {
+	ShippingMethodID int          `json:"shipping_method_id"`
+	Country          string       `json:"country"`
+	City             string       `json:"city"`
+	Price            money.Money  `json:"price"`
+	PerItemPrice     money.Money  `json:"per_item_price"`
+	PerKgPrice       money.Money  `json:"per_kg_price"`
+	MaxWeightGrams   *int         `json:"max_weight_grams"`
+	FreeThreshold    *money.Money `json:"free_shipping_threshold"`
+	MinDays          int          `json:"min_days"`
+	MaxDays          int          `json:"max_days"`
+}
+
+// ShippingQuote is the price of a shipping method for a cart and destination
+type ShippingQuote struct {
+	Code          string       `json:"code"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	Price         money.Money  `json:"price"`
+	FreeShipping  bool         `json:"free_shipping"`
+	FreeThreshold *money.Money `json:"free_shipping_threshold,omitempty"`
+	AmountToFree  *money.Money `json:"amount_to_free_shipping,omitempty"` // Spend this much more for free shipping
+	MinDays       int          `json:"min_days"`
+	MaxDays       int          `json:"max_days"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TaxRate is the KDV / VAT rate charged for a destination country,
+// optionally only for one category (and its subcategories)
+type TaxRate struct {
+	ID         int       `json:"id"`
+	Country    string    `json:"country"`
+	CategoryID *int      `json:"category_id,omitempty"` // nil = country default
+	Rate       float64   `json:"rate"`                  // Percent, e.g. 20
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TaxRateRequest is the admin request to create or update a tax rate
+type TaxRateRequest struct {
+	Country    string  `json:"country"`
+	CategoryID *int    `json:"category_id"`
+	Rate       float64 `json:"rate"`
+	Name       string  `json:"name"`
+}
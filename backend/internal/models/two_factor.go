@@ -0,0 +1,41 @@
+package models
+
+// TwoFactorStatus tells a user whether two-factor authentication is on
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"` // Staff can't turn it off
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+	SessionVerified   bool `json:"session_verified"` // This login passed a second factor
+}
+
+// TwoFactorSetup is the secret to add to an authenticator app, as text and as
+// an otpauth:// URI for a QR code. Not active until confirmed with a code.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries a code from the user's authenticator app
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorConfirmRequest proves it's the user changing two-factor settings
+// (turning it off, new recovery codes): the password and a current code or recovery code
+type TwoFactorConfirmRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// TwoFactorLoginRequest completes a login with the pre-auth token from the
+// password step and an authenticator or recovery code
+type TwoFactorLoginRequest struct {
+	PreAuthToken string `json:"pre_auth_token"`
+	Code         string `json:"code"`
+}
+
+// RecoveryCodes are one-time codes for logging in without the authenticator.
+// Shown once; only their hashes are stored.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
@@ -4,15 +4,28 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID           int       `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"` // Never send password in JSON
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	Phone        string    `json:"phone,omitempty"`
-	Role         string    `json:"role"` // 'customer' or 'admin'
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int    `json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"` // Never send password in JSON; empty for social sign-in only accounts
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Phone        string `json:"phone,omitempty"`
+	Role         string `json:"role"` // Name of a row in roles, e.g. 'customer' or 'admin'
+
+	// What the role allows in the admin API (empty for customers)
+	Permissions []string `json:"permissions"`
+
+	// When the user followed the verification link (nil until then)
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Whether logging in needs a TOTP code after the password
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+
+	// False for accounts created through social sign-in until a password is set
+	HasPassword bool `json:"has_password"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // RegisterRequest is the request body for user registration
@@ -22,6 +35,9 @@ type RegisterRequest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Phone     string `json:"phone,omitempty"`
+
+	// Optional guest order lookup token; attaches past guest orders to the new account
+	OrderToken string `json:"order_token,omitempty"`
 }
 
 // LoginRequest is the request body for user login
@@ -30,8 +46,62 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-// AuthResponse is returned after successful login/register
+// AuthResponse is returned after successful login/register/refresh. When the
+// account has two-factor authentication, login returns only TwoFactorRequired
+// and a PreAuthToken to send with a code to /auth/2fa/verify.
 type AuthResponse struct {
+	Token        string `json:"token,omitempty"`         // Short-lived access token
+	ExpiresIn    int    `json:"expires_in"`              // Seconds until Token (or PreAuthToken) expires
+	RefreshToken string `json:"refresh_token,omitempty"` // Single use; exchange at /auth/refresh for a new pair
+	User         *User  `json:"user,omitempty"`
+
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	PreAuthToken      string `json:"pre_auth_token,omitempty"`
+}
+
+// RefreshRequest exchanges a refresh token for a new access and refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutRequest ends the session of a refresh token, or all of the user's sessions
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllDevices   bool   `json:"all_devices"`
+}
+
+// ForgotPasswordRequest asks for a password reset link by email
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from the reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmailRequest confirms an email address with the token from the verification link
+type VerifyEmailRequest struct {
 	Token string `json:"token"`
-	User  *User  `json:"user"`
+}
+
+// ClientInfo identifies the device a request comes from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session is a login on one device; its refresh token rotates on every refresh
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	TwoFactor bool `json:"two_factor"` // The login passed a second factor
 }
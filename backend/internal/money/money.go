@@ -0,0 +1,398 @@
+// Package money represents prices as integer minor units (e.g. kuruş or cents)
+// so totals never pick up floating point error.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the store's base currency (ISO 4217). Stored prices are in
+// this currency; it is set once at startup from the config.
+var DefaultCurrency = "TRY"
+
+// minorDigits is the number of decimal places of the minor unit.
+// All currencies the store deals in (TRY, EUR, USD) use 2.
+const minorDigits = 2
+
+const minorScale = 100
+
+// Money is an amount in minor units of a currency.
+// The zero value is 0 in DefaultCurrency.
+//
+// In JSON it is a plain number with two decimals (12.50) and in SQL a
+// DECIMAL string, so the API and schema keep their existing shape.
+type Money struct {
+	Amount   int64  // Minor units
+	Currency string // ISO 4217, empty means DefaultCurrency
+}
+
+// New returns amount minor units of currency
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromMinor returns amount minor units of the default currency
+func FromMinor(amount int64) Money {
+	return Money{Amount: amount}
+}
+
+// FromFloat converts a float amount in major units, rounding half away from zero.
+// Only for values that are floats by nature (e.g. external input); never for totals.
+func FromFloat(amount float64) Money {
+	return Money{Amount: int64(math.Round(amount * minorScale))}
+}
+
+// Parse parses a decimal string like "12", "12.5" or "-0.05".
+// Extra decimal places are rounded half away from zero.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, errors.New("empty amount")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/minorScale-1 {
+		return Money{}, fmt.Errorf("amount %q out of range", s)
+	}
+
+	// Pad or cut the fraction to the minor digits, rounding on the first cut digit
+	roundUp := false
+	if len(frac) > minorDigits {
+		roundUp = frac[minorDigits] >= '5'
+		frac = frac[:minorDigits]
+	}
+	frac += strings.Repeat("0", minorDigits-len(frac))
+	minor, _ := strconv.ParseInt(frac, 10, 64)
+
+	amount := units*minorScale + minor
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount}, nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrencyCode returns the currency, resolving empty to DefaultCurrency
+func (m Money) CurrencyCode() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// In returns the same amount tagged with currency
+func (m Money) In(currency string) Money {
+	return Money{Amount: m.Amount, Currency: currency}
+}
+
+// String formats the amount with two decimals, e.g. "1234.50"
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/minorScale, amount%minorScale)
+}
+
+// Float64 returns the amount in major units, for display and third-party APIs only
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / minorScale
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Equal reports whether two amounts are the same (currencies must match)
+func (m Money) Equal(o Money) bool {
+	m.mustMatch(o)
+	return m.Amount == o.Amount
+}
+
+// LessThan reports whether m < o (currencies must match)
+func (m Money) LessThan(o Money) bool {
+	m.mustMatch(o)
+	return m.Amount < o.Amount
+}
+
+// GreaterThan reports whether m > o (currencies must match)
+func (m Money) GreaterThan(o Money) bool {
+	m.mustMatch(o)
+	return m.Amount > o.Amount
+}
+
+// Add returns m + o (currencies must match)
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return Money{Amount: m.Amount + o.Amount, Currency: m.pick(o)}
+}
+
+// Sub returns m - o (currencies must match)
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return Money{Amount: m.Amount - o.Amount, Currency: m.pick(o)}
+}
+
+// Mul returns m * n, e.g. a unit price times a quantity
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Min returns the smaller of m and o (currencies must match)
+func (m Money) Min(o Money) Money {
+	if o.LessThan(m) {
+		return o
+	}
+	return m
+}
+
+// Max returns the larger of m and o (currencies must match)
+func (m Money) Max(o Money) Money {
+	if o.GreaterThan(m) {
+		return o
+	}
+	return m
+}
+
+// Percent returns p percent of m, rounded half away from zero to the minor unit.
+// p is taken to two decimals (e.g. 12.5 or 18).
+func (m Money) Percent(p float64) Money {
+	basisPoints := int64(math.Round(p * 100))
+	return Money{Amount: divRound(m.Amount*basisPoints, 100*100), Currency: m.Currency}
+}
+
+// Ratio returns m * num / den, rounded half away from zero to the minor unit.
+// A zero den is a programming error and panics; callers pass denominators that
+// can't be zero (order line quantities, 100% plus a tax rate).
+func (m Money) Ratio(num, den int64) Money {
+	if den == 0 {
+		panic("money: ratio with zero denominator")
+	}
+	return Money{Amount: divRound(m.Amount*num, den), Currency: m.Currency}
+}
+
+// rateScale is the precision of exchange rates (8 decimals)
+const rateScale = 100000000
+
+// Convert returns m in another currency at rate (units of currency per unit of
+// m's currency, taken to 8 decimals), rounded half away from zero to the minor unit.
+// The product is computed with big integers since it can exceed int64.
+func (m Money) Convert(currency string, rate float64) Money {
+	n := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(int64(math.Round(rate*rateScale))))
+	q, r := new(big.Int).QuoRem(n, big.NewInt(rateScale), new(big.Int))
+	if r.Abs(r).Cmp(big.NewInt(rateScale/2)) >= 0 {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+	return Money{Amount: q.Int64(), Currency: currency}
+}
+
+// Allocate splits m over the given weights in proportion, so that the parts
+// always add up to exactly m. Leftover minor units go to the largest
+// remainders first (ties to the earlier part). Zero weights get nothing.
+func (m Money) Allocate(weights []int64) []Money {
+	parts := make([]Money, len(weights))
+	var total int64
+	for _, w := range weights {
+		if w > 0 {
+			total += w
+		}
+	}
+	for i := range parts {
+		parts[i].Currency = m.Currency
+	}
+	if total == 0 {
+		return parts
+	}
+
+	sign := int64(1)
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = -1, -amount
+	}
+
+	remainders := make([]int64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		parts[i].Amount = amount * w / total
+		remainders[i] = amount * w % total
+		allocated += parts[i].Amount
+	}
+
+	for left := amount - allocated; left > 0; left-- {
+		best := -1
+		for i, w := range weights {
+			if w > 0 && (best < 0 || remainders[i] > remainders[best]) {
+				best = i
+			}
+		}
+		parts[best].Amount++
+		remainders[best] = -1
+	}
+
+	for i := range parts {
+		parts[i].Amount *= sign
+	}
+	return parts
+}
+
+// Sum adds amounts (currencies must match); the sum of nothing is zero
+func Sum(amounts ...Money) Money {
+	var total Money
+	for _, a := range amounts {
+		total = total.Add(a)
+	}
+	return total
+}
+
+// divRound divides rounding half away from zero
+func divRound(n, d int64) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	q, r := n/d, n%d
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= d {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// mustMatch panics when two non-zero amounts are in different currencies.
+// Mixing currencies is a programming error: amounts are only combined after
+// conversion, and checkout refuses base currency balances (loyalty points, gift
+// cards, store credit) for orders in other currencies (see checkBalanceCurrency).
+func (m Money) mustMatch(o Money) {
+	if m.Amount != 0 && o.Amount != 0 && m.CurrencyCode() != o.CurrencyCode() {
+		panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.CurrencyCode(), o.CurrencyCode()))
+	}
+}
+
+// pick keeps the explicit currency of either operand
+func (m Money) pick(o Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	return o.Currency
+}
+
+// MarshalJSON writes the amount as a number with two decimals
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a number or a numeric string, without going through float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
+
+// Scan reads a DECIMAL column
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money{Amount: v * minorScale}
+		return nil
+	case float64:
+		*m = FromFloat(v)
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
+
+// Value writes the amount as a DECIMAL string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,327 @@
+package money
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{"even split", 90, []int64{1, 1, 1}, []int64{30, 30, 30}},
+		{"leftover to earlier part on ties", 100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{"leftover to largest remainder", 100, []int64{1, 2, 4}, []int64{14, 29, 57}},
+		{"negative amount", -100, []int64{1, 1, 1}, []int64{-34, -33, -33}},
+		{"zero weights get nothing", 7, []int64{0, 3, 0, 4}, []int64{0, 3, 0, 4}},
+		{"negative weights get nothing", 10, []int64{-5, 1, 1}, []int64{0, 5, 5}},
+		{"all weights zero", 10, []int64{0, 0}, []int64{0, 0}},
+		{"no weights", 10, []int64{}, []int64{}},
+		{"zero amount", 0, []int64{1, 2}, []int64{0, 0}},
+		{"one minor unit", 1, []int64{1, 1, 1}, []int64{1, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := New(tt.amount, "EUR").Allocate(tt.weights)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.want))
+			}
+			for i, part := range parts {
+				if part.Amount != tt.want[i] {
+					t.Errorf("part %d = %d, want %d", i, part.Amount, tt.want[i])
+				}
+				if part.Currency != "EUR" {
+					t.Errorf("part %d currency = %q, want EUR", i, part.Currency)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateAddsUp(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		amount := rng.Int63n(2000001) - 1000000
+		weights := make([]int64, rng.Intn(8))
+		hasWeight := false
+		for j := range weights {
+			// Include zero and negative weights, which must get nothing
+			weights[j] = rng.Int63n(1001) - 100
+			if weights[j] <= 0 {
+				weights[j] = 0
+			} else {
+				hasWeight = true
+			}
+		}
+
+		parts := FromMinor(amount).Allocate(weights)
+		var sum int64
+		for j, part := range parts {
+			if weights[j] == 0 && part.Amount != 0 {
+				t.Fatalf("Allocate(%d, %v): zero weight %d got %d", amount, weights, j, part.Amount)
+			}
+			if (amount < 0 && part.Amount > 0) || (amount > 0 && part.Amount < 0) {
+				t.Fatalf("Allocate(%d, %v): part %d has the wrong sign: %d", amount, weights, j, part.Amount)
+			}
+			sum += part.Amount
+		}
+
+		want := amount
+		if !hasWeight {
+			want = 0
+		}
+		if sum != want {
+			t.Fatalf("Allocate(%d, %v) parts sum to %d, want %d", amount, weights, sum, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"12", 1200},
+		{"12.5", 1250},
+		{"12.50", 1250},
+		{"-0.05", -5},
+		{"+1.10", 110},
+		{".5", 50},
+		{" 3.99 ", 399},
+		{"0.005", 1},
+		{"-0.005", -1},
+		{"0.004", 0},
+		{"1.995", 200},
+		{"-1.995", -200},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got.Amount != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{"", " ", "abc", "1.2.3", "1,50", "1e5", "--1", "99999999999999999999", "-", "+", ".", "-.", "+."} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseStringRoundTrip(t *testing.T) {
+	amounts := []int64{0, 1, -1, 5, -5, 99, 100, -100, 123456, -123456}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		amounts = append(amounts, rng.Int63n(1<<50)-1<<49)
+	}
+
+	for _, amount := range amounts {
+		s := FromMinor(amount).String()
+		got, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", s, err)
+		}
+		if got.Amount != amount {
+			t.Fatalf("Parse(FromMinor(%d).String() = %q) = %d", amount, s, got.Amount)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount int64
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{123450, "1234.50"},
+		{-123450, "-1234.50"},
+	}
+
+	for _, tt := range tests {
+		if got := FromMinor(tt.amount).String(); got != tt.want {
+			t.Errorf("FromMinor(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestPercentRoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		percent float64
+		want    int64
+	}{
+		{1, 50, 1},     // 0.5
+		{-1, 50, -1},   // -0.5
+		{3, 50, 2},     // 1.5
+		{-3, 50, -2},   // -1.5
+		{1, 49.99, 0},  // 0.4999
+		{-1, 49.99, 0}, // -0.4999
+		{1000, 18, 180},
+		{1250, 12.5, 156}, // 156.25
+		{-1250, 12.5, -156},
+		{1000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := FromMinor(tt.amount).Percent(tt.percent).Amount; got != tt.want {
+			t.Errorf("FromMinor(%d).Percent(%v) = %d, want %d", tt.amount, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestRatioRoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		amount, num, den int64
+		want             int64
+	}{
+		{5, 1, 2, 3},   // 2.5
+		{-5, 1, 2, -3}, // -2.5
+		{5, -1, 2, -3},
+		{5, 1, -2, -3},
+		{-5, 1, -2, 3},
+		{4, 1, 3, 1}, // 1.33
+		{5, 1, 3, 2}, // 1.67
+		{-4, 1, 3, -1},
+		{-5, 1, 3, -2},
+		{1800, 1800, 11800, 275}, // Tax part of a tax-inclusive 18.00 at 18%: 274.58
+	}
+
+	for _, tt := range tests {
+		if got := FromMinor(tt.amount).Ratio(tt.num, tt.den).Amount; got != tt.want {
+			t.Errorf("FromMinor(%d).Ratio(%d, %d) = %d, want %d", tt.amount, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestConvertRoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		amount int64
+		rate   float64
+		want   int64
+	}{
+		{1, 0.5, 1},   // 0.5
+		{-1, 0.5, -1}, // -0.5
+		{3, 0.5, 2},   // 1.5
+		{-3, 0.5, -2}, // -1.5
+		{1, 0.49999999, 0},
+		{1000, 0.02815, 28}, // 28.15
+		{10000, 35.12345678, 351235},
+		{1e15, 2, 2e15}, // amount * scaled rate is past int64
+	}
+
+	for _, tt := range tests {
+		got := FromMinor(tt.amount).Convert("EUR", tt.rate)
+		if got.Amount != tt.want {
+			t.Errorf("FromMinor(%d).Convert(%v) = %d, want %d", tt.amount, tt.rate, got.Amount, tt.want)
+		}
+		if got.Currency != "EUR" {
+			t.Errorf("FromMinor(%d).Convert(%v) currency = %q, want EUR", tt.amount, tt.rate, got.Currency)
+		}
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct {
+		n, d, want int64
+	}{
+		{0, 5, 0},
+		{4, 2, 2},
+		{5, 2, 3},
+		{-5, 2, -3},
+		{5, -2, -3},
+		{-5, -2, 3},
+		{1, 3, 0},
+		{-1, 3, 0},
+		{2, 3, 1},
+		{-2, 3, -1},
+		{-7, 3, -2},
+		{-8, 3, -3},
+		{7, -3, -2},
+		{8, -3, -3},
+		{-8, -3, 3},
+	}
+
+	for _, tt := range tests {
+		if got := divRound(tt.n, tt.d); got != tt.want {
+			t.Errorf("divRound(%d, %d) = %d, want %d", tt.n, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestCurrencyMismatchPanics(t *testing.T) {
+	eur := New(100, "EUR")
+	try := New(100, "TRY")
+
+	ops := map[string]func(){
+		"Add":         func() { eur.Add(try) },
+		"Sub":         func() { eur.Sub(try) },
+		"Min":         func() { eur.Min(try) },
+		"Max":         func() { eur.Max(try) },
+		"LessThan":    func() { eur.LessThan(try) },
+		"GreaterThan": func() { eur.GreaterThan(try) },
+		"Equal":       func() { eur.Equal(try) },
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s of EUR and TRY didn't panic", name)
+				}
+			}()
+			op()
+		})
+	}
+}
+
+func TestCurrencyMatch(t *testing.T) {
+	// An empty currency is the default currency
+	if got := New(100, "TRY").Add(FromMinor(50)); got.Amount != 150 || got.Currency != "TRY" {
+		t.Errorf("TRY + default = %v %s, want 1.50 TRY", got, got.Currency)
+	}
+	// Zero takes any currency, so sums can start from the zero value
+	if got := Sum(New(100, "EUR"), New(50, "EUR")); got.Amount != 150 || got.Currency != "EUR" {
+		t.Errorf("Sum = %v %s, want 1.50 EUR", got, got.Currency)
+	}
+	if got := New(0, "TRY").Add(New(100, "EUR")); got.Amount != 100 {
+		t.Errorf("0 TRY + 1.00 EUR = %v, want 1.00", got)
+	}
+}
+
+func TestRatioZeroDenominatorPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Ratio with a zero denominator didn't panic")
+		}
+	}()
+	FromMinor(100).Ratio(1, 0)
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, amount := range []int64{0, 1, -1, 1250, -99999} {
+		m := FromMinor(amount)
+		data, err := m.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON(%d) error: %v", amount, err)
+		}
+		var got Money
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Fatalf("UnmarshalJSON(%s) error: %v", data, err)
+		}
+		if got.Amount != amount {
+			t.Errorf("JSON round trip of %d gave %d", amount, got.Amount)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"encoding/json"
+	"time"
+)
+
+type AbandonedCartRepository struct {
+	db *sql.DB
+}
+
+func NewAbandonedCartRepository(db *sql.DB) *AbandonedCartRepository {
+	return &AbandonedCartRepository{db: db}
+}
+
+// FindIdleCarts finds user carts whose last activity is between notBefore and idleBefore
+// and that haven't been recorded as abandoned for that activity yet.
+// Guest carts are skipped since there's no one to email.
+func (r *AbandonedCartRepository) FindIdleCarts(idleBefore, notBefore time.Time) ([]models.AbandonedCart, error) {
+	query := `
+		WITH idle AS (
+			SELECT c.user_id,
+			       MAX(c.updated_at) AS last_activity,
+			       json_agg(json_build_object(
+			           'product_variant_id', c.product_variant_id,
+			           'quantity', c.quantity
+			       )) AS items
+			FROM cart c
+			WHERE c.user_id IS NOT NULL AND c.saved_for_later = false
+			GROUP BY c.user_id
+			HAVING MAX(c.updated_at) < $1 AND MAX(c.updated_at) >= $2
+		)
+		SELECT i.user_id, u.email, u.first_name, i.last_activity, i.items
+		FROM idle i
+		JOIN users u ON u.id = i.user_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM abandoned_carts a
+			WHERE a.user_id = i.user_id AND a.last_activity_at = i.last_activity
+		)
+	`
+
+	rows, err := r.db.Query(query, idleBefore, notBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := []models.AbandonedCart{}
+	for rows.Next() {
+		var ac models.AbandonedCart
+		var items []byte
+		if err := rows.Scan(&ac.UserID, &ac.Email, &ac.FirstName, &ac.LastActivityAt, &items); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(items, &ac.Items); err != nil {
+			return nil, err
+		}
+		carts = append(carts, ac)
+	}
+
+	return carts, rows.Err()
+}
+
+// Create records a detected abandoned cart.
+// Returns false if the same cart activity was already recorded (e.g. by another instance).
+func (r *AbandonedCartRepository) Create(ac *models.AbandonedCart) (bool, error) {
+	items, err := json.Marshal(ac.Items)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO abandoned_carts (user_id, last_activity_at, items, cart_total)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, last_activity_at) DO NOTHING
+		RETURNING id, created_at
+	`
+	err = r.db.QueryRow(query, ac.UserID, ac.LastActivityAt, items, ac.CartTotal).Scan(&ac.ID, &ac.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MarkNotified links the recovery notification to the abandoned cart
+func (r *AbandonedCartRepository) MarkNotified(id, notificationID int) error {
+	query := `
+		UPDATE abandoned_carts
+		SET notification_id = $2, notified_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, notificationID)
+	return err
+}
+
+// GetByID retrieves an abandoned cart
+func (r *AbandonedCartRepository) GetByID(id int) (*models.AbandonedCart, error) {
+	query := `
+		SELECT a.id, a.user_id, u.email, a.last_activity_at, a.items, a.cart_total,
+		       a.notification_id, a.notified_at, a.clicked_at,
+		       a.recovered_order_id, a.recovered_at, a.created_at
+		FROM abandoned_carts a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.id = $1
+	`
+
+	ac := &models.AbandonedCart{}
+	var items []byte
+	err := r.db.QueryRow(query, id).Scan(
+		&ac.ID, &ac.UserID, &ac.Email, &ac.LastActivityAt, &items, &ac.CartTotal,
+		&ac.NotificationID, &ac.NotifiedAt, &ac.ClickedAt,
+		&ac.RecoveredOrderID, &ac.RecoveredAt, &ac.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(items, &ac.Items); err != nil {
+		return nil, err
+	}
+	return ac, nil
+}
+
+// MarkClicked records the first time the recovery link was opened
+func (r *AbandonedCartRepository) MarkClicked(id int) error {
+	query := `UPDATE abandoned_carts SET clicked_at = COALESCE(clicked_at, CURRENT_TIMESTAMP) WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// FindAttributable returns the latest not yet recovered abandoned cart of a user
+// whose recovery link was clicked and that was notified after since, or 0 if
+// there is none
+func (r *AbandonedCartRepository) FindAttributable(userID int, since time.Time) (int, error) {
+	query := `
+		SELECT id FROM abandoned_carts
+		WHERE user_id = $1 AND notified_at >= $2 AND clicked_at IS NOT NULL AND recovered_order_id IS NULL
+		ORDER BY notified_at DESC
+		LIMIT 1
+	`
+
+	var id int
+	err := r.db.QueryRow(query, userID, since).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// MarkRecovered links an order to the abandoned cart it recovered.
+// Returns false if the cart was already recovered by another order.
+func (r *AbandonedCartRepository) MarkRecovered(id, orderID int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE abandoned_carts
+		SET recovered_order_id = $2, recovered_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND recovered_order_id IS NULL
+	`
+	result, err := tx.Exec(query, id, orderID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n != 1 {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`UPDATE orders SET abandoned_cart_id = $1 WHERE id = $2`, id, orderID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// GetStats summarizes abandoned cart recovery
+func (r *AbandonedCartRepository) GetStats() (*models.AbandonedCartStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(a.notified_at),
+		       COUNT(a.clicked_at),
+		       COUNT(a.recovered_order_id),
+		       COALESCE(SUM(o.total), 0)
+		FROM abandoned_carts a
+		LEFT JOIN orders o ON o.id = a.recovered_order_id
+	`
+
+	stats := &models.AbandonedCartStats{}
+	err := r.db.QueryRow(query).Scan(
+		&stats.Detected, &stats.Notified, &stats.Clicked, &stats.Recovered, &stats.RecoveredRevenue,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Notified > 0 {
+		stats.RecoveryRate = float64(stats.Recovered) / float64(stats.Notified)
+	}
+	return stats, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns is the column list shared by all API key queries (see scanAPIKey)
+const apiKeyColumns = `id, name, key_prefix, scopes, created_by, expires_at, last_used_at,
+		       COALESCE(last_used_ip, ''), revoked_at, revoked_by, created_at`
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.ExpiresAt,
+		&key.LastUsedAt, &key.LastUsedIP, &key.RevokedAt, &key.RevokedBy, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Create inserts an API key; only the hash of the key itself is stored
+func (r *APIKeyRepository) Create(key *models.APIKey, keyHash string) error {
+	return r.db.QueryRow(`
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes), key.CreatedBy, key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetAll retrieves all API keys, newest first
+func (r *APIKeyRepository) GetAll() ([]models.APIKey, error) {
+	rows, err := r.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetByID retrieves an API key by ID
+func (r *APIKeyRepository) GetByID(id int) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// Use looks up a usable (unrevoked, unexpired) key by hash and records it as
+// used from ip. Returns nil if there is no such key.
+func (r *APIKeyRepository) Use(keyHash, ip string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(`
+		UPDATE api_keys
+		SET last_used_at = CURRENT_TIMESTAMP, last_used_ip = $2
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		RETURNING `+apiKeyColumns,
+		keyHash, nullIfEmpty(ip)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// Revoke stops a key from working. Returns false if it doesn't exist or is
+// already revoked.
+func (r *APIKeyRepository) Revoke(id int, revokedBy *int) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP, revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id, revokedBy)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// LogRequest records a request made with a key
+func (r *APIKeyRepository) LogRequest(entry *models.APIKeyRequestLog) error {
+	return r.db.QueryRow(`
+		INSERT INTO api_key_requests (api_key_id, method, path, status, ip_address)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, entry.APIKeyID, entry.Method, entry.Path, entry.Status, nullIfEmpty(entry.IPAddress)).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// GetRequests retrieves the latest requests made with a key
+func (r *APIKeyRepository) GetRequests(keyID, limit int) ([]models.APIKeyRequestLog, error) {
+	rows, err := r.db.Query(`
+		SELECT id, api_key_id, method, path, status, COALESCE(ip_address, ''), created_at
+		FROM api_key_requests
+		WHERE api_key_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, keyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.APIKeyRequestLog{}
+	for rows.Next() {
+		var e models.APIKeyRequestLog
+		if err := rows.Scan(&e.ID, &e.APIKeyID, &e.Method, &e.Path, &e.Status, &e.IPAddress, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
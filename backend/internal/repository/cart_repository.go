@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"fmt"
 )
 
 type CartRepository struct {
@@ -13,16 +15,72 @@ func NewCartRepository(db *sql.DB) *CartRepository {
 	return &CartRepository{db: db}
 }
 
+// cartColumns is the column list shared by cart queries (see scanCartItem)
+const cartColumns = `c.id, COALESCE(c.user_id, 0), COALESCE(c.guest_id, ''), c.product_variant_id,
+		       c.quantity, c.unit_price, c.saved_for_later, c.created_at, c.updated_at`
+
+// scanCartItem scans a row selected with cartColumns
+func scanCartItem(row rowScanner) (*models.CartItem, error) {
+	item := &models.CartItem{}
+	err := row.Scan(
+		&item.ID, &item.UserID, &item.GuestID, &item.ProductVariantID,
+		&item.Quantity, &item.AddedUnitPrice, &item.SavedForLater, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ownerColumn returns the cart column and value identifying the cart owner
+func ownerColumn(owner models.CartOwner) (string, interface{}) {
+	if owner.IsGuest() {
+		return "guest_id", owner.GuestID
+	}
+	return "user_id", owner.UserID
+}
+
+// GetItem gets a single cart line of the owner's cart
+func (r *CartRepository) GetItem(cartItemID int, owner models.CartOwner) (*models.CartItem, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		SELECT `+cartColumns+`
+		FROM cart c
+		WHERE c.id = $1 AND c.%s = $2
+	`, column)
+
+	item, err := scanCartItem(r.db.QueryRow(query, cartItemID, value))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
 // GetUserCart gets all items in user's cart
 func (r *CartRepository) GetUserCart(userID int) ([]models.CartItem, error) {
-	query := `
-		SELECT c.id, c.user_id, c.product_variant_id, c.quantity, c.created_at, c.updated_at
+	return r.GetCart(models.CartOwner{UserID: userID})
+}
+
+// GetCart gets all items in a user's or guest's cart (excluding saved-for-later lines)
+func (r *CartRepository) GetCart(owner models.CartOwner) ([]models.CartItem, error) {
+	return r.getLines(owner, false)
+}
+
+// GetSavedItems gets the owner's saved-for-later lines
+func (r *CartRepository) GetSavedItems(owner models.CartOwner) ([]models.CartItem, error) {
+	return r.getLines(owner, true)
+}
+
+func (r *CartRepository) getLines(owner models.CartOwner, saved bool) ([]models.CartItem, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		SELECT `+cartColumns+`
 		FROM cart c
-		WHERE c.user_id = $1
+		WHERE c.%s = $1 AND c.saved_for_later = $2
 		ORDER BY c.created_at DESC
-	`
+	`, column)
 	
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Query(query, value, saved)
 	if err != nil {
 		return nil, err
 	}
@@ -30,57 +88,151 @@ func (r *CartRepository) GetUserCart(userID int) ([]models.CartItem, error) {
 
 	items := []models.CartItem{}
 	for rows.Next() {
-		var item models.CartItem
-		err := rows.Scan(
-			&item.ID, &item.UserID, &item.ProductVariantID,
-			&item.Quantity, &item.CreatedAt, &item.UpdatedAt,
-		)
+		item, err := scanCartItem(rows)
 		if err != nil {
 			return nil, err
 		}
-		items = append(items, item)
+		items = append(items, *item)
 	}
 
 	return items, nil
 }
 
-// AddItem adds or updates item in cart
-func (r *CartRepository) AddItem(userID, variantID, quantity int) error {
-	query := `
-		INSERT INTO cart (user_id, product_variant_id, quantity)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (user_id, product_variant_id)
-		DO UPDATE SET quantity = cart.quantity + $3, updated_at = CURRENT_TIMESTAMP
-	`
-	_, err := r.db.Exec(query, userID, variantID, quantity)
+// AddItem adds or updates item in cart, remembering the current unit price.
+// Adding a variant that was saved for later moves it back to the cart with the new quantity.
+func (r *CartRepository) AddItem(owner models.CartOwner, variantID, quantity int, unitPrice money.Money) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		INSERT INTO cart (%s, product_variant_id, quantity, unit_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (%s, product_variant_id)
+		DO UPDATE SET quantity = CASE WHEN cart.saved_for_later THEN $3 ELSE cart.quantity + $3 END,
+		              unit_price = $4,
+		              saved_for_later = false,
+		              updated_at = CURRENT_TIMESTAMP
+	`, column, column)
+	_, err := r.db.Exec(query, value, variantID, quantity, unitPrice)
 	return err
 }
 
+// AddItemIfMissing adds a line only if the variant isn't in the cart yet.
+// Returns whether a line was added.
+func (r *CartRepository) AddItemIfMissing(owner models.CartOwner, variantID, quantity int, unitPrice money.Money) (bool, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		INSERT INTO cart (%s, product_variant_id, quantity, unit_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (%s, product_variant_id) DO NOTHING
+	`, column, column)
+	result, err := r.db.Exec(query, value, variantID, quantity, unitPrice)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 // UpdateQuantity updates item quantity
-func (r *CartRepository) UpdateQuantity(cartItemID, userID, quantity int) error {
-	query := `
+func (r *CartRepository) UpdateQuantity(cartItemID int, owner models.CartOwner, quantity int) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
 		UPDATE cart 
 		SET quantity = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2 AND user_id = $3
-	`
-	_, err := r.db.Exec(query, quantity, cartItemID, userID)
+		WHERE id = $2 AND %s = $3
+	`, column)
+	_, err := r.db.Exec(query, quantity, cartItemID, value)
+	return err
+}
+
+// SetUnitPrice updates the remembered unit price of a cart line (customer accepted a new price)
+func (r *CartRepository) SetUnitPrice(cartItemID int, owner models.CartOwner, unitPrice money.Money) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		UPDATE cart 
+		SET unit_price = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND %s = $3
+	`, column)
+	_, err := r.db.Exec(query, unitPrice, cartItemID, value)
+	return err
+}
+
+// SetSavedForLater moves a line between the cart and the saved-for-later list
+func (r *CartRepository) SetSavedForLater(cartItemID int, owner models.CartOwner, saved bool) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		UPDATE cart 
+		SET saved_for_later = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND %s = $3
+	`, column)
+	_, err := r.db.Exec(query, saved, cartItemID, value)
 	return err
 }
 
 // RemoveItem removes item from cart
-func (r *CartRepository) RemoveItem(cartItemID, userID int) error {
-	query := `DELETE FROM cart WHERE id = $1 AND user_id = $2`
-	_, err := r.db.Exec(query, cartItemID, userID)
+func (r *CartRepository) RemoveItem(cartItemID int, owner models.CartOwner) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`DELETE FROM cart WHERE id = $1 AND %s = $2`, column)
+	_, err := r.db.Exec(query, cartItemID, value)
 	return err
 }
 
 // ClearCart removes all items from user's cart
 func (r *CartRepository) ClearCart(userID int) error {
-	query := `DELETE FROM cart WHERE user_id = $1`
-	_, err := r.db.Exec(query, userID)
+	return r.Clear(models.CartOwner{UserID: userID})
+}
+
+// Clear removes all items from a user's or guest's cart.
+// Saved-for-later lines are kept.
+func (r *CartRepository) Clear(owner models.CartOwner) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`DELETE FROM cart WHERE %s = $1 AND saved_for_later = false`, column)
+	_, err := r.db.Exec(query, value)
 	return err
 }
 
+// MergeGuestCart moves a guest cart into a user's cart.
+// Quantities for the same variant are combined and capped at available stock;
+// out-of-stock lines are dropped unless they were saved for later.
+func (r *CartRepository) MergeGuestCart(guestID string, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Active lines are limited to what is in stock; saved-for-later lines are
+	// carried over as they are, since they don't reserve anything
+	merge := `
+		INSERT INTO cart (user_id, product_variant_id, quantity, unit_price, saved_for_later)
+		SELECT $2, g.product_variant_id,
+			CASE WHEN g.saved_for_later THEN g.quantity ELSE LEAST(g.quantity, pv.stock_quantity) END,
+			g.unit_price, g.saved_for_later
+		FROM cart g
+		JOIN product_variants pv ON pv.id = g.product_variant_id
+		WHERE g.guest_id = $1 AND (g.saved_for_later OR pv.stock_quantity > 0)
+		ON CONFLICT (user_id, product_variant_id)
+		DO UPDATE SET quantity = CASE
+			WHEN cart.saved_for_later AND EXCLUDED.saved_for_later THEN cart.quantity + EXCLUDED.quantity
+			WHEN EXCLUDED.saved_for_later THEN cart.quantity
+			ELSE LEAST(
+				cart.quantity + EXCLUDED.quantity,
+				(SELECT stock_quantity FROM product_variants WHERE id = EXCLUDED.product_variant_id)
+			)
+		END,
+		saved_for_later = cart.saved_for_later AND EXCLUDED.saved_for_later,
+		updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.Exec(merge, guestID, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM cart WHERE guest_id = $1`, guestID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // GetVariantByID gets a product variant
 func (r *CartRepository) GetVariantByID(variantID int) (*models.ProductVariant, error) {
 	variant := &models.ProductVariant{}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"strings"
+)
+
+type CatalogRepository struct {
+	db *sql.DB
+}
+
+func NewCatalogRepository(db *sql.DB) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+// BeginTx starts a transaction for a catalog import
+func (r *CatalogRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// GetBrandIDsBySlug returns a slug -> id map of all brands
+func (r *CatalogRepository) GetBrandIDsBySlug() (map[string]int, error) {
+	return r.slugMap(`SELECT id, slug FROM brands`)
+}
+
+// GetCategoryIDsBySlug returns a slug -> id map of all categories
+func (r *CatalogRepository) GetCategoryIDsBySlug() (map[string]int, error) {
+	return r.slugMap(`SELECT id, slug FROM categories`)
+}
+
+func (r *CatalogRepository) slugMap(query string) (map[string]int, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int{}
+	for rows.Next() {
+		var id int
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, err
+		}
+		ids[slug] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// UpsertProduct inserts or updates a product by slug.
+// Returns the product ID and whether it was newly created.
+func (r *CatalogRepository) UpsertProduct(tx *sql.Tx, p *models.Product) (int, bool, error) {
+	query := `
+		INSERT INTO products (name, slug, description, brand_id, category_id, base_price, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (slug)
+		DO UPDATE SET name = EXCLUDED.name,
+		              description = EXCLUDED.description,
+		              brand_id = EXCLUDED.brand_id,
+		              category_id = EXCLUDED.category_id,
+		              base_price = EXCLUDED.base_price,
+		              is_active = EXCLUDED.is_active,
+		              updated_at = CURRENT_TIMESTAMP
+		RETURNING id, (xmax = 0)
+	`
+
+	var id int
+	var created bool
+	err := tx.QueryRow(
+		query,
+		p.Name, p.Slug, p.Description, p.BrandID, p.CategoryID, p.BasePrice, p.IsActive,
+	).Scan(&id, &created)
+
+	return id, created, err
+}
+
+// UpsertVariant inserts or updates a product variant by SKU.
+// Returns whether the variant was newly created.
+func (r *CatalogRepository) UpsertVariant(tx *sql.Tx, v *models.ProductVariant) (bool, error) {
+	query := `
+		INSERT INTO product_variants (product_id, sku, size, color, color_hex, stock_quantity, price_adjustment, weight_grams)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (sku)
+		DO UPDATE SET product_id = EXCLUDED.product_id,
+		              size = EXCLUDED.size,
+		              color = EXCLUDED.color,
+		              color_hex = EXCLUDED.color_hex,
+		              stock_quantity = EXCLUDED.stock_quantity,
+		              price_adjustment = EXCLUDED.price_adjustment,
+		              weight_grams = EXCLUDED.weight_grams,
+		              updated_at = CURRENT_TIMESTAMP
+		RETURNING id, (xmax = 0)
+	`
+
+	var created bool
+	err := tx.QueryRow(
+		query,
+		v.ProductID, v.SKU, v.Size, v.Color, v.ColorHex, v.StockQuantity, v.PriceAdjustment, v.WeightGrams,
+	).Scan(&v.ID, &created)
+
+	return created, err
+}
+
+// ReplaceImages replaces all images of a product with the given URLs.
+// The first URL becomes the primary image.
+func (r *CatalogRepository) ReplaceImages(tx *sql.Tx, productID int, altText string, urls []string) error {
+	if _, err := tx.Exec(`DELETE FROM product_images WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO product_images (product_id, image_url, alt_text, display_order, is_primary)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for i, url := range urls {
+		if _, err := tx.Exec(query, productID, url, altText, i, i == 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Savepoint runs fn inside a savepoint so a failing row doesn't abort the whole transaction
+func (r *CatalogRepository) Savepoint(tx *sql.Tx, fn func() error) error {
+	if _, err := tx.Exec(`SAVEPOINT catalog_row`); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT catalog_row`); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	_, err := tx.Exec(`RELEASE SAVEPOINT catalog_row`)
+	return err
+}
+
+// ExportRows returns one catalog row per product variant, including inactive products
+func (r *CatalogRepository) ExportRows() ([]models.CatalogRow, error) {
+	query := `
+		SELECT p.slug, p.name, COALESCE(p.description, ''),
+		       COALESCE(b.slug, ''), COALESCE(c.slug, ''),
+		       p.base_price, p.is_active,
+		       pv.sku, pv.size, pv.color, COALESCE(pv.color_hex, ''),
+		       pv.stock_quantity, COALESCE(pv.price_adjustment, 0), pv.weight_grams,
+		       COALESCE((
+		           SELECT string_agg(pi.image_url, '|' ORDER BY pi.display_order, pi.id)
+		           FROM product_images pi
+		           WHERE pi.product_id = p.id
+		       ), '')
+		FROM products p
+		JOIN product_variants pv ON pv.product_id = p.id
+		LEFT JOIN brands b ON b.id = p.brand_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		ORDER BY p.slug, pv.sku
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalog := []models.CatalogRow{}
+	for rows.Next() {
+		var row models.CatalogRow
+		var images string
+		err := rows.Scan(
+			&row.ProductSlug, &row.ProductName, &row.Description,
+			&row.BrandSlug, &row.CategorySlug,
+			&row.BasePrice, &row.IsActive,
+			&row.SKU, &row.Size, &row.Color, &row.ColorHex,
+			&row.StockQuantity, &row.PriceAdjustment, &row.WeightGrams, &images,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if images != "" {
+			row.ImageURLs = strings.Split(images, "|")
+		}
+		catalog = append(catalog, row)
+	}
+
+	return catalog, rows.Err()
+}
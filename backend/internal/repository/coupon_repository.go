@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// couponColumns is the column list shared by all coupon queries (see scanCoupon)
+const couponColumns = `c.id, c.code, COALESCE(c.description, ''), c.discount_type, c.discount_value,
+		       c.max_discount, c.min_subtotal, c.brand_ids, c.category_ids,
+		       c.usage_limit, c.per_user_limit, c.times_used,
+		       c.starts_at, c.expires_at, c.is_active, c.created_at, c.updated_at`
+
+type CouponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+// scanCoupon scans a row selected with couponColumns
+func scanCoupon(row rowScanner) (*models.Coupon, error) {
+	c := &models.Coupon{}
+	err := row.Scan(
+		&c.ID, &c.Code, &c.Description, &c.DiscountType, &c.DiscountValue,
+		&c.MaxDiscount, &c.MinSubtotal, pq.Array(&c.BrandIDs), pq.Array(&c.CategoryIDs),
+		&c.UsageLimit, &c.PerUserLimit, &c.TimesUsed,
+		&c.StartsAt, &c.ExpiresAt, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetAll retrieves all coupons, newest first
+func (r *CouponRepository) GetAll() ([]models.Coupon, error) {
+	rows, err := r.db.Query(`SELECT ` + couponColumns + ` FROM coupons c ORDER BY c.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	for rows.Next() {
+		c, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *c)
+	}
+
+	return coupons, rows.Err()
+}
+
+// GetByID retrieves a coupon
+func (r *CouponRepository) GetByID(id int) (*models.Coupon, error) {
+	c, err := scanCoupon(r.db.QueryRow(`SELECT `+couponColumns+` FROM coupons c WHERE c.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// GetByCode retrieves a coupon by its (case-insensitive) code
+func (r *CouponRepository) GetByCode(code string) (*models.Coupon, error) {
+	c, err := scanCoupon(r.db.QueryRow(`SELECT `+couponColumns+` FROM coupons c WHERE UPPER(c.code) = UPPER($1)`, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Create creates a coupon
+func (r *CouponRepository) Create(c *models.Coupon) error {
+	query := `
+		INSERT INTO coupons (
+			code, description, discount_type, discount_value, max_discount, min_subtotal,
+			brand_ids, category_ids, usage_limit, per_user_limit, starts_at, expires_at, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, times_used, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		c.Code, c.Description, c.DiscountType, c.DiscountValue, c.MaxDiscount, c.MinSubtotal,
+		pq.Array(c.BrandIDs), pq.Array(c.CategoryIDs), c.UsageLimit, c.PerUserLimit,
+		c.StartsAt, c.ExpiresAt, c.IsActive,
+	).Scan(&c.ID, &c.TimesUsed, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// Update updates a coupon's settings. The usage counter is never overwritten.
+// Returns false if the coupon doesn't exist.
+func (r *CouponRepository) Update(c *models.Coupon) (bool, error) {
+	query := `
+		UPDATE coupons
+		SET code = $2, description = $3, discount_type = $4, discount_value = $5,
+		    max_discount = $6, min_subtotal = $7, brand_ids = $8, category_ids = $9,
+		    usage_limit = $10, per_user_limit = $11, starts_at = $12, expires_at = $13,
+		    is_active = $14, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING times_used, created_at, updated_at
+	`
+	err := r.db.QueryRow(
+		query, c.ID,
+		c.Code, c.Description, c.DiscountType, c.DiscountValue, c.MaxDiscount, c.MinSubtotal,
+		pq.Array(c.BrandIDs), pq.Array(c.CategoryIDs), c.UsageLimit, c.PerUserLimit,
+		c.StartsAt, c.ExpiresAt, c.IsActive,
+	).Scan(&c.TimesUsed, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete deletes a coupon that has never been redeemed.
+// Returns false if it doesn't exist or has redemptions.
+func (r *CouponRepository) Delete(id int) (bool, error) {
+	query := `
+		DELETE FROM coupons c
+		WHERE c.id = $1 AND NOT EXISTS (SELECT 1 FROM coupon_redemptions cr WHERE cr.coupon_id = c.id)
+	`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetCartCoupon retrieves the coupon applied to the owner's cart, if any
+func (r *CouponRepository) GetCartCoupon(owner models.CartOwner) (*models.Coupon, error) {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		SELECT `+couponColumns+`
+		FROM cart_coupons cc
+		JOIN coupons c ON c.id = cc.coupon_id
+		WHERE cc.%s = $1
+	`, column)
+
+	c, err := scanCoupon(r.db.QueryRow(query, value))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// SetCartCoupon applies a coupon to the owner's cart, replacing any previous one
+func (r *CouponRepository) SetCartCoupon(owner models.CartOwner, couponID int) error {
+	column, value := ownerColumn(owner)
+	query := fmt.Sprintf(`
+		INSERT INTO cart_coupons (%s, coupon_id)
+		VALUES ($1, $2)
+		ON CONFLICT (%s) DO UPDATE SET coupon_id = EXCLUDED.coupon_id, created_at = CURRENT_TIMESTAMP
+	`, column, column)
+	_, err := r.db.Exec(query, value, couponID)
+	return err
+}
+
+// RemoveCartCoupon removes the coupon from the owner's cart
+func (r *CouponRepository) RemoveCartCoupon(owner models.CartOwner) error {
+	column, value := ownerColumn(owner)
+	_, err := r.db.Exec(fmt.Sprintf(`DELETE FROM cart_coupons WHERE %s = $1`, column), value)
+	return err
+}
+
+// MergeGuestCartCoupon moves a guest cart's coupon to the user's cart
+// unless the user already has one
+func (r *CouponRepository) MergeGuestCartCoupon(guestID string, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO cart_coupons (user_id, coupon_id)
+		SELECT $2, coupon_id FROM cart_coupons WHERE guest_id = $1
+		ON CONFLICT (user_id) DO NOTHING
+	`
+	if _, err := tx.Exec(query, guestID, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM cart_coupons WHERE guest_id = $1`, guestID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountRedemptions counts a customer's redemptions of a coupon, by account or checkout email
+func (r *CouponRepository) CountRedemptions(couponID, userID int, email string) (int, error) {
+	return countRedemptions(r.db.QueryRow, couponID, userID, email)
+}
+
+// CountRedemptionsTx is CountRedemptions inside a redemption transaction
+func (r *CouponRepository) CountRedemptionsTx(tx *sql.Tx, couponID, userID int, email string) (int, error) {
+	return countRedemptions(tx.QueryRow, couponID, userID, email)
+}
+
+func countRedemptions(queryRow func(string, ...interface{}) *sql.Row, couponID, userID int, email string) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM coupon_redemptions
+		WHERE coupon_id = $1
+		  AND ((user_id IS NOT NULL AND user_id = $2) OR ($3 <> '' AND LOWER(guest_email) = LOWER($3)))
+	`
+	var count int
+	err := queryRow(query, couponID, userID, email).Scan(&count)
+	return count, err
+}
+
+// BeginTx starts a transaction for a coupon redemption
+func (r *CouponRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// LockCoupon locks a coupon row until the transaction ends so concurrent
+// redemptions see each other's usage counts
+func (r *CouponRepository) LockCoupon(tx *sql.Tx, id int) (*models.Coupon, error) {
+	c, err := scanCoupon(tx.QueryRow(`SELECT `+couponColumns+` FROM coupons c WHERE c.id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// CreateRedemption records a redemption and increments the coupon's usage counter
+func (r *CouponRepository) CreateRedemption(tx *sql.Tx, couponID, userID int, email string, amount money.Money) (int, error) {
+	query := `
+		INSERT INTO coupon_redemptions (coupon_id, user_id, guest_email, discount_amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	var id int
+	if err := tx.QueryRow(query, couponID, nullIfZero(userID), nullIfEmpty(email), amount).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`UPDATE coupons SET times_used = times_used + 1 WHERE id = $1`, couponID); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// AttachRedemption links a redemption to the order it was made for
+func (r *CouponRepository) AttachRedemption(tx *sql.Tx, redemptionID, orderID int) error {
+	_, err := tx.Exec(`UPDATE coupon_redemptions SET order_id = $2 WHERE id = $1`, redemptionID, orderID)
+	return err
+}
+
+// ReleaseRedemption undoes a redemption whose order couldn't be created
+func (r *CouponRepository) ReleaseRedemption(redemptionID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var couponID int
+	err = tx.QueryRow(`DELETE FROM coupon_redemptions WHERE id = $1 RETURNING coupon_id`, redemptionID).Scan(&couponID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE coupons SET times_used = GREATEST(times_used - 1, 0) WHERE id = $1`, couponID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+)
+
+type CurrencyRepository struct {
+	db *sql.DB
+}
+
+func NewCurrencyRepository(db *sql.DB) *CurrencyRepository {
+	return &CurrencyRepository{db: db}
+}
+
+// GetAll retrieves all exchange rates by currency code
+func (r *CurrencyRepository) GetAll() ([]models.ExchangeRate, error) {
+	rows, err := r.db.Query(`SELECT currency, rate, created_at, updated_at FROM exchange_rates ORDER BY currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var er models.ExchangeRate
+		if err := rows.Scan(&er.Currency, &er.Rate, &er.CreatedAt, &er.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, er)
+	}
+
+	return rates, rows.Err()
+}
+
+// Get retrieves the exchange rate of a currency
+func (r *CurrencyRepository) Get(currency string) (*models.ExchangeRate, error) {
+	er := &models.ExchangeRate{}
+	err := r.db.QueryRow(
+		`SELECT currency, rate, created_at, updated_at FROM exchange_rates WHERE currency = $1`, currency,
+	).Scan(&er.Currency, &er.Rate, &er.CreatedAt, &er.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return er, err
+}
+
+// Upsert creates or updates the exchange rate of a currency
+func (r *CurrencyRepository) Upsert(er *models.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (currency, rate)
+		VALUES ($1, $2)
+		ON CONFLICT (currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+	return r.db.QueryRow(query, er.Currency, er.Rate).Scan(&er.CreatedAt, &er.UpdatedAt)
+}
+
+// Delete deletes the exchange rate of a currency. Returns false if it doesn't exist.
+func (r *CurrencyRepository) Delete(currency string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM exchange_rates WHERE currency = $1`, currency)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
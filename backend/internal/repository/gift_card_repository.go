@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// giftCardColumns is the column list shared by all gift card queries (see scanGiftCard)
+const giftCardColumns = `gc.id, gc.code, gc.initial_balance, gc.balance,
+		       COALESCE(gc.recipient_email, ''), COALESCE(gc.message, ''),
+		       gc.purchaser_user_id, COALESCE(gc.payment_transaction_id, ''), gc.issued_by, gc.api_key_id,
+		       gc.is_active, gc.expires_at, gc.created_at, gc.updated_at`
+
+type GiftCardRepository struct {
+	db *sql.DB
+}
+
+func NewGiftCardRepository(db *sql.DB) *GiftCardRepository {
+	return &GiftCardRepository{db: db}
+}
+
+// scanGiftCard scans a row selected with giftCardColumns
+func scanGiftCard(row rowScanner) (*models.GiftCard, error) {
+	gc := &models.GiftCard{}
+	err := row.Scan(
+		&gc.ID, &gc.Code, &gc.InitialBalance, &gc.Balance,
+		&gc.RecipientEmail, &gc.Message,
+		&gc.PurchaserUserID, &gc.PaymentTransactionID, &gc.IssuedBy, &gc.APIKeyID,
+		&gc.IsActive, &gc.ExpiresAt, &gc.CreatedAt, &gc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gc, nil
+}
+
+// GetAll retrieves all gift cards, newest first
+func (r *GiftCardRepository) GetAll() ([]models.GiftCard, error) {
+	return r.query(`SELECT ` + giftCardColumns + ` FROM gift_cards gc ORDER BY gc.created_at DESC`)
+}
+
+// GetByPurchaser retrieves the gift cards a user bought, newest first
+func (r *GiftCardRepository) GetByPurchaser(userID int) ([]models.GiftCard, error) {
+	return r.query(`
+		SELECT `+giftCardColumns+`
+		FROM gift_cards gc
+		WHERE gc.purchaser_user_id = $1
+		ORDER BY gc.created_at DESC
+	`, userID)
+}
+
+func (r *GiftCardRepository) query(query string, args ...interface{}) ([]models.GiftCard, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := []models.GiftCard{}
+	for rows.Next() {
+		gc, err := scanGiftCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, *gc)
+	}
+
+	return cards, rows.Err()
+}
+
+// GetByID retrieves a gift card
+func (r *GiftCardRepository) GetByID(id int) (*models.GiftCard, error) {
+	gc, err := scanGiftCard(r.db.QueryRow(`SELECT `+giftCardColumns+` FROM gift_cards gc WHERE gc.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return gc, err
+}
+
+// GetByCode retrieves a gift card by its (normalized) code
+func (r *GiftCardRepository) GetByCode(code string) (*models.GiftCard, error) {
+	gc, err := scanGiftCard(r.db.QueryRow(`SELECT `+giftCardColumns+` FROM gift_cards gc WHERE gc.code = $1`, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return gc, err
+}
+
+// Create creates a gift card and records its initial load
+func (r *GiftCardRepository) Create(gc *models.GiftCard) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO gift_cards (code, initial_balance, balance, recipient_email, message,
+		                        purchaser_user_id, issued_by, api_key_id, is_active, expires_at)
+		VALUES ($1, $2, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(
+		query, gc.Code, gc.InitialBalance, nullIfEmpty(gc.RecipientEmail), nullIfEmpty(gc.Message),
+		gc.PurchaserUserID, gc.IssuedBy, gc.APIKeyID, gc.IsActive, gc.ExpiresAt,
+	).Scan(&gc.ID, &gc.CreatedAt, &gc.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO gift_card_transactions (gift_card_id, amount, note) VALUES ($1, $2, 'Issued')`,
+		gc.ID, gc.InitialBalance,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update changes whether a gift card can be used and until when. Returns false if it doesn't exist.
+func (r *GiftCardRepository) Update(id int, isActive bool, expiresAt *time.Time) (bool, error) {
+	query := `
+		UPDATE gift_cards
+		SET is_active = $2, expires_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	result, err := r.db.Exec(query, id, isActive, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ActivatePurchased activates a purchased gift card once it has been paid.
+// Returns false if the card doesn't exist, wasn't purchased or is already paid.
+func (r *GiftCardRepository) ActivatePurchased(id int, transactionID string) (bool, error) {
+	query := `
+		UPDATE gift_cards
+		SET is_active = TRUE, payment_transaction_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND purchaser_user_id IS NOT NULL AND payment_transaction_id IS NULL
+	`
+	result, err := r.db.Exec(query, id, transactionID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// BeginTx starts a transaction for redeeming gift cards and store credit
+func (r *GiftCardRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// LockGiftCards locks the gift cards with the given codes until the transaction
+// ends. Rows are locked in id order so concurrent checkouts can't deadlock.
+func (r *GiftCardRepository) LockGiftCards(tx *sql.Tx, codes []string) (map[string]*models.GiftCard, error) {
+	rows, err := tx.Query(`
+		SELECT `+giftCardColumns+`
+		FROM gift_cards gc
+		WHERE gc.code = ANY($1)
+		ORDER BY gc.id
+		FOR UPDATE
+	`, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := map[string]*models.GiftCard{}
+	for rows.Next() {
+		gc, err := scanGiftCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards[gc.Code] = gc
+	}
+
+	return cards, rows.Err()
+}
+
+// AddGiftCardTransaction changes a gift card's balance by amount (negative to spend)
+// and records it. Returns the transaction ID.
+func (r *GiftCardRepository) AddGiftCardTransaction(tx *sql.Tx, giftCardID int, orderID *int, amount money.Money, note string) (int, error) {
+	_, err := tx.Exec(
+		`UPDATE gift_cards SET balance = balance + $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		giftCardID, amount,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO gift_card_transactions (gift_card_id, order_id, amount, note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, giftCardID, orderID, amount, nullIfEmpty(note)).Scan(&id)
+	return id, err
+}
+
+// AttachGiftCardTransaction links a gift card transaction to the order it was made for
+func (r *GiftCardRepository) AttachGiftCardTransaction(tx *sql.Tx, transactionID, orderID int) error {
+	_, err := tx.Exec(`UPDATE gift_card_transactions SET order_id = $2 WHERE id = $1`, transactionID, orderID)
+	return err
+}
+
+// ReleaseGiftCardTransaction undoes a gift card transaction whose order couldn't be created
+func (r *GiftCardRepository) ReleaseGiftCardTransaction(transactionID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var giftCardID int
+	var amount money.Money
+	err = tx.QueryRow(
+		`DELETE FROM gift_card_transactions WHERE id = $1 RETURNING gift_card_id, amount`, transactionID,
+	).Scan(&giftCardID, &amount)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE gift_cards SET balance = balance - $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		giftCardID, amount,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetStoreCreditBalance retrieves a user's store credit balance
+func (r *GiftCardRepository) GetStoreCreditBalance(userID int) (money.Money, error) {
+	var balance money.Money
+	err := r.db.QueryRow(`SELECT balance FROM store_credits WHERE user_id = $1`, userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return money.Money{}, nil
+	}
+	return balance, err
+}
+
+// LockStoreCredit locks a user's store credit until the transaction ends and returns the balance
+func (r *GiftCardRepository) LockStoreCredit(tx *sql.Tx, userID int) (money.Money, error) {
+	var balance money.Money
+	err := tx.QueryRow(`SELECT balance FROM store_credits WHERE user_id = $1 FOR UPDATE`, userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return money.Money{}, nil
+	}
+	return balance, err
+}
+
+// AddStoreCreditTransaction changes a user's store credit by t.Amount (negative to spend)
+// and records it. The balance can't go below zero.
+func (r *GiftCardRepository) AddStoreCreditTransaction(tx *sql.Tx, t *models.StoreCreditTransaction) error {
+	_, err := tx.Exec(`
+		INSERT INTO store_credits (user_id, balance)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET balance = store_credits.balance + EXCLUDED.balance, updated_at = CURRENT_TIMESTAMP
+	`, t.UserID, t.Amount)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO store_credit_transactions (user_id, amount, reason, order_id, refund_id, created_by, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return tx.QueryRow(
+		query, t.UserID, t.Amount, t.Reason, t.OrderID, t.RefundID, t.CreatedBy, t.APIKeyID,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// AttachStoreCreditTransaction links a store credit transaction to the order it was made for
+func (r *GiftCardRepository) AttachStoreCreditTransaction(tx *sql.Tx, transactionID, orderID int) error {
+	_, err := tx.Exec(`UPDATE store_credit_transactions SET order_id = $2 WHERE id = $1`, transactionID, orderID)
+	return err
+}
+
+// ReleaseStoreCreditTransaction undoes a store credit transaction whose order couldn't be created
+func (r *GiftCardRepository) ReleaseStoreCreditTransaction(transactionID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var amount money.Money
+	err = tx.QueryRow(
+		`DELETE FROM store_credit_transactions WHERE id = $1 RETURNING user_id, amount`, transactionID,
+	).Scan(&userID, &amount)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE store_credits SET balance = balance - $2, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1`,
+		userID, amount,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetStoreCreditTransactions retrieves a user's store credit history, newest first
+func (r *GiftCardRepository) GetStoreCreditTransactions(userID int) ([]models.StoreCreditTransaction, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, amount, reason, order_id, refund_id, created_by, api_key_id, created_at
+		FROM store_credit_transactions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.StoreCreditTransaction{}
+	for rows.Next() {
+		var t models.StoreCreditTransaction
+		err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.Reason, &t.OrderID, &t.RefundID, &t.CreatedBy, &t.APIKeyID, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
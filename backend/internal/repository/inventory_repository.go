@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// BeginTx starts a transaction for a stock sync
+func (r *InventoryRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// LockVariantsBySKU loads and row-locks the variants with the given SKUs.
+// Rows are locked in ID order so concurrent syncs can't deadlock.
+func (r *InventoryRepository) LockVariantsBySKU(tx *sql.Tx, skus []string) ([]models.ProductVariant, error) {
+	query := `
+		SELECT id, product_id, sku, stock_quantity, COALESCE(price_adjustment, 0)
+		FROM product_variants
+		WHERE sku = ANY($1)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, pq.Array(skus))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []models.ProductVariant{}
+	for rows.Next() {
+		var v models.ProductVariant
+		if err := rows.Scan(&v.ID, &v.ProductID, &v.SKU, &v.StockQuantity, &v.PriceAdjustment); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+// ApplyStockChanges updates variants and writes audit entries for one batch
+func (r *InventoryRepository) ApplyStockChanges(tx *sql.Tx, changes []models.StockAuditEntry, source string, changedBy, apiKeyID *int) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(changes))
+	skus := make([]string, len(changes))
+	oldStock := make([]int64, len(changes))
+	newStock := make([]int64, len(changes))
+	oldPrice := make([]string, len(changes))
+	newPrice := make([]string, len(changes))
+	for i, c := range changes {
+		ids[i] = int64(c.ProductVariantID)
+		skus[i] = c.SKU
+		oldStock[i] = int64(c.OldStockQuantity)
+		newStock[i] = int64(c.NewStockQuantity)
+		oldPrice[i] = c.OldPriceAdjustment.String()
+		newPrice[i] = c.NewPriceAdjustment.String()
+	}
+
+	update := `
+		UPDATE product_variants pv
+		SET stock_quantity = u.stock_quantity,
+		    price_adjustment = u.price_adjustment,
+		    updated_at = CURRENT_TIMESTAMP
+		FROM unnest($1::int[], $2::int[], $3::numeric[]) AS u(id, stock_quantity, price_adjustment)
+		WHERE pv.id = u.id
+	`
+	if _, err := tx.Exec(update, pq.Array(ids), pq.Array(newStock), pq.Array(newPrice)); err != nil {
+		return err
+	}
+
+	audit := `
+		INSERT INTO stock_audit_log (
+			product_variant_id, sku,
+			old_stock_quantity, new_stock_quantity,
+			old_price_adjustment, new_price_adjustment,
+			source, changed_by, api_key_id
+		)
+		SELECT u.id, u.sku, u.old_stock, u.new_stock, u.old_price, u.new_price, $7, $8, $9
+		FROM unnest($1::int[], $2::text[], $3::int[], $4::int[], $5::numeric[], $6::numeric[])
+		     AS u(id, sku, old_stock, new_stock, old_price, new_price)
+	`
+	_, err := tx.Exec(
+		audit,
+		pq.Array(ids), pq.Array(skus),
+		pq.Array(oldStock), pq.Array(newStock),
+		pq.Array(oldPrice), pq.Array(newPrice),
+		source, changedBy, apiKeyID,
+	)
+	return err
+}
+
+// GetAuditLog retrieves the most recent stock changes, optionally for one SKU
+func (r *InventoryRepository) GetAuditLog(sku string, limit int) ([]models.StockAuditEntry, error) {
+	query := `
+		SELECT id, COALESCE(product_variant_id, 0), sku,
+		       old_stock_quantity, new_stock_quantity,
+		       old_price_adjustment, new_price_adjustment,
+		       source, changed_by, api_key_id, created_at
+		FROM stock_audit_log
+		WHERE $1 = '' OR sku = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, sku, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.StockAuditEntry{}
+	for rows.Next() {
+		var e models.StockAuditEntry
+		err := rows.Scan(
+			&e.ID, &e.ProductVariantID, &e.SKU,
+			&e.OldStockQuantity, &e.NewStockQuantity,
+			&e.OldPriceAdjustment, &e.NewPriceAdjustment,
+			&e.Source, &e.ChangedBy, &e.APIKeyID, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
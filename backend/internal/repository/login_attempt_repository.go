@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"time"
+)
+
+type LoginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// LockedUntil returns the latest time until which an account or IP is locked
+// out (nil if neither is)
+func (r *LoginAttemptRepository) LockedUntil(account, ip string) (*time.Time, error) {
+	var until *time.Time
+	err := r.db.QueryRow(`
+		SELECT MAX(locked_until)
+		FROM login_throttles
+		WHERE ((scope = $1 AND key = $2) OR (scope = $3 AND key = $4))
+		  AND locked_until > CURRENT_TIMESTAMP
+	`, models.LoginScopeAccount, account, models.LoginScopeIP, ip).Scan(&until)
+	return until, err
+}
+
+// RecordFailure counts a failed login for an account or IP and returns its
+// consecutive failures. The count starts over once window has passed
+// without a failure.
+func (r *LoginAttemptRepository) RecordFailure(scope, key string, window time.Duration) (int, error) {
+	var failures int
+	err := r.db.QueryRow(`
+		INSERT INTO login_throttles (scope, key, failures)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (scope, key)
+		DO UPDATE SET failures = CASE
+		                  WHEN login_throttles.last_failure_at < CURRENT_TIMESTAMP - make_interval(secs => $3) THEN 1
+		                  ELSE login_throttles.failures + 1
+		              END,
+		              last_failure_at = CURRENT_TIMESTAMP
+		RETURNING failures
+	`, scope, key, window.Seconds()).Scan(&failures)
+	return failures, err
+}
+
+// Lock blocks logins for an account or IP until the given time
+func (r *LoginAttemptRepository) Lock(scope, key string, until time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE login_throttles SET locked_until = $3 WHERE scope = $1 AND key = $2`, scope, key, until,
+	)
+	return err
+}
+
+// Reset forgets the failed logins of an account or IP
+func (r *LoginAttemptRepository) Reset(scope, key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key)
+	return err
+}
+
+// DeleteStale removes the counters of accounts and IPs that aren't locked and
+// haven't failed since before. Returns how many were removed.
+func (r *LoginAttemptRepository) DeleteStale(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// LogFailure records a failed login in the audit trail
+func (r *LoginAttemptRepository) LogFailure(f *models.LoginFailure) error {
+	query := `
+		INSERT INTO login_failures (email, user_id, ip_address, user_agent, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query, f.Email, f.UserID, nullIfEmpty(f.IPAddress), nullIfEmpty(f.UserAgent), f.Reason,
+	).Scan(&f.ID, &f.CreatedAt)
+}
+
+// GetFailures retrieves the latest failed logins, optionally for one email
+// and/or IP, newest first
+func (r *LoginAttemptRepository) GetFailures(email, ip string, limit int) ([]models.LoginFailure, error) {
+	rows, err := r.db.Query(`
+		SELECT id, email, user_id, COALESCE(ip_address, ''), COALESCE(user_agent, ''), reason, created_at
+		FROM login_failures
+		WHERE ($1 = '' OR email = $1) AND ($2 = '' OR ip_address = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, email, ip, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := []models.LoginFailure{}
+	for rows.Next() {
+		var f models.LoginFailure
+		if err := rows.Scan(&f.ID, &f.Email, &f.UserID, &f.IPAddress, &f.UserAgent, &f.Reason, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+
+	return failures, rows.Err()
+}
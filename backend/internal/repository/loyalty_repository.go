@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"time"
+)
+
+type LoyaltyRepository struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepository(db *sql.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db}
+}
+
+// GetAccount retrieves a user's points balance and last activity (nil if never active)
+func (r *LoyaltyRepository) GetAccount(userID int) (int, *time.Time, error) {
+	var balance int
+	var lastActivity *time.Time
+	err := r.db.QueryRow(
+		`SELECT balance, last_activity_at FROM loyalty_accounts WHERE user_id = $1`, userID,
+	).Scan(&balance, &lastActivity)
+	if err == sql.ErrNoRows {
+		return 0, nil, nil
+	}
+	return balance, lastActivity, err
+}
+
+// BeginTx starts a transaction for changing points balances
+func (r *LoyaltyRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// LockAccount locks a user's points balance until the transaction ends and returns it
+func (r *LoyaltyRepository) LockAccount(tx *sql.Tx, userID int) (int, error) {
+	var balance int
+	err := tx.QueryRow(`SELECT balance FROM loyalty_accounts WHERE user_id = $1 FOR UPDATE`, userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// AddTransaction changes a user's points balance by t.Points and records it.
+// Activity (earning, redeeming, admin credits) restarts the expiry period.
+// The balance can't go below zero.
+func (r *LoyaltyRepository) AddTransaction(tx *sql.Tx, t *models.LoyaltyTransaction, activity bool) error {
+	_, err := tx.Exec(`
+		INSERT INTO loyalty_accounts (user_id, balance)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET balance = loyalty_accounts.balance + EXCLUDED.balance,
+		              last_activity_at = CASE WHEN $3 THEN CURRENT_TIMESTAMP ELSE loyalty_accounts.last_activity_at END,
+		              updated_at = CURRENT_TIMESTAMP
+	`, t.UserID, t.Points, activity)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO loyalty_transactions (user_id, type, points, order_id, refund_id, reason, created_by, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	return tx.QueryRow(
+		query, t.UserID, t.Type, t.Points, t.OrderID, t.RefundID, nullIfEmpty(t.Reason), t.CreatedBy, t.APIKeyID,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// AttachTransaction links a points transaction to the order it was made for
+func (r *LoyaltyRepository) AttachTransaction(tx *sql.Tx, transactionID, orderID int) error {
+	_, err := tx.Exec(`UPDATE loyalty_transactions SET order_id = $2 WHERE id = $1`, transactionID, orderID)
+	return err
+}
+
+// ReleaseTransaction undoes a points transaction whose order couldn't be created
+func (r *LoyaltyRepository) ReleaseTransaction(transactionID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID, points int
+	err = tx.QueryRow(
+		`DELETE FROM loyalty_transactions WHERE id = $1 RETURNING user_id, points`, transactionID,
+	).Scan(&userID, &points)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE loyalty_accounts SET balance = balance - $2, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1`,
+		userID, points,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetOrderPoints sums an order's points by transaction type
+func (r *LoyaltyRepository) GetOrderPoints(tx *sql.Tx, orderID int) (map[string]int, error) {
+	rows, err := tx.Query(`
+		SELECT type, SUM(points)
+		FROM loyalty_transactions
+		WHERE order_id = $1
+		GROUP BY type
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := map[string]int{}
+	for rows.Next() {
+		var t string
+		var sum int
+		if err := rows.Scan(&t, &sum); err != nil {
+			return nil, err
+		}
+		points[t] = sum
+	}
+
+	return points, rows.Err()
+}
+
+// ExpireInactive zeroes the balances without activity since before and records
+// the expired points. Returns how many accounts expired.
+func (r *LoyaltyRepository) ExpireInactive(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		WITH expired AS (
+			UPDATE loyalty_accounts la
+			SET balance = 0, updated_at = CURRENT_TIMESTAMP
+			FROM (
+				SELECT user_id, balance
+				FROM loyalty_accounts
+				WHERE balance > 0 AND last_activity_at < $1
+				FOR UPDATE
+			) old
+			WHERE la.user_id = old.user_id
+			RETURNING la.user_id, old.balance
+		)
+		INSERT INTO loyalty_transactions (user_id, type, points, reason)
+		SELECT user_id, $2, -balance, 'Points expired after inactivity'
+		FROM expired
+	`, before, models.LoyaltyExpire)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetTransactions retrieves a user's points history, newest first
+func (r *LoyaltyRepository) GetTransactions(userID int) ([]models.LoyaltyTransaction, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, type, points, order_id, refund_id, COALESCE(reason, ''), created_by, api_key_id, created_at
+		FROM loyalty_transactions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.LoyaltyTransaction{}
+	for rows.Next() {
+		var t models.LoyaltyTransaction
+		err := rows.Scan(&t.ID, &t.UserID, &t.Type, &t.Points, &t.OrderID, &t.RefundID, &t.Reason, &t.CreatedBy, &t.APIKeyID, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
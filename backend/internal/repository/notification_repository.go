@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"time"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create queues a new notification
+func (r *NotificationRepository) Create(n *models.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, channel, type, recipient, subject, body)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		n.UserID, n.Channel, n.Type, n.Recipient, n.Subject, n.Body,
+	).Scan(&n.ID, &n.Status, &n.CreatedAt)
+}
+
+// ClaimPending claims up to limit notifications waiting to be sent, oldest
+// first. Claimed notifications are skipped by other dispatchers until they are
+// marked sent or failed, or the lease runs out (e.g. the dispatcher crashed).
+func (r *NotificationRepository) ClaimPending(limit int, lease time.Duration) ([]models.Notification, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE notifications
+			SET claimed_until = CURRENT_TIMESTAMP + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM notifications
+				WHERE status = 'pending' AND (claimed_until IS NULL OR claimed_until < CURRENT_TIMESTAMP)
+				ORDER BY created_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, user_id, channel, type, recipient, subject, body,
+			          status, attempts, COALESCE(last_error, '') AS last_error, created_at, sent_at
+		)
+		SELECT * FROM claimed ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(
+			&n.ID, &n.UserID, &n.Channel, &n.Type, &n.Recipient, &n.Subject, &n.Body,
+			&n.Status, &n.Attempts, &n.LastError, &n.CreatedAt, &n.SentAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkSent marks a notification as delivered
+func (r *NotificationRepository) MarkSent(id int) error {
+	query := `
+		UPDATE notifications
+		SET status = 'sent', attempts = attempts + 1, sent_at = CURRENT_TIMESTAMP, claimed_until = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// MarkAttemptFailed records a failed delivery; the notification is given up
+// on (status failed) once maxAttempts is reached
+func (r *NotificationRepository) MarkAttemptFailed(id int, errMsg string, maxAttempts int) error {
+	query := `
+		UPDATE notifications
+		SET attempts = attempts + 1,
+		    last_error = $2,
+		    claimed_until = NULL,
+		    status = CASE WHEN attempts + 1 >= $3 THEN 'failed' ELSE 'pending' END
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, errMsg, maxAttempts)
+	return err
+}
@@ -7,6 +7,59 @@ import (
 	"time"
 )
 
+// orderColumns is the column list shared by all order queries (see scanOrder)
+const orderColumns = `id, COALESCE(user_id, 0), COALESCE(guest_email, ''), order_number,
+		       shipping_address_line1, COALESCE(shipping_address_line2, ''), shipping_city,
+		       COALESCE(shipping_state, ''), shipping_postal_code, shipping_country,
+		       shipping_full_name, shipping_phone, COALESCE(shipping_method, ''),
+		       subtotal, discount, shipping_cost, tax, total, currency, exchange_rate, prices_include_tax,
+		       status, COALESCE(payment_method, ''), payment_status, COALESCE(notes, ''),
+		       abandoned_cart_id, created_at, updated_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryRower is implemented by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanOrder scans a row selected with orderColumns
+func scanOrder(row rowScanner) (*models.Order, error) {
+	o := &models.Order{}
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.GuestEmail, &o.OrderNumber,
+		&o.ShippingAddressLine1, &o.ShippingAddressLine2, &o.ShippingCity,
+		&o.ShippingState, &o.ShippingPostalCode, &o.ShippingCountry,
+		&o.ShippingFullName, &o.ShippingPhone, &o.ShippingMethod,
+		&o.Subtotal, &o.Discount, &o.ShippingCost, &o.Tax, &o.Total, &o.Currency, &o.ExchangeRate, &o.PricesIncludeTax,
+		&o.Status, &o.PaymentMethod, &o.PaymentStatus, &o.Notes,
+		&o.AbandonedCartID, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// nullIfZero stores 0 as NULL (e.g. user_id of a guest order)
+func nullIfZero(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// nullIfEmpty stores "" as NULL
+func nullIfEmpty(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
 type OrderRepository struct {
 	db *sql.DB
 }
@@ -16,29 +69,29 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 }
 
 // CreateOrder creates a new order
-func (r *OrderRepository) CreateOrder(order *models.Order) (int, error) {
+func (r *OrderRepository) CreateOrder(tx *sql.Tx, order *models.Order) (int, error) {
 	query := `
 		INSERT INTO orders (
-			user_id, order_number, 
+			user_id, guest_email, order_number, 
 			shipping_address_line1, shipping_address_line2, shipping_city, 
 			shipping_state, shipping_postal_code, shipping_country,
-			shipping_full_name, shipping_phone,
-			subtotal, shipping_cost, tax, total,
+			shipping_full_name, shipping_phone, shipping_method,
+			subtotal, discount, shipping_cost, tax, total, currency, exchange_rate, prices_include_tax,
 			status, payment_method, payment_status, notes
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
 		RETURNING id, created_at, updated_at
 	`
 	
 	var id int
 	var createdAt, updatedAt time.Time
 	
-	err := r.db.QueryRow(
+	err := tx.QueryRow(
 		query,
-		order.UserID, order.OrderNumber,
+		nullIfZero(order.UserID), nullIfEmpty(order.GuestEmail), order.OrderNumber,
 		order.ShippingAddressLine1, order.ShippingAddressLine2, order.ShippingCity,
 		order.ShippingState, order.ShippingPostalCode, order.ShippingCountry,
-		order.ShippingFullName, order.ShippingPhone,
-		order.Subtotal, order.ShippingCost, order.Tax, order.Total,
+		order.ShippingFullName, order.ShippingPhone, nullIfEmpty(order.ShippingMethod),
+		order.Subtotal, order.Discount, order.ShippingCost, order.Tax, order.Total, order.Currency, order.ExchangeRate, order.PricesIncludeTax,
 		order.Status, order.PaymentMethod, order.PaymentStatus, order.Notes,
 	).Scan(&id, &createdAt, &updatedAt)
 	
@@ -54,34 +107,30 @@ func (r *OrderRepository) CreateOrder(order *models.Order) (int, error) {
 }
 
 // CreateOrderItem creates an order item
-func (r *OrderRepository) CreateOrderItem(item *models.OrderItem) error {
+func (r *OrderRepository) CreateOrderItem(tx *sql.Tx, item *models.OrderItem) error {
 	query := `
 		INSERT INTO order_items (
 			order_id, product_variant_id,
 			product_name, product_sku, size, color,
-			quantity, unit_price, total_price
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			quantity, unit_price, total_price, discount_amount, sale_price_id,
+			tax_rate, tax_amount
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, created_at
 	`
 	
-	return r.db.QueryRow(
+	return tx.QueryRow(
 		query,
 		item.OrderID, item.ProductVariantID,
 		item.ProductName, item.ProductSKU, item.Size, item.Color,
-		item.Quantity, item.UnitPrice, item.TotalPrice,
+		item.Quantity, item.UnitPrice, item.TotalPrice, item.DiscountAmount, item.SalePriceID,
+		item.TaxRate, item.TaxAmount,
 	).Scan(&item.ID, &item.CreatedAt)
 }
 
 // GetUserOrders retrieves all orders for a user
 func (r *OrderRepository) GetUserOrders(userID int) ([]models.Order, error) {
 	query := `
-		SELECT id, user_id, order_number, 
-		       shipping_address_line1, shipping_address_line2, shipping_city,
-		       shipping_state, shipping_postal_code, shipping_country,
-		       shipping_full_name, shipping_phone,
-		       subtotal, shipping_cost, tax, total,
-		       status, payment_method, payment_status, notes,
-		       created_at, updated_at
+		SELECT `+orderColumns+`
 		FROM orders
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -95,20 +144,11 @@ func (r *OrderRepository) GetUserOrders(userID int) ([]models.Order, error) {
 
 	orders := []models.Order{}
 	for rows.Next() {
-		var o models.Order
-		err := rows.Scan(
-			&o.ID, &o.UserID, &o.OrderNumber,
-			&o.ShippingAddressLine1, &o.ShippingAddressLine2, &o.ShippingCity,
-			&o.ShippingState, &o.ShippingPostalCode, &o.ShippingCountry,
-			&o.ShippingFullName, &o.ShippingPhone,
-			&o.Subtotal, &o.ShippingCost, &o.Tax, &o.Total,
-			&o.Status, &o.PaymentMethod, &o.PaymentStatus, &o.Notes,
-			&o.CreatedAt, &o.UpdatedAt,
-		)
+		o, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, o)
+		orders = append(orders, *o)
 	}
 
 	return orders, nil
@@ -117,13 +157,7 @@ func (r *OrderRepository) GetUserOrders(userID int) ([]models.Order, error) {
 // GetAllOrders retrieves all orders (admin only)
 func (r *OrderRepository) GetAllOrders() ([]models.Order, error) {
 	query := `
-		SELECT id, user_id, order_number, 
-		       shipping_address_line1, shipping_address_line2, shipping_city,
-		       shipping_state, shipping_postal_code, shipping_country,
-		       shipping_full_name, shipping_phone,
-		       subtotal, shipping_cost, tax, total,
-		       status, payment_method, payment_status, notes,
-		       created_at, updated_at
+		SELECT `+orderColumns+`
 		FROM orders
 		ORDER BY created_at DESC
 	`
@@ -136,20 +170,11 @@ func (r *OrderRepository) GetAllOrders() ([]models.Order, error) {
 
 	orders := []models.Order{}
 	for rows.Next() {
-		var o models.Order
-		err := rows.Scan(
-			&o.ID, &o.UserID, &o.OrderNumber,
-			&o.ShippingAddressLine1, &o.ShippingAddressLine2, &o.ShippingCity,
-			&o.ShippingState, &o.ShippingPostalCode, &o.ShippingCountry,
-			&o.ShippingFullName, &o.ShippingPhone,
-			&o.Subtotal, &o.ShippingCost, &o.Tax, &o.Total,
-			&o.Status, &o.PaymentMethod, &o.PaymentStatus, &o.Notes,
-			&o.CreatedAt, &o.UpdatedAt,
-		)
+		o, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, o)
+		orders = append(orders, *o)
 	}
 
 	return orders, nil
@@ -158,28 +183,62 @@ func (r *OrderRepository) GetAllOrders() ([]models.Order, error) {
 // GetOrderByID retrieves a single order with items
 func (r *OrderRepository) GetOrderByID(orderID, userID int) (*models.Order, error) {
 	query := `
-		SELECT id, user_id, order_number,
-		       shipping_address_line1, shipping_address_line2, shipping_city,
-		       shipping_state, shipping_postal_code, shipping_country,
-		       shipping_full_name, shipping_phone,
-		       subtotal, shipping_cost, tax, total,
-		       status, payment_method, payment_status, notes,
-		       created_at, updated_at
+		SELECT `+orderColumns+`
 		FROM orders
 		WHERE id = $1 AND user_id = $2
 	`
 	
-	order := &models.Order{}
-	err := r.db.QueryRow(query, orderID, userID).Scan(
-		&order.ID, &order.UserID, &order.OrderNumber,
-		&order.ShippingAddressLine1, &order.ShippingAddressLine2, &order.ShippingCity,
-		&order.ShippingState, &order.ShippingPostalCode, &order.ShippingCountry,
-		&order.ShippingFullName, &order.ShippingPhone,
-		&order.Subtotal, &order.ShippingCost, &order.Tax, &order.Total,
-		&order.Status, &order.PaymentMethod, &order.PaymentStatus, &order.Notes,
-		&order.CreatedAt, &order.UpdatedAt,
-	)
-	
+	return r.getOrderWithItems(query, orderID, userID)
+}
+
+// GetOrder retrieves any order with items (admin)
+func (r *OrderRepository) GetOrder(orderID int) (*models.Order, error) {
+	query := `
+		SELECT `+orderColumns+`
+		FROM orders
+		WHERE id = $1
+	`
+	return r.getOrderWithItems(query, orderID)
+}
+
+// GetGuestOrderByID retrieves a guest order by ID and checkout email
+func (r *OrderRepository) GetGuestOrderByID(orderID int, email string) (*models.Order, error) {
+	query := `
+		SELECT `+orderColumns+`
+		FROM orders
+		WHERE id = $1 AND LOWER(guest_email) = LOWER($2)
+	`
+	return r.getOrderWithItems(query, orderID, email)
+}
+
+// GetGuestOrderByNumber retrieves a guest order by order number and checkout email
+func (r *OrderRepository) GetGuestOrderByNumber(orderNumber, email string) (*models.Order, error) {
+	query := `
+		SELECT `+orderColumns+`
+		FROM orders
+		WHERE order_number = $1 AND LOWER(guest_email) = LOWER($2)
+	`
+	return r.getOrderWithItems(query, orderNumber, email)
+}
+
+// AttachGuestOrders moves all guest orders placed with email to a user account.
+// Returns the number of orders attached.
+func (r *OrderRepository) AttachGuestOrders(email string, userID int) (int64, error) {
+	query := `
+		UPDATE orders
+		SET user_id = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE LOWER(guest_email) = LOWER($1) AND user_id IS NULL
+	`
+	result, err := r.db.Exec(query, email, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// getOrderWithItems runs a single-order query and loads its items
+func (r *OrderRepository) getOrderWithItems(query string, args ...interface{}) (*models.Order, error) {
+	order, err := scanOrder(r.db.QueryRow(query, args...))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -188,22 +247,44 @@ func (r *OrderRepository) GetOrderByID(orderID, userID int) (*models.Order, erro
 	}
 	
 	// Get order items
-	items, err := r.getOrderItems(orderID)
+	items, err := r.getOrderItems(order.ID)
 	if err == nil {
 		order.Items = items
 	}
 	
+	// Get discount lines
+	discounts, err := r.getOrderDiscounts(order.ID)
+	if err == nil {
+		order.Discounts = discounts
+	}
+	
+	// Get payments
+	payments, err := r.getOrderPayments(order.ID)
+	if err == nil {
+		order.Payments = payments
+	}
+	
+	// Get refunds
+	refunds, err := r.getOrderRefunds(order.ID)
+	if err == nil {
+		order.Refunds = refunds
+	}
+	
 	return order, nil
 }
 
 // getOrderItems retrieves items for an order
 func (r *OrderRepository) getOrderItems(orderID int) ([]models.OrderItem, error) {
 	query := `
-		SELECT id, order_id, product_variant_id,
-		       product_name, product_sku, size, color,
-		       quantity, unit_price, total_price, created_at
-		FROM order_items
-		WHERE order_id = $1
+		SELECT oi.id, oi.order_id, oi.product_variant_id,
+		       oi.product_name, oi.product_sku, oi.size, oi.color,
+		       oi.quantity, oi.unit_price, oi.total_price, oi.discount_amount, oi.sale_price_id,
+		       oi.tax_rate, oi.tax_amount,
+		       COALESCE((SELECT SUM(ri.quantity) FROM order_refund_items ri WHERE ri.order_item_id = oi.id), 0),
+		       oi.created_at
+		FROM order_items oi
+		WHERE oi.order_id = $1
+		ORDER BY oi.id
 	`
 	
 	rows, err := r.db.Query(query, orderID)
@@ -218,7 +299,8 @@ func (r *OrderRepository) getOrderItems(orderID int) ([]models.OrderItem, error)
 		err := rows.Scan(
 			&item.ID, &item.OrderID, &item.ProductVariantID,
 			&item.ProductName, &item.ProductSKU, &item.Size, &item.Color,
-			&item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CreatedAt,
+			&item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.DiscountAmount, &item.SalePriceID,
+			&item.TaxRate, &item.TaxAmount, &item.RefundedQuantity, &item.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -229,6 +311,281 @@ func (r *OrderRepository) getOrderItems(orderID int) ([]models.OrderItem, error)
 	return items, nil
 }
 
+// CreateOrderDiscount adds a discount line to an order
+func (r *OrderRepository) CreateOrderDiscount(tx *sql.Tx, d *models.OrderDiscount) error {
+	query := `
+		INSERT INTO order_discounts (order_id, type, promotion_id, code, description, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	return tx.QueryRow(query, d.OrderID, d.Type, d.PromotionID, nullIfEmpty(d.Code), d.Description, d.Amount).Scan(&d.ID)
+}
+
+// getOrderDiscounts retrieves the discount lines of an order
+func (r *OrderRepository) getOrderDiscounts(orderID int) ([]models.OrderDiscount, error) {
+	query := `
+		SELECT id, order_id, type, promotion_id, COALESCE(code, ''), COALESCE(description, ''), amount
+		FROM order_discounts
+		WHERE order_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discounts := []models.OrderDiscount{}
+	for rows.Next() {
+		var d models.OrderDiscount
+		if err := rows.Scan(&d.ID, &d.OrderID, &d.Type, &d.PromotionID, &d.Code, &d.Description, &d.Amount); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, d)
+	}
+
+	return discounts, rows.Err()
+}
+
+// CreateOrderPayment records one tender of an order
+func (r *OrderRepository) CreateOrderPayment(tx *sql.Tx, p *models.OrderPayment) error {
+	query := `
+		INSERT INTO order_payments (order_id, method, amount, status, gift_card_id, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return tx.QueryRow(
+		query, p.OrderID, p.Method, p.Amount, p.Status, p.GiftCardID, nullIfEmpty(p.TransactionID),
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// orderPaymentColumns is the column list shared by order payment queries (see
+// scanOrderPayments). Only the end of gift card codes is shown.
+const orderPaymentColumns = `op.id, op.order_id, op.method, op.amount, op.status, op.gift_card_id,
+		       COALESCE(RIGHT(gc.code, 4), ''), COALESCE(op.transaction_id, ''),
+		       (SELECT COALESCE(SUM(rp.amount), 0) FROM order_refund_payments rp WHERE rp.order_payment_id = op.id),
+		       op.created_at, op.updated_at`
+
+// scanOrderPayments scans rows selected with orderPaymentColumns
+func scanOrderPayments(rows *sql.Rows) ([]models.OrderPayment, error) {
+	defer rows.Close()
+
+	payments := []models.OrderPayment{}
+	for rows.Next() {
+		var p models.OrderPayment
+		err := rows.Scan(
+			&p.ID, &p.OrderID, &p.Method, &p.Amount, &p.Status, &p.GiftCardID,
+			&p.GiftCardCode, &p.TransactionID, &p.Refunded, &p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+// getOrderPayments retrieves the tenders of an order
+func (r *OrderRepository) getOrderPayments(orderID int) ([]models.OrderPayment, error) {
+	rows, err := r.db.Query(`
+		SELECT `+orderPaymentColumns+`
+		FROM order_payments op
+		LEFT JOIN gift_cards gc ON gc.id = op.gift_card_id
+		WHERE op.order_id = $1
+		ORDER BY op.id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return scanOrderPayments(rows)
+}
+
+// BeginTx starts a transaction for placing or refunding an order
+func (r *OrderRepository) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// LockOrder row-locks an order so its refunds and cancellation are recorded one
+// at a time. Returns the order's status and payment status, or "" if the order
+// doesn't exist.
+func (r *OrderRepository) LockOrder(tx *sql.Tx, orderID int) (string, string, error) {
+	var status, paymentStatus string
+	err := tx.QueryRow(
+		`SELECT status, payment_status FROM orders WHERE id = $1 FOR UPDATE`, orderID,
+	).Scan(&status, &paymentStatus)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return status, paymentStatus, err
+}
+
+// GetCapturedPayments retrieves the payments of an order that were actually
+// captured, with what refunds and cancellation already gave back through each
+func (r *OrderRepository) GetCapturedPayments(tx *sql.Tx, orderID int) ([]models.OrderPayment, error) {
+	rows, err := tx.Query(`
+		SELECT `+orderPaymentColumns+`
+		FROM order_payments op
+		LEFT JOIN gift_cards gc ON gc.id = op.gift_card_id
+		WHERE op.order_id = $1 AND op.status = $2
+		ORDER BY op.id
+	`, orderID, models.OrderPaymentCaptured)
+	if err != nil {
+		return nil, err
+	}
+	return scanOrderPayments(rows)
+}
+
+// GetRefundedItems sums the refunded quantity, amount and tax of each order line
+func (r *OrderRepository) GetRefundedItems(tx *sql.Tx, orderID int) (map[int]models.OrderRefundItem, error) {
+	query := `
+		SELECT ri.order_item_id, SUM(ri.quantity), SUM(ri.amount), SUM(ri.tax_amount)
+		FROM order_refund_items ri
+		JOIN order_refunds rf ON rf.id = ri.refund_id
+		WHERE rf.order_id = $1
+		GROUP BY ri.order_item_id
+	`
+
+	rows, err := tx.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunded := map[int]models.OrderRefundItem{}
+	for rows.Next() {
+		var item models.OrderRefundItem
+		if err := rows.Scan(&item.OrderItemID, &item.Quantity, &item.Amount, &item.TaxAmount); err != nil {
+			return nil, err
+		}
+		refunded[item.OrderItemID] = item
+	}
+
+	return refunded, rows.Err()
+}
+
+// CreateRefund records a refund and its lines
+func (r *OrderRepository) CreateRefund(tx *sql.Tx, refund *models.OrderRefund) error {
+	query := `
+		INSERT INTO order_refunds (order_id, amount, tax, reason, method, created_by, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := tx.QueryRow(
+		query, refund.OrderID, refund.Amount, refund.Tax, nullIfEmpty(refund.Reason), refund.Method, refund.CreatedBy, refund.APIKeyID,
+	).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range refund.Items {
+		item := &refund.Items[i]
+		item.RefundID = refund.ID
+		err := tx.QueryRow(`
+			INSERT INTO order_refund_items (refund_id, order_item_id, quantity, amount, tax_amount)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, item.RefundID, item.OrderItemID, item.Quantity, item.Amount, item.TaxAmount).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRefundPayment records the part of a refund (or cancellation) given back through one payment
+func (r *OrderRepository) CreateRefundPayment(tx *sql.Tx, p *models.OrderRefundPayment) error {
+	query := `
+		INSERT INTO order_refund_payments (refund_id, order_payment_id, amount, method, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return tx.QueryRow(
+		query, nullIfZero(p.RefundID), p.OrderPaymentID, p.Amount, p.Method, p.Status,
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+// SetPaymentStatus updates an order's payment status inside a transaction
+func (r *OrderRepository) SetPaymentStatus(tx *sql.Tx, orderID int, status string) error {
+	query := `UPDATE orders SET payment_status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	_, err := tx.Exec(query, status, orderID)
+	return err
+}
+
+// getOrderRefunds retrieves the refunds of an order with their lines
+func (r *OrderRepository) getOrderRefunds(orderID int) ([]models.OrderRefund, error) {
+	query := `
+		SELECT rf.id, rf.order_id, rf.amount, rf.tax, COALESCE(rf.reason, ''), rf.method, rf.created_by, rf.api_key_id, rf.created_at,
+		       ri.id, ri.order_item_id, ri.quantity, ri.amount, ri.tax_amount
+		FROM order_refunds rf
+		JOIN order_refund_items ri ON ri.refund_id = rf.id
+		WHERE rf.order_id = $1
+		ORDER BY rf.id, ri.id
+	`
+
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []models.OrderRefund{}
+	for rows.Next() {
+		var rf models.OrderRefund
+		var item models.OrderRefundItem
+		err := rows.Scan(
+			&rf.ID, &rf.OrderID, &rf.Amount, &rf.Tax, &rf.Reason, &rf.Method, &rf.CreatedBy, &rf.APIKeyID, &rf.CreatedAt,
+			&item.ID, &item.OrderItemID, &item.Quantity, &item.Amount, &item.TaxAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.RefundID = rf.ID
+
+		if n := len(refunds); n > 0 && refunds[n-1].ID == rf.ID {
+			refunds[n-1].Items = append(refunds[n-1].Items, item)
+			continue
+		}
+		rf.Items = []models.OrderRefundItem{item}
+		rf.Payments = []models.OrderRefundPayment{}
+		refunds = append(refunds, rf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Attach where each refund's money went
+	index := map[int]int{}
+	for i, rf := range refunds {
+		index[rf.ID] = i
+	}
+	paymentRows, err := r.db.Query(`
+		SELECT rp.id, rp.refund_id, rp.order_payment_id, rp.amount, rp.method, rp.status, rp.created_at
+		FROM order_refund_payments rp
+		JOIN order_refunds rf ON rf.id = rp.refund_id
+		WHERE rf.order_id = $1
+		ORDER BY rp.id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer paymentRows.Close()
+
+	for paymentRows.Next() {
+		var p models.OrderRefundPayment
+		err := paymentRows.Scan(&p.ID, &p.RefundID, &p.OrderPaymentID, &p.Amount, &p.Method, &p.Status, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[p.RefundID]; ok {
+			refunds[i].Payments = append(refunds[i].Payments, p)
+		}
+	}
+
+	return refunds, paymentRows.Err()
+}
+
 // GenerateOrderNumber generates a unique order number
 func (r *OrderRepository) GenerateOrderNumber() string {
 	return fmt.Sprintf("ORD-%d", time.Now().UnixNano()/1000000)
@@ -316,9 +673,9 @@ func (r *OrderRepository) CreateAddress(addr *models.Address) (int, error) {
 	return id, err
 }
 
-// UpdateOrderStatus updates order status
-func (r *OrderRepository) UpdateOrderStatus(orderID int, status string) error {
+// UpdateOrderStatus updates order status inside a transaction
+func (r *OrderRepository) UpdateOrderStatus(tx *sql.Tx, orderID int, status string) error {
 	query := `UPDATE orders SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(query, status, orderID)
+	_, err := tx.Exec(query, status, orderID)
 	return err
 }
\ No newline at end of file
@@ -67,6 +67,14 @@ func (r *ProductRepository) GetAll(query *models.ProductListQuery) ([]models.Pro
 		argCount++
 	}
 	
+	if query.OnSale {
+		sql += ` AND EXISTS (
+			SELECT 1 FROM sale_prices s
+			WHERE (s.product_id = p.id OR s.product_variant_id IN (SELECT id FROM product_variants WHERE product_id = p.id))
+			  AND ` + activeSaleCondition + `
+		)`
+	}
+	
 	if query.Search != "" {
 		// Temporarily using ILIKE only for debugging
 		sql += fmt.Sprintf(" AND p.name ILIKE $%d", argCount)
@@ -111,18 +119,28 @@ func (r *ProductRepository) GetAll(query *models.ProductListQuery) ([]models.Pro
 	return products, nil
 }
 
-// GetByID retrieves a single product with all details
+// GetByID retrieves a single active product with all details
 func (r *ProductRepository) GetByID(id int) (*models.Product, error) {
+	return r.getByID(id, true)
+}
+
+// GetByIDIncludingInactive retrieves a product even if it was deactivated
+// (e.g. to explain why a cart line can no longer be bought)
+func (r *ProductRepository) GetByIDIncludingInactive(id int) (*models.Product, error) {
+	return r.getByID(id, false)
+}
+
+func (r *ProductRepository) getByID(id int, activeOnly bool) (*models.Product, error) {
 	product := &models.Product{}
 	
 	query := `
 		SELECT id, name, slug, description, brand_id, category_id, 
 		       base_price, is_active, created_at, updated_at
 		FROM products
-		WHERE id = $1 AND is_active = true
+		WHERE id = $1 AND (is_active = true OR NOT $2)
 	`
 	
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRow(query, id, activeOnly).Scan(
 		&product.ID, &product.Name, &product.Slug, &product.Description,
 		&product.BrandID, &product.CategoryID, &product.BasePrice,
 		&product.IsActive, &product.CreatedAt, &product.UpdatedAt,
@@ -169,7 +187,7 @@ func (r *ProductRepository) GetByID(id int) (*models.Product, error) {
 // getVariantsByProductID retrieves all variants for a product
 func (r *ProductRepository) getVariantsByProductID(productID int) ([]models.ProductVariant, error) {
 	query := `
-		SELECT id, product_id, sku, size, color, color_hex, stock_quantity, price_adjustment
+		SELECT id, product_id, sku, size, color, color_hex, stock_quantity, price_adjustment, weight_grams
 		FROM product_variants
 		WHERE product_id = $1
 		ORDER BY size, color
@@ -186,7 +204,7 @@ func (r *ProductRepository) getVariantsByProductID(productID int) ([]models.Prod
 		var v models.ProductVariant
 		err := rows.Scan(
 			&v.ID, &v.ProductID, &v.SKU, &v.Size, &v.Color,
-			&v.ColorHex, &v.StockQuantity, &v.PriceAdjustment,
+			&v.ColorHex, &v.StockQuantity, &v.PriceAdjustment, &v.WeightGrams,
 		)
 		if err != nil {
 			return nil, err
@@ -343,14 +361,14 @@ func (r *ProductRepository) SearchSuggestions(searchTerm string, limit int) ([]s
 }
 
 // ReduceStock reduces the stock quantity of a product variant
-func (r *ProductRepository) ReduceStock(variantID int, quantity int) error {
+func (r *ProductRepository) ReduceStock(tx *sql.Tx, variantID int, quantity int) error {
 	query := `
 		UPDATE product_variants 
 		SET stock_quantity = stock_quantity - $1
 		WHERE id = $2 AND stock_quantity >= $1
 	`
 	
-	result, err := r.db.Exec(query, quantity, variantID)
+	result, err := tx.Exec(query, quantity, variantID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+// promotionColumns is the column list shared by all promotion queries (see scanPromotion)
+const promotionColumns = `id, name, COALESCE(description, ''), type, brand_ids, category_ids,
+		       buy_quantity, get_quantity, percent, tiers, bundle_groups,
+		       priority, starts_at, ends_at, is_active, created_at, updated_at`
+
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+// scanPromotion scans a row selected with promotionColumns
+func scanPromotion(row rowScanner) (*models.Promotion, error) {
+	p := &models.Promotion{}
+	var tiers, groups []byte
+	err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &p.Type, pq.Array(&p.BrandIDs), pq.Array(&p.CategoryIDs),
+		&p.BuyQuantity, &p.GetQuantity, &p.Percent, &tiers, &groups,
+		&p.Priority, &p.StartsAt, &p.EndsAt, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(tiers, &p.Tiers); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(groups, &p.BundleGroups); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetAll retrieves all promotions in evaluation order
+func (r *PromotionRepository) GetAll() ([]models.Promotion, error) {
+	return r.list(`SELECT ` + promotionColumns + ` FROM promotions ORDER BY priority, id`)
+}
+
+// GetActive retrieves promotions that are active right now, in evaluation order
+func (r *PromotionRepository) GetActive() ([]models.Promotion, error) {
+	return r.list(`
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE is_active = true
+		  AND (starts_at IS NULL OR starts_at <= CURRENT_TIMESTAMP)
+		  AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP)
+		ORDER BY priority, id
+	`)
+}
+
+func (r *PromotionRepository) list(query string) ([]models.Promotion, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+
+	return promotions, rows.Err()
+}
+
+// GetByID retrieves a promotion
+func (r *PromotionRepository) GetByID(id int) (*models.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRow(`SELECT `+promotionColumns+` FROM promotions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// Create creates a promotion
+func (r *PromotionRepository) Create(p *models.Promotion) error {
+	tiers, groups, err := marshalPromotionRules(p)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO promotions (
+			name, description, type, brand_ids, category_ids,
+			buy_quantity, get_quantity, percent, tiers, bundle_groups,
+			priority, starts_at, ends_at, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		p.Name, p.Description, p.Type, pq.Array(p.BrandIDs), pq.Array(p.CategoryIDs),
+		p.BuyQuantity, p.GetQuantity, p.Percent, tiers, groups,
+		p.Priority, p.StartsAt, p.EndsAt, p.IsActive,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// Update updates a promotion. Returns false if it doesn't exist.
+func (r *PromotionRepository) Update(p *models.Promotion) (bool, error) {
+	tiers, groups, err := marshalPromotionRules(p)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE promotions
+		SET name = $2, description = $3, type = $4, brand_ids = $5, category_ids = $6,
+		    buy_quantity = $7, get_quantity = $8, percent = $9, tiers = $10, bundle_groups = $11,
+		    priority = $12, starts_at = $13, ends_at = $14, is_active = $15,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err = r.db.QueryRow(
+		query, p.ID,
+		p.Name, p.Description, p.Type, pq.Array(p.BrandIDs), pq.Array(p.CategoryIDs),
+		p.BuyQuantity, p.GetQuantity, p.Percent, tiers, groups,
+		p.Priority, p.StartsAt, p.EndsAt, p.IsActive,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete deletes a promotion; past orders keep their discount lines.
+// Returns false if it doesn't exist.
+func (r *PromotionRepository) Delete(id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM promotions WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func marshalPromotionRules(p *models.Promotion) ([]byte, []byte, error) {
+	tiers, err := json.Marshal(p.Tiers)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups, err := json.Marshal(p.BundleGroups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tiers, groups, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// roleColumns is the column list shared by all role queries (see scanRole)
+const roleColumns = `ro.id, ro.name, COALESCE(ro.description, ''), ro.built_in,
+		       ARRAY(SELECT permission FROM role_permissions WHERE role_id = ro.id ORDER BY permission),
+		       (SELECT COUNT(*) FROM users WHERE role = ro.name), ro.created_at, ro.updated_at`
+
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// scanRole scans a row selected with roleColumns
+func scanRole(row rowScanner) (*models.Role, error) {
+	role := &models.Role{}
+	err := row.Scan(&role.ID, &role.Name, &role.Description, &role.BuiltIn, pq.Array(&role.Permissions),
+		&role.UserCount, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// GetPermissions retrieves every permission a role can have
+func (r *RoleRepository) GetPermissions() ([]models.Permission, error) {
+	rows, err := r.db.Query(`SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+
+	return permissions, rows.Err()
+}
+
+// GetRoles retrieves all roles with their permissions
+func (r *RoleRepository) GetRoles() ([]models.Role, error) {
+	rows, err := r.db.Query(`SELECT ` + roleColumns + ` FROM roles ro ORDER BY ro.built_in DESC, ro.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetRole retrieves a role by ID
+func (r *RoleRepository) GetRole(id int) (*models.Role, error) {
+	return r.get(`SELECT `+roleColumns+` FROM roles ro WHERE ro.id = $1`, id)
+}
+
+// GetRoleByName retrieves a role by name
+func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
+	return r.get(`SELECT `+roleColumns+` FROM roles ro WHERE ro.name = $1`, name)
+}
+
+func (r *RoleRepository) get(query string, args ...interface{}) (*models.Role, error) {
+	role, err := scanRole(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return role, err
+}
+
+// CreateRole inserts a role with its permissions
+func (r *RoleRepository) CreateRole(role *models.Role) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO roles (name, description)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, role.Name, nullIfEmpty(role.Description)).Scan(&role.ID, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := setRolePermissions(tx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateRole changes a custom role's description and permissions. Returns
+// false if it doesn't exist or is built in.
+func (r *RoleRepository) UpdateRole(role *models.Role) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE roles
+		SET description = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND NOT built_in
+	`, role.ID, nullIfEmpty(role.Description))
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := setRolePermissions(tx, role.ID, role.Permissions); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func setRolePermissions(tx *sql.Tx, roleID int, permissions []string) error {
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT INTO role_permissions (role_id, permission)
+		SELECT $1, UNNEST($2::text[])
+	`, roleID, pq.Array(permissions))
+	return err
+}
+
+// DeleteRole deletes a custom role no user has. Returns false if it doesn't
+// exist, is built in or is still assigned.
+func (r *RoleRepository) DeleteRole(id int) (bool, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM roles ro
+		WHERE ro.id = $1 AND NOT ro.built_in
+		  AND NOT EXISTS (SELECT 1 FROM users WHERE role = ro.name)
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UserHasPermission reports whether a user's role has a permission
+func (r *RoleRepository) UserHasPermission(userID int, permission string) (bool, error) {
+	var has bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM users u
+			JOIN roles ro ON ro.name = u.role
+			JOIN role_permissions rp ON rp.role_id = ro.id
+			WHERE u.id = $1 AND rp.permission = $2
+		)
+	`, userID, permission).Scan(&has)
+	return has, err
+}
+
+// SetUserRole assigns a role to a user. Returns false if the user doesn't exist.
+func (r *RoleRepository) SetUserRole(userID int, role string) (bool, error) {
+	result, err := r.db.Exec(
+		`UPDATE users SET role = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, userID, role,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// activeSaleCondition matches sales (aliased s) that are running and not sold out
+const activeSaleCondition = `s.starts_at <= CURRENT_TIMESTAMP AND s.ends_at > CURRENT_TIMESTAMP
+			  AND (s.quantity_limit IS NULL OR s.quantity_sold < s.quantity_limit)`
+
+// saleColumns is the column list shared by all sale queries (see scanSale)
+const saleColumns = `s.id, s.product_id, s.product_variant_id, s.price, s.starts_at, s.ends_at,
+		       s.quantity_limit, s.quantity_sold, s.created_at, s.updated_at`
+
+type SaleRepository struct {
+	db *sql.DB
+}
+
+func NewSaleRepository(db *sql.DB) *SaleRepository {
+	return &SaleRepository{db: db}
+}
+
+// scanSale scans a row selected with saleColumns
+func scanSale(row rowScanner) (*models.SalePrice, error) {
+	s := &models.SalePrice{}
+	err := row.Scan(
+		&s.ID, &s.ProductID, &s.ProductVariantID, &s.Price, &s.StartsAt, &s.EndsAt,
+		&s.QuantityLimit, &s.QuantitySold, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *SaleRepository) list(query string, args ...interface{}) ([]models.SalePrice, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []models.SalePrice{}
+	for rows.Next() {
+		s, err := scanSale(rows)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, *s)
+	}
+
+	return sales, rows.Err()
+}
+
+// GetAll retrieves all sales, latest start first
+func (r *SaleRepository) GetAll() ([]models.SalePrice, error) {
+	return r.list(`SELECT ` + saleColumns + ` FROM sale_prices s ORDER BY s.starts_at DESC`)
+}
+
+// GetActiveForProducts retrieves the running sales of the given products and their variants
+func (r *SaleRepository) GetActiveForProducts(productIDs []int) ([]models.SalePrice, error) {
+	ids := make([]int64, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = int64(id)
+	}
+
+	query := `
+		SELECT ` + saleColumns + `
+		FROM sale_prices s
+		LEFT JOIN product_variants pv ON pv.id = s.product_variant_id
+		WHERE (s.product_id = ANY($1) OR pv.product_id = ANY($1))
+		  AND ` + activeSaleCondition + `
+		ORDER BY s.price
+	`
+	return r.list(query, pq.Array(ids))
+}
+
+// GetByID retrieves a sale
+func (r *SaleRepository) GetByID(id int) (*models.SalePrice, error) {
+	s, err := scanSale(r.db.QueryRow(`SELECT `+saleColumns+` FROM sale_prices s WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// Create creates a sale
+func (r *SaleRepository) Create(s *models.SalePrice) error {
+	query := `
+		INSERT INTO sale_prices (product_id, product_variant_id, price, starts_at, ends_at, quantity_limit)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, quantity_sold, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query, s.ProductID, s.ProductVariantID, s.Price, s.StartsAt, s.EndsAt, s.QuantityLimit,
+	).Scan(&s.ID, &s.QuantitySold, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// Update updates a sale. The sold counter is never overwritten.
+// Returns false if the sale doesn't exist.
+func (r *SaleRepository) Update(s *models.SalePrice) (bool, error) {
+	query := `
+		UPDATE sale_prices
+		SET product_id = $2, product_variant_id = $3, price = $4, starts_at = $5, ends_at = $6,
+		    quantity_limit = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING quantity_sold, created_at, updated_at
+	`
+	err := r.db.QueryRow(
+		query, s.ID, s.ProductID, s.ProductVariantID, s.Price, s.StartsAt, s.EndsAt, s.QuantityLimit,
+	).Scan(&s.QuantitySold, &s.CreatedAt, &s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete deletes a sale. Returns false if it doesn't exist.
+func (r *SaleRepository) Delete(id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM sale_prices WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Reserve takes quantity units from a sale in one statement, so concurrent
+// checkouts can't sell more than a flash sale's limit.
+// Returns false if the sale ended or doesn't have that many units left.
+func (r *SaleRepository) Reserve(id, quantity int) (bool, error) {
+	query := `
+		UPDATE sale_prices s
+		SET quantity_sold = quantity_sold + $2, updated_at = CURRENT_TIMESTAMP
+		WHERE s.id = $1
+		  AND s.starts_at <= CURRENT_TIMESTAMP AND s.ends_at > CURRENT_TIMESTAMP
+		  AND (s.quantity_limit IS NULL OR s.quantity_sold + $2 <= s.quantity_limit)
+	`
+	result, err := r.db.Exec(query, id, quantity)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Release gives back units reserved for an order that wasn't placed
+func (r *SaleRepository) Release(id, quantity int) error {
+	query := `
+		UPDATE sale_prices
+		SET quantity_sold = GREATEST(quantity_sold - $2, 0), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, quantity)
+	return err
+}
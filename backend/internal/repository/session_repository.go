@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+	"time"
+)
+
+// sessionColumns is the column list shared by all session queries (see scanSession)
+const sessionColumns = `id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       expires_at, revoked_at, last_used_at, created_at, two_factor`
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row rowScanner) (*models.Session, error) {
+	s := &models.Session{}
+	err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.ExpiresAt, &s.RevokedAt, &s.LastUsedAt, &s.CreatedAt, &s.TwoFactor)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create starts a session with the hash of its first refresh token
+func (r *SessionRepository) Create(s *models.Session, tokenHash string) error {
+	query := `
+		INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at, two_factor)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, last_used_at, created_at
+	`
+	return r.db.QueryRow(
+		query, s.UserID, tokenHash, nullIfEmpty(s.UserAgent), nullIfEmpty(s.IPAddress), s.ExpiresAt, s.TwoFactor,
+	).Scan(&s.ID, &s.LastUsedAt, &s.CreatedAt)
+}
+
+// GetByTokenHash finds the session whose current refresh token has this hash
+func (r *SessionRepository) GetByTokenHash(tokenHash string) (*models.Session, error) {
+	return r.get(`SELECT `+sessionColumns+` FROM sessions WHERE refresh_token_hash = $1`, tokenHash)
+}
+
+// GetByPreviousTokenHash finds the session whose last replaced refresh token has this hash
+func (r *SessionRepository) GetByPreviousTokenHash(tokenHash string) (*models.Session, error) {
+	return r.get(`SELECT `+sessionColumns+` FROM sessions WHERE previous_token_hash = $1`, tokenHash)
+}
+
+// GetByID finds a session by ID
+func (r *SessionRepository) GetByID(id int) (*models.Session, error) {
+	return r.get(`SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id)
+}
+
+func (r *SessionRepository) get(query string, args ...interface{}) (*models.Session, error) {
+	s, err := scanSession(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// Rotate replaces a session's refresh token. Returns false if the token was
+// already rotated or the session revoked, so a token can be used only once.
+func (r *SessionRepository) Rotate(id int, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE sessions
+		SET refresh_token_hash = $3, previous_token_hash = $2, expires_at = $4, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL
+	`, id, oldHash, newHash, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// IsActive reports whether a user's session exists and hasn't been revoked or
+// expired, and whether its login passed a second factor
+func (r *SessionRepository) IsActive(id, userID int) (bool, bool, error) {
+	var active, twoFactor bool
+	err := r.db.QueryRow(`
+		SELECT revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP, two_factor
+		FROM sessions
+		WHERE id = $1 AND user_id = $2
+	`, id, userID).Scan(&active, &twoFactor)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	return active, twoFactor, err
+}
+
+// MarkTwoFactor records that a session's user has passed a second factor
+func (r *SessionRepository) MarkTwoFactor(id int) error {
+	_, err := r.db.Exec(`UPDATE sessions SET two_factor = TRUE WHERE id = $1`, id)
+	return err
+}
+
+// Revoke ends a session
+func (r *SessionRepository) Revoke(id int) error {
+	_, err := r.db.Exec(`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`, id)
+	return err
+}
+
+// RevokeAllForUser ends all of a user's sessions. Returns how many were active.
+func (r *SessionRepository) RevokeAllForUser(userID int) (int64, error) {
+	result, err := r.db.Exec(
+		`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
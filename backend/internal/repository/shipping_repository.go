@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// shippingMethodColumns is the column list shared by shipping method queries (see scanShippingMethod)
+const shippingMethodColumns = `m.id, m.code, m.name, COALESCE(m.description, ''), m.sort_order, m.is_active,
+		       m.created_at, m.updated_at`
+
+// shippingRateColumns is the column list shared by shipping rate queries (see scanShippingRate)
+const shippingRateColumns = `sr.id, sr.shipping_method_id, COALESCE(sr.country, ''), COALESCE(sr.city, ''),
+		       sr.price, sr.per_item_price, sr.per_kg_price, sr.max_weight_grams, sr.free_shipping_threshold,
+		       sr.min_days, sr.max_days, sr.created_at, sr.updated_at`
+
+type ShippingRepository struct {
+	db *sql.DB
+}
+
+func NewShippingRepository(db *sql.DB) *ShippingRepository {
+	return &ShippingRepository{db: db}
+}
+
+// scanShippingMethod scans a row selected with shippingMethodColumns
+func scanShippingMethod(row rowScanner) (*models.ShippingMethod, error) {
+	m := &models.ShippingMethod{Rates: []models.ShippingRate{}}
+	err := row.Scan(&m.ID, &m.Code, &m.Name, &m.Description, &m.SortOrder, &m.IsActive, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// scanShippingRate scans a row selected with shippingRateColumns
+func scanShippingRate(row rowScanner) (*models.ShippingRate, error) {
+	sr := &models.ShippingRate{}
+	err := row.Scan(
+		&sr.ID, &sr.ShippingMethodID, &sr.Country, &sr.City,
+		&sr.Price, &sr.PerItemPrice, &sr.PerKgPrice, &sr.MaxWeightGrams, &sr.FreeThreshold,
+		&sr.MinDays, &sr.MaxDays, &sr.CreatedAt, &sr.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sr, nil
+}
+
+// GetMethods retrieves shipping methods with their rates, in display order
+func (r *ShippingRepository) GetMethods(activeOnly bool) ([]models.ShippingMethod, error) {
+	rows, err := r.db.Query(`
+		SELECT `+shippingMethodColumns+`
+		FROM shipping_methods m
+		WHERE m.is_active OR NOT $1
+		ORDER BY m.sort_order, m.id
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	methods := []models.ShippingMethod{}
+	index := map[int]int{}
+	for rows.Next() {
+		m, err := scanShippingMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[m.ID] = len(methods)
+		methods = append(methods, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rateRows, err := r.db.Query(`SELECT ` + shippingRateColumns + ` FROM shipping_rates sr ORDER BY sr.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rateRows.Close()
+
+	for rateRows.Next() {
+		sr, err := scanShippingRate(rateRows)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[sr.ShippingMethodID]; ok {
+			methods[i].Rates = append(methods[i].Rates, *sr)
+		}
+	}
+
+	return methods, rateRows.Err()
+}
+
+// GetMethodByCode retrieves a shipping method without its rates
+func (r *ShippingRepository) GetMethodByCode(code string) (*models.ShippingMethod, error) {
+	m, err := scanShippingMethod(r.db.QueryRow(`SELECT `+shippingMethodColumns+` FROM shipping_methods m WHERE m.code = $1`, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+// CreateMethod creates a shipping method
+func (r *ShippingRepository) CreateMethod(m *models.ShippingMethod) error {
+	query := `
+		INSERT INTO shipping_methods (code, name, description, sort_order, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query, m.Code, m.Name, nullIfEmpty(m.Description), m.SortOrder, m.IsActive,
+	).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+}
+
+// UpdateMethod updates a shipping method. Returns false if it doesn't exist.
+func (r *ShippingRepository) UpdateMethod(m *models.ShippingMethod) (bool, error) {
+	query := `
+		UPDATE shipping_methods
+		SET code = $2, name = $3, description = $4, sort_order = $5, is_active = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err := r.db.QueryRow(
+		query, m.ID, m.Code, m.Name, nullIfEmpty(m.Description), m.SortOrder, m.IsActive,
+	).Scan(&m.CreatedAt, &m.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateRate creates a shipping rate
+func (r *ShippingRepository) CreateRate(sr *models.ShippingRate) error {
+	query := `
+		INSERT INTO shipping_rates (
+			shipping_method_id, country, city, price, per_item_price, per_kg_price,
+			max_weight_grams, free_shipping_threshold, min_days, max_days
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query, sr.ShippingMethodID, nullIfEmpty(sr.Country), nullIfEmpty(sr.City),
+		sr.Price, sr.PerItemPrice, sr.PerKgPrice, sr.MaxWeightGrams, sr.FreeThreshold, sr.MinDays, sr.MaxDays,
+	).Scan(&sr.ID, &sr.CreatedAt, &sr.UpdatedAt)
+}
+
+// UpdateRate updates a shipping rate. Returns false if it doesn't exist.
+func (r *ShippingRepository) UpdateRate(sr *models.ShippingRate) (bool, error) {
+	query := `
+		UPDATE shipping_rates
+		SET shipping_method_id = $2, country = $3, city = $4, price = $5, per_item_price = $6, per_kg_price = $7,
+		    max_weight_grams = $8, free_shipping_threshold = $9, min_days = $10, max_days = $11,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err := r.db.QueryRow(
+		query, sr.ID, sr.ShippingMethodID, nullIfEmpty(sr.Country), nullIfEmpty(sr.City),
+		sr.Price, sr.PerItemPrice, sr.PerKgPrice, sr.MaxWeightGrams, sr.FreeThreshold, sr.MinDays, sr.MaxDays,
+	).Scan(&sr.CreatedAt, &sr.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteRate deletes a shipping rate. Returns false if it doesn't exist.
+func (r *ShippingRepository) DeleteRate(id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM shipping_rates WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetVariantWeights retrieves the unit weight in grams of each variant
+func (r *ShippingRepository) GetVariantWeights(variantIDs []int) (map[int]int, error) {
+	ids := make([]int64, len(variantIDs))
+	for i, id := range variantIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := r.db.Query(`SELECT id, weight_grams FROM product_variants WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weights := map[int]int{}
+	for rows.Next() {
+		var id, grams int
+		if err := rows.Scan(&id, &grams); err != nil {
+			return nil, err
+		}
+		weights[id] = grams
+	}
+
+	return weights, rows.Err()
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"ecommerce-backend/internal/models"
+)
+
+// taxRateColumns is the column list shared by all tax rate queries (see scanTaxRate)
+const taxRateColumns = `t.id, t.country, t.category_id, t.rate, t.name, t.created_at, t.updated_at`
+
+type TaxRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRepository(db *sql.DB) *TaxRepository {
+	return &TaxRepository{db: db}
+}
+
+// scanTaxRate scans a row selected with taxRateColumns
+func scanTaxRate(row rowScanner) (*models.TaxRate, error) {
+	t := &models.TaxRate{}
+	err := row.Scan(&t.ID, &t.Country, &t.CategoryID, &t.Rate, &t.Name, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetAll retrieves all tax rates by country, default rate first
+func (r *TaxRepository) GetAll() ([]models.TaxRate, error) {
+	rows, err := r.db.Query(`
+		SELECT ` + taxRateColumns + `
+		FROM tax_rates t
+		ORDER BY LOWER(t.country), t.category_id NULLS FIRST
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.TaxRate{}
+	for rows.Next() {
+		t, err := scanTaxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *t)
+	}
+
+	return rates, rows.Err()
+}
+
+// GetByID retrieves a tax rate
+func (r *TaxRepository) GetByID(id int) (*models.TaxRate, error) {
+	t, err := scanTaxRate(r.db.QueryRow(`SELECT `+taxRateColumns+` FROM tax_rates t WHERE t.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// Find retrieves the rate for a country and exact category (nil = country default)
+func (r *TaxRepository) Find(country string, categoryID *int) (*models.TaxRate, error) {
+	query := `
+		SELECT ` + taxRateColumns + `
+		FROM tax_rates t
+		WHERE LOWER(t.country) = LOWER($1) AND COALESCE(t.category_id, 0) = COALESCE($2, 0)
+	`
+	t, err := scanTaxRate(r.db.QueryRow(query, country, categoryID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// GetRate retrieves the rate that applies to a category in a country: the rate of the
+// category or its nearest parent that has one, else the country default.
+// Returns nil if the country has no rates.
+func (r *TaxRepository) GetRate(country string, categoryID *int) (*models.TaxRate, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = $2
+			UNION ALL
+			SELECT c.id, c.parent_id, chain.depth + 1
+			FROM categories c
+			JOIN chain ON c.id = chain.parent_id
+		)
+		SELECT ` + taxRateColumns + `
+		FROM tax_rates t
+		LEFT JOIN chain ON chain.id = t.category_id
+		WHERE LOWER(t.country) = LOWER($1)
+		  AND (t.category_id IS NULL OR chain.id IS NOT NULL)
+		ORDER BY t.category_id IS NULL, chain.depth
+		LIMIT 1
+	`
+	t, err := scanTaxRate(r.db.QueryRow(query, country, categoryID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// Create creates a tax rate
+func (r *TaxRepository) Create(t *models.TaxRate) error {
+	query := `
+		INSERT INTO tax_rates (country, category_id, rate, name)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(query, t.Country, t.CategoryID, t.Rate, t.Name).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// Update updates a tax rate. Returns false if it doesn't exist.
+func (r *TaxRepository) Update(t *models.TaxRate) (bool, error) {
+	query := `
+		UPDATE tax_rates
+		SET country = $2, category_id = $3, rate = $4, name = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err := r.db.QueryRow(query, t.ID, t.Country, t.CategoryID, t.Rate, t.Name).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete deletes a tax rate. Returns false if it doesn't exist.
+func (r *TaxRepository) Delete(id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
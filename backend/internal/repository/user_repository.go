@@ -3,6 +3,9 @@ package repository
 import (
 	"database/sql"
 	"ecommerce-backend/internal/models"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type UserRepository struct {
@@ -13,33 +16,64 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Create inserts a new user
+// Create inserts a new user; without a PasswordHash the user can't log in with a password
 func (r *UserRepository) Create(user *models.User) error {
+	return createUser(r.db, user)
+}
+
+// CreateWithIdentity inserts a user created through social sign-in together
+// with their provider identity
+func (r *UserRepository) CreateWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createUser(tx, user); err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	if err := linkIdentity(tx, identity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func createUser(q queryRower, user *models.User) error {
 	query := `
-		INSERT INTO users (email, password_hash, first_name, last_name, phone, role)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (email, password_hash, first_name, last_name, phone, role, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRow(
+	err := q.QueryRow(
 		query,
 		user.Email,
-		user.PasswordHash,
+		nullIfEmpty(user.PasswordHash),
 		user.FirstName,
 		user.LastName,
 		user.Phone,
 		user.Role,
+		user.EmailVerifiedAt,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	user.HasPassword = user.PasswordHash != ""
+	return nil
 }
 
-// FindByEmail finds a user by email
-func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+// userColumns is the column list shared by all user queries (see scanUser)
+const userColumns = `id, email, COALESCE(password_hash, ''), first_name, last_name, phone, role,
+		       ARRAY(SELECT rp.permission FROM role_permissions rp JOIN roles ro ON ro.id = rp.role_id
+		             WHERE ro.name = users.role ORDER BY rp.permission),
+		       email_verified_at, totp_enabled_at IS NOT NULL, created_at, updated_at`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row rowScanner) (*models.User, error) {
 	user := &models.User{}
-	query := `
-		SELECT id, email, password_hash, first_name, last_name, phone, role, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
-	err := r.db.QueryRow(query, email).Scan(
+	err := row.Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -47,9 +81,22 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 		&user.LastName,
 		&user.Phone,
 		&user.Role,
+		pq.Array(&user.Permissions),
+		&user.EmailVerifiedAt,
+		&user.TwoFactorEnabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	user.HasPassword = user.PasswordHash != ""
+	return user, nil
+}
+
+// FindByEmail finds a user by email
+func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = $1`, email))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -58,37 +105,51 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 
 // FindByID finds a user by ID
 func (r *UserRepository) FindByID(id int) (*models.User, error) {
-	user := &models.User{}
-	query := `
-		SELECT id, email, password_hash, first_name, last_name, phone, role, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.PasswordHash,
-		&user.FirstName,
-		&user.LastName,
-		&user.Phone,
-		&user.Role,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return user, err
+}
+
+// FindByIdentity finds the user linked to a provider identity
+func (r *UserRepository) FindByIdentity(provider, subject string) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRow(`
+		SELECT `+userColumns+` FROM users
+		WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)
+	`, provider, subject))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	return user, err
 }
 
+// LinkIdentity links a provider identity to an existing user
+func (r *UserRepository) LinkIdentity(identity *models.UserIdentity) error {
+	return linkIdentity(r.db, identity)
+}
+
+func linkIdentity(q queryRower, identity *models.UserIdentity) error {
+	return q.QueryRow(`
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, last_login_at
+	`, identity.UserID, identity.Provider, identity.Subject, nullIfEmpty(identity.Email),
+	).Scan(&identity.ID, &identity.CreatedAt, &identity.LastLoginAt)
+}
+
+// TouchIdentity records a sign-in with a provider identity and the email it reported
+func (r *UserRepository) TouchIdentity(provider, subject, email string) error {
+	_, err := r.db.Exec(`
+		UPDATE user_identities SET last_login_at = CURRENT_TIMESTAMP, email = $3
+		WHERE provider = $1 AND subject = $2
+	`, provider, subject, nullIfEmpty(email))
+	return err
+}
+
 // GetAllUsers retrieves all users
 func (r *UserRepository) GetAllUsers() ([]*models.User, error) {
-	query := `
-		SELECT id, email, password_hash, first_name, last_name, phone, role, created_at, updated_at
-		FROM users
-		ORDER BY created_at DESC
-	`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -96,18 +157,7 @@ func (r *UserRepository) GetAllUsers() ([]*models.User, error) {
 
 	var users []*models.User
 	for rows.Next() {
-		user := &models.User{}
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.PasswordHash,
-			&user.FirstName,
-			&user.LastName,
-			&user.Phone,
-			&user.Role,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -116,3 +166,265 @@ func (r *UserRepository) GetAllUsers() ([]*models.User, error) {
 
 	return users, rows.Err()
 }
+
+// IsEmailVerified reports whether a user has verified their email address
+func (r *UserRepository) IsEmailVerified(userID int) (bool, error) {
+	var verified bool
+	err := r.db.QueryRow(`SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID).Scan(&verified)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return verified, err
+}
+
+// CreateEmailVerificationToken stores the hash of an email verification token
+func (r *UserRepository) CreateEmailVerificationToken(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(
+		`INSERT INTO email_verification_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, tokenHash, expiresAt,
+	)
+	return err
+}
+
+// LastEmailVerificationSentAt returns when a user's latest verification token
+// was created (nil if never)
+func (r *UserRepository) LastEmailVerificationSentAt(userID int) (*time.Time, error) {
+	var sentAt *time.Time
+	err := r.db.QueryRow(
+		`SELECT MAX(created_at) FROM email_verification_tokens WHERE user_id = $1`, userID,
+	).Scan(&sentAt)
+	return sentAt, err
+}
+
+// VerifyEmail uses up a valid verification token and marks the user's email
+// verified. All the user's other verification tokens stop working too.
+// Returns the user ID, or 0 if the token is unknown, used or expired.
+func (r *UserRepository) VerifyEmail(tokenHash string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE email_verification_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id
+	`, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(
+		`UPDATE email_verification_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// CreatePasswordResetToken stores the hash of a password reset token
+func (r *UserRepository) CreatePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, tokenHash, expiresAt,
+	)
+	return err
+}
+
+// ResetPassword uses up a valid reset token and sets the user's new password.
+// All the user's other reset tokens stop working too. Returns the user ID,
+// or 0 if the token is unknown, used or expired.
+func (r *UserRepository) ResetPassword(tokenHash, passwordHash string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE password_reset_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id
+	`, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// The reset link was emailed, so following it proves the address too
+	_, err = tx.Exec(`
+		UPDATE users
+		SET password_hash = $2, email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID, passwordHash)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(
+		`UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// GetTOTPSecret returns a user's TOTP secret, enabled or pending ("" if none)
+func (r *UserRepository) GetTOTPSecret(userID int) (string, error) {
+	var secret string
+	err := r.db.QueryRow(`SELECT COALESCE(totp_secret, '') FROM users WHERE id = $1`, userID).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return secret, err
+}
+
+// SetPendingTOTPSecret stores a new TOTP secret for a user who hasn't enabled
+// two-factor authentication yet. Returns false if it's already enabled.
+func (r *UserRepository) SetPendingTOTPSecret(userID int, secret string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users
+		SET totp_secret = $2, totp_last_step = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND totp_enabled_at IS NULL
+	`, userID, secret)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code. Returns false if
+// a code from that step or a later one was already used, so codes work once.
+func (r *UserRepository) UseTOTPStep(userID int, step int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users
+		SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// EnableTOTP turns on two-factor authentication with the pending secret and
+// replaces the user's recovery codes
+func (r *UserRepository) EnableTOTP(userID int, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE users SET totp_enabled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP turns off two-factor authentication and deletes the recovery codes
+func (r *UserRepository) DisableTOTP(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(tx, userID, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes swaps a user's recovery codes for new ones
+func (r *UserRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode uses up one of a user's recovery codes. Returns false if the
+// code is unknown or was already used.
+func (r *UserRepository) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CountRecoveryCodes returns how many of a user's recovery codes are unused
+func (r *UserRepository) CountRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID,
+	).Scan(&n)
+	return n, err
+}
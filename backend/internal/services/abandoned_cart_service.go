@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+	"ecommerce-backend/internal/utils"
+)
+
+// abandonedCartMaxAge is how old an idle cart may be and still get a recovery email
+const abandonedCartMaxAge = 7 * 24 * time.Hour
+
+// recoveryAttributionWindow is how long after the email an order counts as recovered
+const recoveryAttributionWindow = 7 * 24 * time.Hour
+
+type AbandonedCartService struct {
+	abandonedCartRepo   *repository.AbandonedCartRepository
+	cartRepo            *repository.CartRepository
+	productRepo         *repository.ProductRepository
+	cartService         *CartService
+	saleService         *SaleService
+	notificationService *NotificationService
+	jwtSecret           string
+	frontendURL         string
+	idleAfter           time.Duration
+}
+
+func NewAbandonedCartService(
+	abandonedCartRepo *repository.AbandonedCartRepository,
+	cartRepo *repository.CartRepository,
+	productRepo *repository.ProductRepository,
+	cartService *CartService,
+	saleService *SaleService,
+	notificationService *NotificationService,
+	jwtSecret, frontendURL string,
+	idleAfter time.Duration,
+) *AbandonedCartService {
+	return &AbandonedCartService{
+		abandonedCartRepo:   abandonedCartRepo,
+		cartRepo:            cartRepo,
+		productRepo:         productRepo,
+		cartService:         cartService,
+		saleService:         saleService,
+		notificationService: notificationService,
+		jwtSecret:           jwtSecret,
+		frontendURL:         frontendURL,
+		idleAfter:           idleAfter,
+	}
+}
+
+// DetectAndNotify records carts idle past the configured window and queues
+// a recovery email for each. Returns how many carts were detected.
+func (s *AbandonedCartService) DetectAndNotify() (int, error) {
+	now := time.Now()
+	carts, err := s.abandonedCartRepo.FindIdleCarts(now.Add(-s.idleAfter), now.Add(-abandonedCartMaxAge))
+	if err != nil {
+		return 0, err
+	}
+
+	detected := 0
+	for i := range carts {
+		ac := &carts[i]
+
+		// Only carts with something purchasable are worth an email
+		cart, err := s.cartService.GetCart(models.CartOwner{UserID: ac.UserID})
+		if err != nil {
+			return detected, err
+		}
+		if cart.TotalItems == 0 {
+			continue
+		}
+		ac.CartTotal = cart.TotalPrice
+
+		created, err := s.abandonedCartRepo.Create(ac)
+		if err != nil {
+			return detected, err
+		}
+		if !created {
+			continue
+		}
+		detected++
+
+		if err := s.notify(ac, cart); err != nil {
+			log.Printf("Failed to queue recovery email for abandoned cart %d: %v", ac.ID, err)
+		}
+	}
+
+	return detected, nil
+}
+
+// notify queues the recovery email with a signed deep link that restores the cart
+func (s *AbandonedCartService) notify(ac *models.AbandonedCart, cart *models.CartResponse) error {
+	token, err := utils.GenerateCartRecoveryToken(ac.ID, ac.UserID, s.jwtSecret)
+	if err != nil {
+		return err
+	}
+	link := s.frontendURL + "/cart/recover?token=" + url.QueryEscape(token)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\n", ac.FirstName)
+	body.WriteString("You left some items in your cart:\n\n")
+	for _, item := range cart.Items {
+		if item.Product == nil || item.Variant == nil {
+			continue
+		}
+		fmt.Fprintf(&body, "  %d x %s (%s)\n", item.Quantity, item.Product.Name, item.Variant.SKU)
+	}
+	fmt.Fprintf(&body, "\nTotal: %s %s\n\nPick up where you left off:\n%s\n", cart.TotalPrice, cart.Currency, link)
+
+	userID := ac.UserID
+	n, err := s.notificationService.QueueEmail(&userID, "abandoned_cart", ac.Email,
+		"You left something in your cart", body.String())
+	if err != nil {
+		return err
+	}
+
+	return s.abandonedCartRepo.MarkNotified(ac.ID, n.ID)
+}
+
+// Run checks for abandoned carts every interval (blocks; start in a goroutine)
+func (s *AbandonedCartService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		detected, err := s.DetectAndNotify()
+		if err != nil {
+			log.Printf("Abandoned cart check failed: %v", err)
+			continue
+		}
+		if detected > 0 {
+			log.Printf("✓ %d abandoned cart(s) detected", detected)
+		}
+	}
+}
+
+// Recover handles a click on a recovery link: lines of the abandoned cart that
+// are no longer in the user's cart are added back at their current price.
+func (s *AbandonedCartService) Recover(token string) (*models.RecoverCartResponse, error) {
+	claims, err := utils.ValidateCartRecoveryToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("invalid or expired recovery link")
+	}
+
+	ac, err := s.abandonedCartRepo.GetByID(claims.AbandonedCartID)
+	if err != nil {
+		return nil, err
+	}
+	if ac == nil || ac.UserID != claims.UserID {
+		return nil, errors.New("invalid or expired recovery link")
+	}
+
+	if err := s.abandonedCartRepo.MarkClicked(ac.ID); err != nil {
+		return nil, err
+	}
+
+	owner := models.CartOwner{UserID: ac.UserID}
+	restored := 0
+	for _, line := range ac.Items {
+		variant, err := s.cartRepo.GetVariantByID(line.ProductVariantID)
+		if err != nil {
+			return nil, err
+		}
+		if variant == nil || variant.StockQuantity <= 0 {
+			continue
+		}
+		product, err := s.productRepo.GetByID(variant.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			continue
+		}
+
+		quantity := line.Quantity
+		if quantity > variant.StockQuantity {
+			quantity = variant.StockQuantity
+		}
+
+		if _, err := s.saleService.PriceVariant(product, variant, quantity); err != nil {
+			return nil, err
+		}
+
+		added, err := s.cartRepo.AddItemIfMissing(owner, variant.ID, quantity, variant.FinalPrice)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			restored++
+		}
+	}
+
+	return &models.RecoverCartResponse{
+		RestoredItems: restored,
+		Email:         ac.Email,
+		Message:       "Your cart has been restored. Log in to check out.",
+	}, nil
+}
+
+// AttributeOrder links a new order to the user's latest recovery email, if it
+// was sent within the attribution window and its link was clicked. Returns the abandoned cart ID or 0.
+func (s *AbandonedCartService) AttributeOrder(userID, orderID int) (int, error) {
+	id, err := s.abandonedCartRepo.FindAttributable(userID, time.Now().Add(-recoveryAttributionWindow))
+	if err != nil || id == 0 {
+		return 0, err
+	}
+	recovered, err := s.abandonedCartRepo.MarkRecovered(id, orderID)
+	if err != nil || !recovered {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetStats returns abandoned cart recovery stats (admin)
+func (s *AbandonedCartService) GetStats() (*models.AbandonedCartStats, error) {
+	return s.abandonedCartRepo.GetStats()
+}
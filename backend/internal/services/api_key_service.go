@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+	"ecommerce-backend/internal/utils"
+)
+
+// apiKeyPrefixLength is how much of a key is stored in the clear so admins can
+// tell keys apart ("ak_" and 8 hex digits)
+const apiKeyPrefixLength = 11
+
+// apiKeyUnscopable are permissions a key may never have, so a leaked key can't
+// mint more keys or hand out roles
+var apiKeyUnscopable = map[string]bool{
+	models.PermAPIKeysWrite: true,
+	models.PermRolesWrite:   true,
+}
+
+// APIKeyService manages admin-issued API keys for integrations. A key acts
+// with its scopes (permission names), never more than its creator has, and
+// every request made with it is recorded.
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository, userRepo *repository.UserRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+// GetKeys returns all API keys (admin)
+func (s *APIKeyService) GetKeys() ([]models.APIKey, error) {
+	return s.apiKeyRepo.GetAll()
+}
+
+// Create issues an API key (admin). The key itself is only returned here.
+func (s *APIKeyService) Create(adminID int, req *models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.New("name is required (at most 100 characters)")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	admin, err := s.userRepo.FindByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil {
+		return nil, errors.New("user not found")
+	}
+	scopes, err := validateScopes(req.Scopes, admin.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.RandomHex(24)
+	if err != nil {
+		return nil, err
+	}
+	plain := "ak_" + secret
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    plain[:apiKeyPrefixLength],
+		Scopes:    scopes,
+		CreatedBy: &adminID,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key, utils.HashToken(plain)); err != nil {
+		return nil, err
+	}
+	return &models.CreatedAPIKey{APIKey: *key, Key: plain}, nil
+}
+
+// Revoke stops a key from working at once (admin)
+func (s *APIKeyService) Revoke(adminID, id int) error {
+	revoked, err := s.apiKeyRepo.Revoke(id, &adminID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return nil
+	}
+
+	key, err := s.apiKeyRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return errors.New("API key not found")
+	}
+	return errors.New("API key is already revoked")
+}
+
+// GetRequests returns the latest requests made with a key (admin)
+func (s *APIKeyService) GetRequests(id, limit int) ([]models.APIKeyRequestLog, error) {
+	key, err := s.apiKeyRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New("API key not found")
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.apiKeyRepo.GetRequests(id, limit)
+}
+
+// AuthenticateAPIKey checks a key sent by a client and records it as used.
+// Returns a zero ID for unknown, revoked or expired keys. Only the scopes the
+// creator's role still grants are returned, so a key loses what its creator
+// loses; a key whose creator was deleted has none.
+func (s *APIKeyService) AuthenticateAPIKey(plain, ip string) (int, []string, error) {
+	if !strings.HasPrefix(plain, "ak_") {
+		return 0, nil, nil
+	}
+	key, err := s.apiKeyRepo.Use(utils.HashToken(plain), ip)
+	if err != nil || key == nil {
+		return 0, nil, err
+	}
+	if key.CreatedBy == nil {
+		return key.ID, []string{}, nil
+	}
+
+	creator, err := s.userRepo.FindByID(*key.CreatedBy)
+	if err != nil {
+		return 0, nil, err
+	}
+	if creator == nil {
+		return key.ID, []string{}, nil
+	}
+	return key.ID, grantedScopes(key.Scopes, creator.Permissions), nil
+}
+
+// LogAPIKeyRequest adds a request made with a key to its audit trail
+func (s *APIKeyService) LogAPIKeyRequest(keyID int, method, path string, status int, ip string) {
+	entry := &models.APIKeyRequestLog{
+		APIKeyID:  keyID,
+		Method:    method,
+		Path:      truncate(path, 255),
+		Status:    status,
+		IPAddress: ip,
+	}
+	if err := s.apiKeyRepo.LogRequest(entry); err != nil {
+		log.Printf("Failed to log request %s %s of API key %d: %v", method, path, keyID, err)
+	}
+}
+
+// grantedScopes returns the scopes that are still among permissions
+func grantedScopes(scopes, permissions []string) []string {
+	has := map[string]bool{}
+	for _, p := range permissions {
+		has[p] = true
+	}
+	granted := []string{}
+	for _, scope := range scopes {
+		if has[scope] {
+			granted = append(granted, scope)
+		}
+	}
+	return granted
+}
+
+// validateScopes checks a key's scopes are permissions its creator has and a
+// key may hold, and removes duplicates
+func validateScopes(scopes, creatorPermissions []string) ([]string, error) {
+	allowed := map[string]bool{}
+	for _, p := range creatorPermissions {
+		allowed[p] = true
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if apiKeyUnscopable[scope] {
+			return nil, fmt.Errorf("API keys can't have %q", scope)
+		}
+		if !allowed[scope] {
+			return nil, fmt.Errorf("you can't grant %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	return result, nil
+}
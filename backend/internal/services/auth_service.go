@@ -1,27 +1,63 @@
 package services
 
 import (
+	"crypto/subtle"
 	"errors"
 	"ecommerce-backend/internal/models"
 	"ecommerce-backend/internal/repository"
 	"ecommerce-backend/internal/utils"
+	"fmt"
+	"log"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret string
+	userRepo            *repository.UserRepository
+	sessionRepo         *repository.SessionRepository
+	notificationService *NotificationService
+	loginThrottle       *LoginThrottleService
+	twoFactorService    *TwoFactorService
+	oidcProviders       []*OIDCProvider
+	jwtSecret           string
+	frontendURL         string
+
+	accessTokenTTL       time.Duration
+	refreshTokenTTL      time.Duration
+	preAuthTokenTTL      time.Duration
+	passwordResetTTL     time.Duration
+	emailVerificationTTL time.Duration
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) *AuthService {
+// verificationResendWait is how long a user waits between verification emails
+const verificationResendWait = time.Minute
+
+// oidcLoginTTL is how long a user has to sign in at a social sign-in provider
+const oidcLoginTTL = 10 * time.Minute
+
+func NewAuthService(userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository, notificationService *NotificationService,
+	loginThrottle *LoginThrottleService, twoFactorService *TwoFactorService, oidcProviders []*OIDCProvider, jwtSecret, frontendURL string,
+	accessTokenTTL, refreshTokenTTL, preAuthTokenTTL, passwordResetTTL, emailVerificationTTL time.Duration) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:             userRepo,
+		sessionRepo:          sessionRepo,
+		notificationService:  notificationService,
+		loginThrottle:        loginThrottle,
+		twoFactorService:     twoFactorService,
+		oidcProviders:        oidcProviders,
+		jwtSecret:            jwtSecret,
+		frontendURL:          frontendURL,
+		accessTokenTTL:       accessTokenTTL,
+		refreshTokenTTL:      refreshTokenTTL,
+		preAuthTokenTTL:      preAuthTokenTTL,
+		passwordResetTTL:     passwordResetTTL,
+		emailVerificationTTL: emailVerificationTTL,
 	}
 }
 
-// Register creates a new user account
-func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthResponse, error) {
+// Register creates a new user account and logs it in
+func (s *AuthService) Register(req *models.RegisterRequest, client models.ClientInfo) (*models.AuthResponse, error) {
 	// Validate input
 	if err := s.validateRegisterRequest(req); err != nil {
 		return nil, err
@@ -49,58 +85,551 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		FirstName:    strings.TrimSpace(req.FirstName),
 		LastName:     strings.TrimSpace(req.LastName),
 		Phone:        strings.TrimSpace(req.Phone),
-		Role:         "customer",
+		Role:         models.RoleCustomer,
+		Permissions:  []string{},
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, err
 	}
 
-	// Generate JWT
-	token, err := utils.GenerateJWT(user.ID, user.Email, user.Role, s.jwtSecret)
-	if err != nil {
-		return nil, err
+	// The account works right away; verifying unlocks checkout where required
+	if err := s.sendVerification(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
 	}
 
-	return &models.AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return s.startSession(user, client, false)
 }
 
-// Login authenticates a user
-func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
+// Login authenticates a user and starts a session. Repeated failures for an
+// email or IP return a *LoginThrottledError until the backoff or lockout ends.
+// With two-factor authentication only a pre-auth token is returned, for
+// VerifyTwoFactor to complete the login.
+func (s *AuthService) Login(req *models.LoginRequest, client models.ClientInfo) (*models.AuthResponse, error) {
 	// Validate input
 	if req.Email == "" || req.Password == "" {
 		return nil, errors.New("email and password are required")
 	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// Checked before the (deliberately slow) password hash comparison
+	if err := s.loginThrottle.Check(email, client.IPAddress); err != nil {
+		if _, ok := err.(*LoginThrottledError); ok {
+			s.loginThrottle.Fail(email, nil, client, models.LoginFailureThrottled)
+		}
+		return nil, err
+	}
 
 	// Find user
-	user, err := s.userRepo.FindByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		s.loginThrottle.Fail(email, nil, client, models.LoginFailureUnknownEmail)
 		return nil, errors.New("invalid email or password")
 	}
 
-	// Check password
-	if !utils.CheckPassword(req.Password, user.PasswordHash) {
+	// Check password; accounts from social sign-in have none until they reset it
+	if !user.HasPassword || !utils.CheckPassword(req.Password, user.PasswordHash) {
+		s.loginThrottle.Fail(email, &user.ID, client, models.LoginFailureWrongPassword)
 		return nil, errors.New("invalid email or password")
 	}
 
-	// Generate JWT
-	token, err := utils.GenerateJWT(user.ID, user.Email, user.Role, s.jwtSecret)
+	// Failures aren't forgotten until the second factor passes too
+	if user.TwoFactorEnabled {
+		return s.twoFactorResponse(user)
+	}
+
+	s.loginThrottle.Succeed(email)
+	return s.startSession(user, client, false)
+}
+
+// twoFactorResponse asks a user who passed the first login step for a code
+func (s *AuthService) twoFactorResponse(user *models.User) (*models.AuthResponse, error) {
+	preAuthToken, err := utils.GeneratePreAuthToken(user.ID, s.jwtSecret, s.preAuthTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthResponse{
+		TwoFactorRequired: true,
+		PreAuthToken:      preAuthToken,
+		ExpiresIn:         int(s.preAuthTokenTTL.Seconds()),
+	}, nil
+}
+
+// VerifyTwoFactor completes a two-factor login with the pre-auth token from
+// Login and a code from the user's authenticator app (or a recovery code)
+func (s *AuthService) VerifyTwoFactor(req *models.TwoFactorLoginRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	userID, err := utils.ValidatePreAuthToken(req.PreAuthToken, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("login expired, please log in again")
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.TwoFactorEnabled {
+		return nil, errors.New("login expired, please log in again")
+	}
+
+	if err := s.twoFactorService.confirm(user, req.Code, client); err != nil {
+		return nil, err
+	}
+
+	s.loginThrottle.Succeed(user.Email)
+	return s.startSession(user, client, true)
+}
+
+// OIDCProviders returns the names of the social sign-in providers
+func (s *AuthService) OIDCProviders() []string {
+	names := []string{}
+	for _, p := range s.oidcProviders {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// StartOIDCLogin begins a social sign-in: the client sends the browser to the
+// returned URL and keeps the login token for OIDCLogin
+func (s *AuthService) StartOIDCLogin(providerName string) (*models.OIDCAuthorization, error) {
+	provider := s.oidcProvider(providerName)
+	if provider == nil {
+		return nil, errors.New("unknown sign-in provider")
+	}
+
+	var secrets [3]string // state, nonce, PKCE code verifier
+	for i := range secrets {
+		secret, err := utils.RandomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = secret
+	}
+	state, nonce, codeVerifier := secrets[0], secrets[1], secrets[2]
+
+	authorizationURL, err := provider.AuthorizationURL(state, nonce, codeVerifier)
+	if err != nil {
+		log.Printf("Failed to start %s sign-in: %v", provider.Name(), err)
+		return nil, fmt.Errorf("%s sign-in is unavailable, please try again later", provider.Name())
+	}
+	loginToken, err := utils.GenerateOIDCLoginToken(provider.Name(), state, nonce, codeVerifier, s.jwtSecret, oidcLoginTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OIDCAuthorization{
+		AuthorizationURL: authorizationURL,
+		LoginToken:       loginToken,
+		ExpiresIn:        int(oidcLoginTTL.Seconds()),
+	}, nil
+}
+
+// OIDCLogin completes a social sign-in with the code and state the provider
+// returned and the login token from StartOIDCLogin. The user is the one linked
+// to the provider identity, else the user with its (verified) email, else a
+// new user without a password. Two-factor accounts still need a code.
+func (s *AuthService) OIDCLogin(providerName string, req *models.OIDCCallbackRequest, client models.ClientInfo) (*models.AuthResponse, error) {
+	provider := s.oidcProvider(providerName)
+	if provider == nil {
+		return nil, errors.New("unknown sign-in provider")
+	}
+	if req.Code == "" || req.State == "" || req.LoginToken == "" {
+		return nil, errors.New("code, state and login token are required")
+	}
+
+	login, err := utils.ValidateOIDCLoginToken(req.LoginToken, s.jwtSecret)
+	if err != nil || login.Provider != provider.Name() ||
+		subtle.ConstantTimeCompare([]byte(login.State), []byte(req.State)) != 1 {
+		return nil, errors.New("sign-in expired, please try again")
+	}
+
+	claims, err := provider.Exchange(req.Code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		log.Printf("%s sign-in failed: %v", provider.Name(), err)
+		return nil, fmt.Errorf("%s sign-in failed, please try again", provider.Name())
+	}
+
+	user, err := oidcUser(s.userRepo, provider.Name(), claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return s.twoFactorResponse(user)
+	}
+	return s.startSession(user, client, false)
+}
+
+// identityUsers is the part of the user repository that provider sign-in uses
+type identityUsers interface {
+	FindByIdentity(provider, subject string) (*models.User, error)
+	TouchIdentity(provider, subject, email string) error
+	FindByEmail(email string) (*models.User, error)
+	LinkIdentity(identity *models.UserIdentity) error
+	CreateWithIdentity(user *models.User, identity *models.UserIdentity) error
+}
+
+// oidcUser finds or creates the user for a provider identity
+func oidcUser(users identityUsers, provider string, claims *oidcClaims) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+
+	user, err := users.FindByIdentity(provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if err := users.TouchIdentity(provider, claims.Subject, email); err != nil {
+			log.Printf("Failed to record %s sign-in of user %d: %v", provider, user.ID, err)
+		}
+		return user, nil
+	}
+
+	// Linking and sign-up both rely on the provider vouching for the email
+	if email == "" || !bool(claims.EmailVerified) {
+		return nil, fmt.Errorf("your %s account has no verified email address", provider)
+	}
+	identity := &models.UserIdentity{Provider: provider, Subject: claims.Subject, Email: email}
+
+	user, err = users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		// Whoever registered an unverified account may not own the address;
+		// linking it would hand them the provider's account
+		if user.EmailVerifiedAt == nil {
+			return nil, errors.New("an account with this email exists but isn't verified; log in with your password or reset it first")
+		}
+		identity.UserID = user.ID
+		if err := users.LinkIdentity(identity); err != nil {
+			return nil, err
+		}
+		log.Printf("Linked %s identity to user %d by verified email", provider, user.ID)
+		return user, nil
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	now := time.Now()
+	user = &models.User{
+		Email:           email,
+		FirstName:       truncate(strings.TrimSpace(firstName), 100),
+		LastName:        truncate(strings.TrimSpace(lastName), 100),
+		Role:            models.RoleCustomer,
+		Permissions:     []string{},
+		EmailVerifiedAt: &now,
+	}
+	if err := users.CreateWithIdentity(user, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// OIDCFrontendCallbackURL is the frontend page that completes a sign-in with a
+// provider; form_post responses are forwarded there. Empty for unknown providers.
+func (s *AuthService) OIDCFrontendCallbackURL(providerName string) string {
+	if s.oidcProvider(providerName) == nil {
+		return ""
+	}
+	return s.frontendURL + "/oidc/callback/" + providerName
+}
+
+// oidcProvider returns a configured provider by name, or nil
+func (s *AuthService) oidcProvider(name string) *OIDCProvider {
+	for _, p := range s.oidcProviders {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token.
+// Each refresh token works once; replaying an old one ends the session,
+// since it means the token was stolen.
+func (s *AuthService) Refresh(refreshToken string) (*models.AuthResponse, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh token is required")
+	}
+	hash := utils.HashToken(refreshToken)
+
+	session, err := s.sessionRepo.GetByTokenHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		reused, err := s.sessionRepo.GetByPreviousTokenHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		if reused != nil {
+			log.Printf("Refresh token reused for session %d of user %d, revoking it", reused.ID, reused.UserID)
+			if err := s.sessionRepo.Revoke(reused.ID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, errors.New("session expired, please log in again")
+	}
+
+	// Re-read the user so a changed role or email takes effect
+	user, err := s.userRepo.FindByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	newToken, err := utils.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.sessionRepo.Rotate(session.ID, hash, utils.HashToken(newToken), time.Now().Add(s.refreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	return s.authResponse(user, session.ID, newToken)
+}
+
+// Logout ends the session of a refresh token, or all of its user's sessions
+func (s *AuthService) Logout(req *models.LogoutRequest) error {
+	if req.RefreshToken == "" {
+		return errors.New("refresh token is required")
+	}
+	session, err := s.sessionRepo.GetByTokenHash(utils.HashToken(req.RefreshToken))
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return errors.New("invalid refresh token")
+	}
+
+	if req.AllDevices {
+		_, err = s.sessionRepo.RevokeAllForUser(session.UserID)
+		return err
+	}
+	return s.sessionRepo.Revoke(session.ID)
+}
+
+// ForgotPassword emails a password reset link if the account exists. It never
+// reports whether it does: the link is sent in the background so the response
+// and its timing are the same either way.
+func (s *AuthService) ForgotPassword(req *models.ForgotPasswordRequest) error {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if !strings.Contains(email, "@") {
+		return errors.New("invalid email format")
+	}
+
+	go func() {
+		if err := s.sendPasswordReset(email); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset creates a single-use reset token and emails its link
+func (s *AuthService) sendPasswordReset(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	token, err := utils.RandomHex(32)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.CreatePasswordResetToken(user.ID, utils.HashToken(token), time.Now().Add(s.passwordResetTTL)); err != nil {
+		return err
+	}
+
+	link := s.frontendURL + "/reset-password?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. "+
+		"If it was you, choose a new password here (the link works once and expires in %s):\n%s\n\n"+
+		"If it wasn't you, ignore this email; your password stays the same.\n",
+		user.FirstName, s.passwordResetTTL, link)
+
+	// Sent directly so the link isn't stored in the notification queue
+	return s.notificationService.SendEmail("password_reset", user.Email, "Reset your password", body)
+}
+
+// ResetPassword sets a new password with a reset token and logs the user out everywhere
+func (s *AuthService) ResetPassword(req *models.ResetPasswordRequest) error {
+	if req.Token == "" {
+		return errors.New("reset token is required")
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+	userID, err := s.userRepo.ResetPassword(utils.HashToken(req.Token), hashedPassword)
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return errors.New("invalid or expired reset link")
+	}
+
+	// Anyone logged in with the old password is logged out
+	if _, err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return err
+	}
+	_, err = s.notificationService.QueueEmail(&userID, "password_changed", user.Email, "Your password was changed",
+		fmt.Sprintf("Hi %s,\n\nThe password of your account was just reset and you were logged out on all devices.\n"+
+			"If this wasn't you, contact us right away.\n", user.FirstName))
+	if err != nil {
+		log.Printf("Failed to queue password changed email for user %d: %v", userID, err)
+	}
+	return nil
+}
+
+// VerifyEmail marks an email address verified with the token from the verification link
+func (s *AuthService) VerifyEmail(req *models.VerifyEmailRequest) (*models.User, error) {
+	if req.Token == "" {
+		return nil, errors.New("verification token is required")
+	}
+
+	userID, err := s.userRepo.VerifyEmail(utils.HashToken(req.Token))
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 {
+		return nil, errors.New("invalid or expired verification link")
+	}
+	return s.userRepo.FindByID(userID)
+}
+
+// ResendVerification emails a new verification link to a user who hasn't verified yet
+func (s *AuthService) ResendVerification(userID int) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if user.EmailVerifiedAt != nil {
+		return errors.New("email is already verified")
+	}
+
+	sentAt, err := s.userRepo.LastEmailVerificationSentAt(userID)
+	if err != nil {
+		return err
+	}
+	if sentAt != nil && time.Since(*sentAt) < verificationResendWait {
+		return errors.New("a verification email was just sent, please wait a minute before asking again")
+	}
+
+	return s.sendVerification(user)
+}
+
+// IsEmailVerified reports whether a user has verified their email address
+func (s *AuthService) IsEmailVerified(userID int) (bool, error) {
+	return s.userRepo.IsEmailVerified(userID)
+}
+
+// sendVerification creates a single-use verification token and emails its link
+func (s *AuthService) sendVerification(user *models.User) error {
+	token, err := utils.RandomHex(32)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.CreateEmailVerificationToken(user.ID, utils.HashToken(token), time.Now().Add(s.emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	link := s.frontendURL + "/verify-email?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by following this link "+
+		"(it expires in %s):\n%s\n\nIf you didn't create an account, ignore this email.\n",
+		user.FirstName, s.emailVerificationTTL, link)
+
+	// Sent directly so the link isn't stored in the notification queue
+	return s.notificationService.SendEmail("email_verification", user.Email, "Confirm your email address", body)
+}
+
+// RevokeSessions logs a user out everywhere (admin); their access tokens stop working at once
+func (s *AuthService) RevokeSessions(userID int) (int64, error) {
+	return s.sessionRepo.RevokeAllForUser(userID)
+}
+
+// GetLoginFailures returns the audit trail of failed logins (admin)
+func (s *AuthService) GetLoginFailures(email, ip string, limit int) ([]models.LoginFailure, error) {
+	return s.loginThrottle.GetFailures(strings.ToLower(strings.TrimSpace(email)), ip, limit)
+}
+
+// IsSessionActive reports whether the session an access token was issued for
+// is still active, and whether its login passed a second factor
+func (s *AuthService) IsSessionActive(sessionID, userID int) (bool, bool, error) {
+	if sessionID == 0 {
+		return false, false, nil
+	}
+	return s.sessionRepo.IsActive(sessionID, userID)
+}
+
+// startSession creates a session for a user who just logged in, with or
+// without passing a second factor
+func (s *AuthService) startSession(user *models.User, client models.ClientInfo, twoFactor bool) (*models.AuthResponse, error) {
+	refreshToken, err := utils.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:    user.ID,
+		UserAgent: truncate(client.UserAgent, 255),
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+		TwoFactor: twoFactor,
+	}
+	if err := s.sessionRepo.Create(session, utils.HashToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	return s.authResponse(user, session.ID, refreshToken)
+}
+
+// authResponse issues an access token for the session
+func (s *AuthService) authResponse(user *models.User, sessionID int, refreshToken string) (*models.AuthResponse, error) {
+	token, err := utils.GenerateJWT(user.ID, sessionID, user.Email, user.Role, s.jwtSecret, s.accessTokenTTL)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		User:         user,
 	}, nil
 }
 
+// truncate cuts s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
+
 // GetUserByID retrieves a user by ID
 func (s *AuthService) GetUserByID(id int) (*models.User, error) {
 	return s.userRepo.FindByID(id)
@@ -111,11 +640,8 @@ func (s *AuthService) validateRegisterRequest(req *models.RegisterRequest) error
 	if req.Email == "" {
 		return errors.New("email is required")
 	}
-	if req.Password == "" {
-		return errors.New("password is required")
-	}
-	if len(req.Password) < 6 {
-		return errors.New("password must be at least 6 characters")
+	if err := validatePassword(req.Password); err != nil {
+		return err
 	}
 	if req.FirstName == "" {
 		return errors.New("first name is required")
@@ -129,3 +655,14 @@ func (s *AuthService) validateRegisterRequest(req *models.RegisterRequest) error
 	}
 	return nil
 }
+
+// validatePassword checks a new password
+func validatePassword(password string) error {
+	if password == "" {
+		return errors.New("password is required")
+	}
+	if len(password) < 6 {
+		return errors.New("password must be at least 6 characters")
+	}
+	return nil
+}
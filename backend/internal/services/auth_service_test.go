@@ -0,0 +1,206 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"ecommerce-backend/internal/models"
+)
+
+// fakeIdentityUsers keeps users and their provider identities in memory
+type fakeIdentityUsers struct {
+	users      []*models.User
+	identities []*models.UserIdentity
+	touched    []string
+	nextID     int
+}
+
+func (f *fakeIdentityUsers) FindByIdentity(provider, subject string) (*models.User, error) {
+	for _, identity := range f.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return f.byID(identity.UserID), nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeIdentityUsers) TouchIdentity(provider, subject, email string) error {
+	f.touched = append(f.touched, provider+"/"+subject)
+	return nil
+}
+
+func (f *fakeIdentityUsers) FindByEmail(email string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeIdentityUsers) LinkIdentity(identity *models.UserIdentity) error {
+	f.identities = append(f.identities, identity)
+	return nil
+}
+
+func (f *fakeIdentityUsers) CreateWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	f.nextID++
+	user.ID = 1000 + f.nextID
+	f.users = append(f.users, user)
+	identity.UserID = user.ID
+	f.identities = append(f.identities, identity)
+	return nil
+}
+
+func (f *fakeIdentityUsers) byID(id int) *models.User {
+	for _, user := range f.users {
+		if user.ID == id {
+			return user
+		}
+	}
+	return nil
+}
+
+func providerClaims(subject, email string, verified bool) *oidcClaims {
+	return &oidcClaims{
+		Email:            email,
+		EmailVerified:    oidcBool(verified),
+		GivenName:        "Jane",
+		FamilyName:       "Doe",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+	}
+}
+
+func TestOIDCUserKnownIdentity(t *testing.T) {
+	verifiedAt := time.Now()
+	store := &fakeIdentityUsers{
+		users:      []*models.User{{ID: 1, Email: "jane@example.com", EmailVerifiedAt: &verifiedAt}},
+		identities: []*models.UserIdentity{{UserID: 1, Provider: "google", Subject: "sub-1"}},
+	}
+
+	// A known identity signs in even if the provider no longer vouches for the email
+	user, err := oidcUser(store, "google", providerClaims("sub-1", "new@example.com", false))
+	if err != nil {
+		t.Fatalf("oidcUser: %v", err)
+	}
+	if user.ID != 1 {
+		t.Errorf("user = %d, want 1", user.ID)
+	}
+	if len(store.touched) != 1 || store.touched[0] != "google/sub-1" {
+		t.Errorf("touched = %v, want [google/sub-1]", store.touched)
+	}
+	if len(store.users) != 1 || len(store.identities) != 1 {
+		t.Errorf("got %d users and %d identities, want nothing new", len(store.users), len(store.identities))
+	}
+}
+
+func TestOIDCUserLinksByVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	store := &fakeIdentityUsers{
+		users: []*models.User{{ID: 7, Email: "jane@example.com", EmailVerifiedAt: &verifiedAt, HasPassword: true}},
+	}
+
+	user, err := oidcUser(store, "google", providerClaims("sub-1", " Jane@Example.com ", true))
+	if err != nil {
+		t.Fatalf("oidcUser: %v", err)
+	}
+	if user.ID != 7 {
+		t.Errorf("user = %d, want the existing user 7", user.ID)
+	}
+	if len(store.identities) != 1 {
+		t.Fatalf("got %d identities, want 1", len(store.identities))
+	}
+	identity := store.identities[0]
+	if identity.UserID != 7 || identity.Provider != "google" || identity.Subject != "sub-1" || identity.Email != "jane@example.com" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if len(store.users) != 1 {
+		t.Errorf("got %d users, want no new user", len(store.users))
+	}
+}
+
+func TestOIDCUserDoesNotLinkUnverifiedAccount(t *testing.T) {
+	store := &fakeIdentityUsers{
+		users: []*models.User{{ID: 7, Email: "jane@example.com", HasPassword: true}},
+	}
+
+	if _, err := oidcUser(store, "google", providerClaims("sub-1", "jane@example.com", true)); err == nil {
+		t.Error("oidcUser linked an account whose email isn't verified")
+	}
+	if len(store.identities) != 0 || len(store.users) != 1 {
+		t.Errorf("got %d identities and %d users, want nothing new", len(store.identities), len(store.users))
+	}
+}
+
+func TestOIDCUserRequiresVerifiedProviderEmail(t *testing.T) {
+	verifiedAt := time.Now()
+	tests := []struct {
+		name   string
+		claims *oidcClaims
+	}{
+		{"unverified email", providerClaims("sub-1", "jane@example.com", false)},
+		{"no email", providerClaims("sub-1", "", true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeIdentityUsers{
+				users: []*models.User{{ID: 7, Email: "jane@example.com", EmailVerifiedAt: &verifiedAt}},
+			}
+			if _, err := oidcUser(store, "google", tt.claims); err == nil {
+				t.Error("oidcUser succeeded without a verified provider email")
+			}
+			if len(store.identities) != 0 || len(store.users) != 1 {
+				t.Errorf("got %d identities and %d users, want nothing new", len(store.identities), len(store.users))
+			}
+		})
+	}
+}
+
+func TestOIDCUserCreatesPasswordlessUser(t *testing.T) {
+	store := &fakeIdentityUsers{}
+
+	user, err := oidcUser(store, "apple", providerClaims("sub-9", "New@Example.com", true))
+	if err != nil {
+		t.Fatalf("oidcUser: %v", err)
+	}
+
+	if user.ID == 0 || len(store.users) != 1 {
+		t.Fatalf("user = %+v, want a new stored user", user)
+	}
+	if user.Email != "new@example.com" {
+		t.Errorf("email = %q, want new@example.com", user.Email)
+	}
+	if user.PasswordHash != "" || user.HasPassword {
+		t.Error("new user has a password")
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("new user's email isn't verified")
+	}
+	if user.Role != models.RoleCustomer {
+		t.Errorf("role = %q, want %q", user.Role, models.RoleCustomer)
+	}
+	if user.FirstName != "Jane" || user.LastName != "Doe" {
+		t.Errorf("name = %q %q, want Jane Doe", user.FirstName, user.LastName)
+	}
+	if len(store.identities) != 1 || store.identities[0].UserID != user.ID || store.identities[0].Provider != "apple" {
+		t.Errorf("identities = %+v, want one apple identity for the new user", store.identities)
+	}
+}
+
+func TestOIDCUserNameFallback(t *testing.T) {
+	store := &fakeIdentityUsers{}
+	claims := providerClaims("sub-9", "new@example.com", true)
+	claims.GivenName, claims.FamilyName = "", ""
+	claims.Name = "Jane van Doe"
+
+	user, err := oidcUser(store, "apple", claims)
+	if err != nil {
+		t.Fatalf("oidcUser: %v", err)
+	}
+	if user.FirstName != "Jane" || user.LastName != "van Doe" {
+		t.Errorf("name = %q %q, want Jane / van Doe", user.FirstName, user.LastName)
+	}
+}
@@ -2,64 +2,338 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
 	"ecommerce-backend/internal/repository"
+	"ecommerce-backend/internal/utils"
 )
 
 type CartService struct {
-	cartRepo    *repository.CartRepository
-	productRepo *repository.ProductRepository
+	cartRepo      *repository.CartRepository
+	productRepo   *repository.ProductRepository
+	couponService    *CouponService
+	promotionService *PromotionService
+	saleService      *SaleService
+	shippingService  *ShippingService
+	jwtSecret        string
 }
 
-func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository) *CartService {
+func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, couponService *CouponService, promotionService *PromotionService, saleService *SaleService, shippingService *ShippingService, jwtSecret string) *CartService {
 	return &CartService{
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
+		cartRepo:         cartRepo,
+		productRepo:      productRepo,
+		couponService:    couponService,
+		promotionService: promotionService,
+		saleService:      saleService,
+		shippingService:  shippingService,
+		jwtSecret:        jwtSecret,
 	}
 }
 
-// GetCart returns user's cart with full details
-func (s *CartService) GetCart(userID int) (*models.CartResponse, error) {
-	items, err := s.cartRepo.GetUserCart(userID)
+// NewGuestCart creates a new anonymous cart owner and its signed token
+func (s *CartService) NewGuestCart() (models.CartOwner, string, error) {
+	guestID, err := utils.NewGuestID()
+	if err != nil {
+		return models.CartOwner{}, "", err
+	}
+	token, err := utils.GenerateCartToken(guestID, s.jwtSecret)
+	if err != nil {
+		return models.CartOwner{}, "", err
+	}
+	return models.CartOwner{GuestID: guestID}, token, nil
+}
+
+// ResolveCartToken validates a guest cart token and returns its owner
+func (s *CartService) ResolveCartToken(token string) (models.CartOwner, error) {
+	guestID, err := utils.ValidateCartToken(token, s.jwtSecret)
+	if err != nil {
+		return models.CartOwner{}, errors.New("invalid cart token")
+	}
+	return models.CartOwner{GuestID: guestID}, nil
+}
+
+// MergeGuestCart moves a guest cart into the user's cart after login or registration
+func (s *CartService) MergeGuestCart(token string, userID int) error {
+	owner, err := s.ResolveCartToken(token)
+	if err != nil {
+		return err
+	}
+	if err := s.cartRepo.MergeGuestCart(owner.GuestID, userID); err != nil {
+		return err
+	}
+	return s.couponService.MergeGuestCartCoupon(owner.GuestID, userID)
+}
+
+// GetCart returns a user's or guest's cart with full details
+func (s *CartService) GetCart(owner models.CartOwner) (*models.CartResponse, error) {
+	items, err := s.cartRepo.GetCart(owner)
 	if err != nil {
 		return nil, err
 	}
 
-	// Populate each item with product and variant details
-	totalPrice := 0.0
+	// Populate each item with product and variant details and revalidate it.
+	// Lines that can't be bought at all don't count toward the totals.
+	var totalPrice money.Money
 	totalItems := 0
+	hasIssues := false
+	priceChanges := 0
+	lines := []discountLine{}
+	lineItems := []int{} // Index into items for each line
+
+	for i := range items {
+		purchasable := s.revalidateItem(&items[i])
+		if len(items[i].Issues) > 0 {
+			hasIssues = true
+		}
+		if hasPriceChange(&items[i]) {
+			priceChanges++
+		}
+		if !purchasable {
+			continue
+		}
+
+		totalPrice = totalPrice.Add(items[i].Variant.FinalPrice.Mul(items[i].Quantity))
+		totalItems += items[i].Quantity
+		lines = append(lines, newDiscountLine(items[i].Product, items[i].Variant, items[i].Quantity, items[i].Variant.FinalPrice))
+		lineItems = append(lineItems, i)
+	}
+
+	// Saved lines keep their variant selection but don't count toward totals
+	savedItems, err := s.cartRepo.GetSavedItems(owner)
+	if err != nil {
+		return nil, err
+	}
+	for i := range savedItems {
+		s.revalidateItem(&savedItems[i])
+	}
+
+	cart := &models.CartResponse{
+		SavedItems:   savedItems,
+		Items:        items,
+		TotalItems:   totalItems,
+		TotalPrice:   totalPrice,
+		HasIssues:    hasIssues,
+		PriceChanges: priceChanges,
+	}
+
+	// Automatic promotions first, then the coupon on the discounted prices
+	cart.Promotions, err = s.promotionService.Apply(lines)
+	if err != nil {
+		return nil, err
+	}
+	for _, promotion := range cart.Promotions {
+		cart.Discount = cart.Discount.Add(promotion.Discount)
+	}
+
+	// A coupon that no longer applies stays on the cart with the reason
+	coupon, err := s.couponService.GetCartCoupon(owner)
+	if err != nil {
+		return nil, err
+	}
+	if coupon != nil {
+		cart.Coupon = &models.AppliedCoupon{Code: coupon.Code, Description: coupon.Description}
+		discount, err := s.couponService.Evaluate(coupon, lines, owner.UserID, "")
+		if err != nil {
+			cart.Coupon.Error = err.Error()
+		} else {
+			cart.Coupon.Discount = discount
+			cart.Discount = cart.Discount.Add(discount)
+			for j, amount := range allocateCoupon(coupon, lines, discount) {
+				lines[j].Discount = lines[j].Discount.Add(amount)
+			}
+		}
+	}
+
+	for j, line := range lines {
+		items[lineItems[j]].Discount = line.Discount
+	}
+	cart.Total = totalPrice.Sub(cart.Discount)
+	cart.Currency = cart.Total.CurrencyCode()
+
+	return cart, nil
+}
+
+// ShippingQuotes returns the shipping methods available for the cart to a destination,
+// priced on the cart total after discounts
+func (s *CartService) ShippingQuotes(owner models.CartOwner, country, city string) ([]models.ShippingQuote, error) {
+	cart, err := s.GetCart(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []shippingItem{}
+	for _, item := range cart.Items {
+		if isPurchasable(&item) {
+			items = append(items, shippingItem{VariantID: item.ProductVariantID, Quantity: item.Quantity})
+		}
+	}
+	if len(items) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	return s.shippingService.Quote(country, city, items, cart.Total)
+}
+
+// isPurchasable reports whether a revalidated cart line counts toward the totals
+func isPurchasable(item *models.CartItem) bool {
+	for _, issue := range item.Issues {
+		switch issue.Type {
+		case models.CartIssueUnavailable, models.CartIssueInactive, models.CartIssueOutOfStock:
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyCoupon applies a coupon code to the cart if it currently gives a discount
+func (s *CartService) ApplyCoupon(owner models.CartOwner, code string) error {
+	coupon, err := s.couponService.FindCoupon(code)
+	if err != nil {
+		return err
+	}
+
+	items, err := s.cartRepo.GetCart(owner)
+	if err != nil {
+		return err
+	}
+	lines := []discountLine{}
+	for i := range items {
+		if s.revalidateItem(&items[i]) {
+			lines = append(lines, newDiscountLine(items[i].Product, items[i].Variant, items[i].Quantity, items[i].Variant.FinalPrice))
+		}
+	}
+
+	if _, err := s.promotionService.Apply(lines); err != nil {
+		return err
+	}
+	if _, err := s.couponService.Evaluate(coupon, lines, owner.UserID, ""); err != nil {
+		return err
+	}
+
+	return s.couponService.SetCartCoupon(owner, coupon.ID)
+}
+
+// RemoveCoupon removes the coupon from the cart
+func (s *CartService) RemoveCoupon(owner models.CartOwner) error {
+	return s.couponService.RemoveCartCoupon(owner)
+}
+
+// hasPriceChange reports whether a revalidated line has an unaccepted price change
+func hasPriceChange(item *models.CartItem) bool {
+	for _, issue := range item.Issues {
+		if issue.Type == models.CartIssuePriceChanged {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptNewPrices accepts the current price of changed cart lines.
+// cartItemID 0 accepts all lines of the cart.
+func (s *CartService) AcceptNewPrices(owner models.CartOwner, cartItemID int) error {
+	items, err := s.cartRepo.GetCart(owner)
+	if err != nil {
+		return err
+	}
 
+	found := cartItemID == 0
 	for i := range items {
-		// Get variant
-		variant, err := s.cartRepo.GetVariantByID(items[i].ProductVariantID)
-		if err != nil || variant == nil {
+		if cartItemID != 0 && items[i].ID != cartItemID {
 			continue
 		}
-		items[i].Variant = variant
+		found = true
 
-		// Get product
-		product, err := s.productRepo.GetByID(variant.ProductID)
-		if err != nil || product == nil {
+		s.revalidateItem(&items[i])
+		if !hasPriceChange(&items[i]) {
 			continue
 		}
-		items[i].Product = product
+		if err := s.cartRepo.SetUnitPrice(items[i].ID, owner, items[i].Variant.FinalPrice); err != nil {
+			return err
+		}
+	}
 
-		// Calculate price
-		itemPrice := product.BasePrice + variant.PriceAdjustment
-		variant.FinalPrice = itemPrice
-		totalPrice += itemPrice * float64(items[i].Quantity)
-		totalItems += items[i].Quantity
+	if !found {
+		return errors.New("cart item not found")
+	}
+	return nil
+}
+
+// revalidateItem populates a cart line and records any issues with it.
+// Returns false if the line can't be bought at all.
+func (s *CartService) revalidateItem(item *models.CartItem) bool {
+	// Get variant
+	variant, err := s.cartRepo.GetVariantByID(item.ProductVariantID)
+	if err != nil || variant == nil {
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:    models.CartIssueUnavailable,
+			Message: "This item is no longer available",
+		})
+		return false
+	}
+	item.Variant = variant
+
+	// Get product (including deactivated ones, to explain why)
+	product, err := s.productRepo.GetByIDIncludingInactive(variant.ProductID)
+	if err != nil || product == nil {
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:    models.CartIssueUnavailable,
+			Message: "This item is no longer available",
+		})
+		return false
+	}
+	item.Product = product
+
+	// Calculate price (sale price if one applies to the whole line)
+	if _, err := s.saleService.PriceVariant(product, variant, item.Quantity); err != nil {
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:    models.CartIssueUnavailable,
+			Message: "This item is no longer available",
+		})
+		return false
+	}
+	itemPrice := variant.FinalPrice
+
+	if !product.IsActive {
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:    models.CartIssueInactive,
+			Message: "This product is no longer sold",
+		})
+		return false
+	}
+
+	available := variant.StockQuantity
+	if available <= 0 {
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:      models.CartIssueOutOfStock,
+			Message:   "This item is out of stock",
+			Available: &available,
+		})
+		return false
+	}
+	if item.Quantity > available {
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:      models.CartIssueReducedStock,
+			Message:   fmt.Sprintf("Only %d left in stock", available),
+			Available: &available,
+		})
+	}
+
+	if item.AddedUnitPrice != nil && !item.AddedUnitPrice.Equal(itemPrice) {
+		oldPrice := *item.AddedUnitPrice
+		item.Issues = append(item.Issues, models.CartItemIssue{
+			Type:     models.CartIssuePriceChanged,
+			Message:  "The price of this item has changed since you added it",
+			OldPrice: &oldPrice,
+			NewPrice: &itemPrice,
+		})
 	}
 
-	return &models.CartResponse{
-		Items:      items,
-		TotalItems: totalItems,
-		TotalPrice: totalPrice,
-	}, nil
+	return true
 }
 
 // AddToCart adds item to cart
-func (s *CartService) AddToCart(userID int, req *models.AddToCartRequest) error {
+func (s *CartService) AddToCart(owner models.CartOwner, req *models.AddToCartRequest) error {
 	// Validate
 	if req.Quantity <= 0 {
 		return errors.New("quantity must be greater than 0")
@@ -74,8 +348,17 @@ func (s *CartService) AddToCart(userID int, req *models.AddToCartRequest) error
 		return errors.New("product variant not found")
 	}
 
+	// Only active products can be added
+	product, err := s.productRepo.GetByID(variant.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return errors.New("product is not available")
+	}
+
 	// Get current cart to check existing quantity
-	cartItems, err := s.cartRepo.GetUserCart(userID)
+	cartItems, err := s.cartRepo.GetCart(owner)
 	if err != nil {
 		return err
 	}
@@ -95,25 +378,107 @@ func (s *CartService) AddToCart(userID int, req *models.AddToCartRequest) error
 		return errors.New("insufficient stock")
 	}
 
+	// Remember the price the customer sees now
+	if _, err := s.saleService.PriceVariant(product, variant, totalQty); err != nil {
+		return err
+	}
+
 	// Add to cart
-	return s.cartRepo.AddItem(userID, req.ProductVariantID, req.Quantity)
+	return s.cartRepo.AddItem(owner, req.ProductVariantID, req.Quantity, variant.FinalPrice)
 }
 
 // UpdateCartItem updates cart item quantity
-func (s *CartService) UpdateCartItem(cartItemID, userID int, req *models.UpdateCartRequest) error {
+func (s *CartService) UpdateCartItem(cartItemID int, owner models.CartOwner, req *models.UpdateCartRequest) error {
 	if req.Quantity <= 0 {
 		return errors.New("quantity must be greater than 0")
 	}
 
-	return s.cartRepo.UpdateQuantity(cartItemID, userID, req.Quantity)
+	item, err := s.cartRepo.GetItem(cartItemID, owner)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return errors.New("cart item not found")
+	}
+
+	// Check new quantity against stock
+	variant, err := s.cartRepo.GetVariantByID(item.ProductVariantID)
+	if err != nil {
+		return err
+	}
+	if variant == nil {
+		return errors.New("product variant not found")
+	}
+	if req.Quantity > variant.StockQuantity {
+		if variant.StockQuantity <= 0 {
+			return errors.New("this item is out of stock")
+		}
+		return fmt.Errorf("insufficient stock: only %d available", variant.StockQuantity)
+	}
+
+	return s.cartRepo.UpdateQuantity(cartItemID, owner, req.Quantity)
+}
+
+// SaveForLater moves a cart line to the saved-for-later list
+func (s *CartService) SaveForLater(cartItemID int, owner models.CartOwner) error {
+	item, err := s.cartRepo.GetItem(cartItemID, owner)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return errors.New("cart item not found")
+	}
+	if item.SavedForLater {
+		return nil
+	}
+
+	return s.cartRepo.SetSavedForLater(cartItemID, owner, true)
+}
+
+// MoveToCart moves a saved-for-later line back into the cart
+func (s *CartService) MoveToCart(cartItemID int, owner models.CartOwner) error {
+	item, err := s.cartRepo.GetItem(cartItemID, owner)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return errors.New("cart item not found")
+	}
+	if !item.SavedForLater {
+		return nil
+	}
+
+	// The line must be purchasable again
+	variant, err := s.cartRepo.GetVariantByID(item.ProductVariantID)
+	if err != nil {
+		return err
+	}
+	if variant == nil {
+		return errors.New("product variant not found")
+	}
+	product, err := s.productRepo.GetByID(variant.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return errors.New("product is not available")
+	}
+	if item.Quantity > variant.StockQuantity {
+		if variant.StockQuantity <= 0 {
+			return errors.New("this item is out of stock")
+		}
+		return fmt.Errorf("insufficient stock: only %d available", variant.StockQuantity)
+	}
+
+	return s.cartRepo.SetSavedForLater(cartItemID, owner, false)
 }
 
 // RemoveFromCart removes item from cart
-func (s *CartService) RemoveFromCart(cartItemID, userID int) error {
-	return s.cartRepo.RemoveItem(cartItemID, userID)
+func (s *CartService) RemoveFromCart(cartItemID int, owner models.CartOwner) error {
+	return s.cartRepo.RemoveItem(cartItemID, owner)
 }
 
 // ClearCart removes all items
-func (s *CartService) ClearCart(userID int) error {
-	return s.cartRepo.ClearCart(userID)
+func (s *CartService) ClearCart(owner models.CartOwner) error {
+	return s.cartRepo.Clear(owner)
 }
\ No newline at end of file
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+type CatalogService struct {
+	catalogRepo *repository.CatalogRepository
+}
+
+func NewCatalogService(catalogRepo *repository.CatalogRepository) *CatalogService {
+	return &CatalogService{catalogRepo: catalogRepo}
+}
+
+// Import reads a catalog CSV and upserts products, variants and images by SKU.
+// The whole import runs in one transaction: any row error rolls everything back.
+// In dry-run mode the transaction is always rolled back.
+func (s *CatalogService) Import(r io.Reader, dryRun bool) (*models.CatalogImportResult, error) {
+	rows, rowErrors, err := parseCatalogCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CatalogImportResult{
+		DryRun:    dryRun,
+		TotalRows: len(rows) + len(rowErrors),
+		Errors:    rowErrors,
+	}
+
+	brands, err := s.catalogRepo.GetBrandIDsBySlug()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.catalogRepo.GetCategoryIDsBySlug()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.catalogRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	productIDs := map[string]int{}
+	imagesDone := map[string]bool{}
+
+	for _, row := range rows {
+		product := &models.Product{
+			Name:        row.ProductName,
+			Slug:        row.ProductSlug,
+			Description: row.Description,
+			BasePrice:   row.BasePrice,
+			IsActive:    row.IsActive,
+		}
+
+		if row.BrandSlug != "" {
+			id, ok := brands[row.BrandSlug]
+			if !ok {
+				result.Errors = append(result.Errors, rowError(row, "unknown brand: "+row.BrandSlug))
+				continue
+			}
+			product.BrandID = &id
+		}
+		if row.CategorySlug != "" {
+			id, ok := categories[row.CategorySlug]
+			if !ok {
+				result.Errors = append(result.Errors, rowError(row, "unknown category: "+row.CategorySlug))
+				continue
+			}
+			product.CategoryID = &id
+		}
+
+		var productCreated, variantCreated, productWritten bool
+		imagesWritten := 0
+
+		err := s.catalogRepo.Savepoint(tx, func() error {
+			// Product fields are taken from the first row of each slug
+			productID, seen := productIDs[row.ProductSlug]
+			if !seen {
+				id, created, err := s.catalogRepo.UpsertProduct(tx, product)
+				if err != nil {
+					return err
+				}
+				productID = id
+				productCreated = created
+				productWritten = true
+			}
+
+			variant := &models.ProductVariant{
+				ProductID:       productID,
+				SKU:             row.SKU,
+				Size:            row.Size,
+				Color:           row.Color,
+				ColorHex:        row.ColorHex,
+				StockQuantity:   row.StockQuantity,
+				PriceAdjustment: row.PriceAdjustment,
+				WeightGrams:     row.WeightGrams,
+			}
+			created, err := s.catalogRepo.UpsertVariant(tx, variant)
+			if err != nil {
+				return err
+			}
+			variantCreated = created
+
+			if len(row.ImageURLs) > 0 && !imagesDone[row.ProductSlug] {
+				if err := s.catalogRepo.ReplaceImages(tx, productID, row.ProductName, row.ImageURLs); err != nil {
+					return err
+				}
+				imagesWritten = len(row.ImageURLs)
+			}
+
+			productIDs[row.ProductSlug] = productID
+			return nil
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, rowError(row, err.Error()))
+			continue
+		}
+
+		if productWritten {
+			if productCreated {
+				result.ProductsCreated++
+			} else {
+				result.ProductsUpdated++
+			}
+		}
+		if variantCreated {
+			result.VariantsCreated++
+		} else {
+			result.VariantsUpdated++
+		}
+		if imagesWritten > 0 {
+			imagesDone[row.ProductSlug] = true
+			result.ImagesReplaced += imagesWritten
+		}
+	}
+
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Export writes the full catalog as CSV
+func (s *CatalogService) Export(w io.Writer) error {
+	rows, err := s.catalogRepo.ExportRows()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(models.CatalogColumns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.ProductSlug,
+			row.ProductName,
+			row.Description,
+			row.BrandSlug,
+			row.CategorySlug,
+			row.BasePrice.String(),
+			strconv.FormatBool(row.IsActive),
+			row.SKU,
+			row.Size,
+			row.Color,
+			row.ColorHex,
+			strconv.Itoa(row.StockQuantity),
+			row.PriceAdjustment.String(),
+			strconv.Itoa(row.WeightGrams),
+			strings.Join(row.ImageURLs, "|"),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseCatalogCSV parses and validates catalog rows.
+// Columns are matched by header name so their order doesn't matter.
+func parseCatalogCSV(r io.Reader) ([]models.CatalogRow, []models.CatalogRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"product_slug", "product_name", "base_price", "sku", "size", "color"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, errors.New("missing required column: " + required)
+		}
+	}
+
+	rows := []models.CatalogRow{}
+	rowErrors := []models.CatalogRowError{}
+	seenSKUs := map[string]int{}
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			rowErrors = append(rowErrors, models.CatalogRowError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := models.CatalogRow{
+			Line:         line,
+			ProductSlug:  get("product_slug"),
+			ProductName:  get("product_name"),
+			Description:  get("description"),
+			BrandSlug:    get("brand_slug"),
+			CategorySlug: get("category_slug"),
+			SKU:          get("sku"),
+			Size:         get("size"),
+			Color:        get("color"),
+			ColorHex:     get("color_hex"),
+			IsActive:     true,
+		}
+
+		if msg := validateCatalogRow(&row, get); msg != "" {
+			rowErrors = append(rowErrors, rowError(row, msg))
+			continue
+		}
+
+		if firstLine, dup := seenSKUs[row.SKU]; dup {
+			rowErrors = append(rowErrors, rowError(row, fmt.Sprintf("duplicate SKU (first seen on line %d)", firstLine)))
+			continue
+		}
+		seenSKUs[row.SKU] = line
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// validateCatalogRow fills the typed fields of row and returns an error message if invalid
+func validateCatalogRow(row *models.CatalogRow, get func(string) string) string {
+	if row.ProductSlug == "" {
+		return "product_slug is required"
+	}
+	if row.ProductName == "" {
+		return "product_name is required"
+	}
+	if row.SKU == "" {
+		return "sku is required"
+	}
+	if row.Size == "" || row.Color == "" {
+		return "size and color are required"
+	}
+	if row.ColorHex != "" && (len(row.ColorHex) != 7 || row.ColorHex[0] != '#') {
+		return "color_hex must look like #RRGGBB"
+	}
+
+	price, err := money.Parse(get("base_price"))
+	if err != nil || price.IsNegative() {
+		return "base_price must be a non-negative number"
+	}
+	row.BasePrice = price
+
+	if v := get("price_adjustment"); v != "" {
+		adj, err := money.Parse(v)
+		if err != nil {
+			return "price_adjustment must be a number"
+		}
+		row.PriceAdjustment = adj
+	}
+
+	if v := get("stock_quantity"); v != "" {
+		qty, err := strconv.Atoi(v)
+		if err != nil || qty < 0 {
+			return "stock_quantity must be a non-negative integer"
+		}
+		row.StockQuantity = qty
+	}
+
+	if v := get("weight_grams"); v != "" {
+		grams, err := strconv.Atoi(v)
+		if err != nil || grams < 0 {
+			return "weight_grams must be a non-negative integer"
+		}
+		row.WeightGrams = grams
+	}
+
+	if v := get("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return "is_active must be true or false"
+		}
+		row.IsActive = active
+	}
+
+	if v := get("image_urls"); v != "" {
+		for _, url := range strings.Split(v, "|") {
+			url = strings.TrimSpace(url)
+			if url == "" {
+				continue
+			}
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				return "invalid image URL: " + url
+			}
+			row.ImageURLs = append(row.ImageURLs, url)
+		}
+	}
+
+	return ""
+}
+
+func rowError(row models.CatalogRow, message string) models.CatalogRowError {
+	return models.CatalogRowError{Line: row.Line, SKU: row.SKU, Message: message}
+}
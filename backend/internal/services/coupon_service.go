@@ -0,0 +1,386 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+// discountLine is a purchasable cart or order line as seen by the discount rules
+type discountLine struct {
+	ProductID  int
+	VariantID  int
+	BrandID    *int
+	CategoryID *int
+	Quantity   int
+	UnitPrice  money.Money
+
+	// Discounts already allocated to the line (promotions, then the coupon)
+	Discount money.Money
+}
+
+// total is the line total after the discounts allocated so far
+func (l discountLine) total() money.Money {
+	return l.UnitPrice.Mul(l.Quantity).Sub(l.Discount)
+}
+
+// newDiscountLine builds a discount line from a populated product and variant
+func newDiscountLine(product *models.Product, variant *models.ProductVariant, quantity int, unitPrice money.Money) discountLine {
+	return discountLine{
+		ProductID:  product.ID,
+		VariantID:  variant.ID,
+		BrandID:    product.BrandID,
+		CategoryID: product.CategoryID,
+		Quantity:   quantity,
+		UnitPrice:  unitPrice,
+	}
+}
+
+type CouponService struct {
+	couponRepo *repository.CouponRepository
+}
+
+func NewCouponService(couponRepo *repository.CouponRepository) *CouponService {
+	return &CouponService{couponRepo: couponRepo}
+}
+
+// GetAllCoupons returns all coupons (admin)
+func (s *CouponService) GetAllCoupons() ([]models.Coupon, error) {
+	return s.couponRepo.GetAll()
+}
+
+// GetCoupon returns a coupon by ID (admin)
+func (s *CouponService) GetCoupon(id int) (*models.Coupon, error) {
+	return s.couponRepo.GetByID(id)
+}
+
+// CreateCoupon creates a coupon (admin)
+func (s *CouponService) CreateCoupon(req *models.CouponRequest) (*models.Coupon, error) {
+	coupon, err := couponFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.couponRepo.GetByCode(coupon.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("coupon code already exists")
+	}
+
+	if err := s.couponRepo.Create(coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// UpdateCoupon updates a coupon's settings; its usage counter is kept (admin)
+func (s *CouponService) UpdateCoupon(id int, req *models.CouponRequest) (*models.Coupon, error) {
+	coupon, err := couponFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	coupon.ID = id
+
+	existing, err := s.couponRepo.GetByCode(coupon.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != id {
+		return nil, errors.New("coupon code already exists")
+	}
+
+	found, err := s.couponRepo.Update(coupon)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("coupon not found")
+	}
+	return coupon, nil
+}
+
+// DeleteCoupon deletes a coupon that was never redeemed (admin).
+// Redeemed coupons must be deactivated instead to keep order history intact.
+func (s *CouponService) DeleteCoupon(id int) error {
+	deleted, err := s.couponRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return nil
+	}
+
+	coupon, err := s.couponRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if coupon == nil {
+		return errors.New("coupon not found")
+	}
+	return errors.New("coupon has been redeemed, deactivate it instead")
+}
+
+// couponFromRequest validates an admin coupon request
+func couponFromRequest(req *models.CouponRequest) (*models.Coupon, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !couponCodePattern.MatchString(code) {
+		return nil, errors.New("code must be 3-50 letters, digits, '-' or '_'")
+	}
+
+	switch req.DiscountType {
+	case models.CouponPercentage:
+		if req.DiscountValue <= 0 || req.DiscountValue > 100 {
+			return nil, errors.New("percentage must be between 0 and 100")
+		}
+	case models.CouponFixed:
+		if req.DiscountValue <= 0 {
+			return nil, errors.New("discount value must be greater than 0")
+		}
+	default:
+		return nil, errors.New("discount type must be percentage or fixed")
+	}
+
+	if req.MaxDiscount != nil && !req.MaxDiscount.GreaterThan(money.Money{}) {
+		return nil, errors.New("max discount must be greater than 0")
+	}
+	if req.MinSubtotal.IsNegative() {
+		return nil, errors.New("minimum subtotal cannot be negative")
+	}
+	if req.UsageLimit != nil && *req.UsageLimit <= 0 {
+		return nil, errors.New("usage limit must be greater than 0")
+	}
+	if req.PerUserLimit != nil && *req.PerUserLimit <= 0 {
+		return nil, errors.New("per-user limit must be greater than 0")
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return nil, errors.New("expiry must be after start")
+	}
+
+	coupon := &models.Coupon{
+		Code:          code,
+		Description:   strings.TrimSpace(req.Description),
+		DiscountType:  req.DiscountType,
+		DiscountValue: math.Round(req.DiscountValue*100) / 100,
+		MaxDiscount:   req.MaxDiscount,
+		MinSubtotal:   req.MinSubtotal,
+		BrandIDs:      req.BrandIDs,
+		CategoryIDs:   req.CategoryIDs,
+		UsageLimit:    req.UsageLimit,
+		PerUserLimit:  req.PerUserLimit,
+		StartsAt:      req.StartsAt,
+		ExpiresAt:     req.ExpiresAt,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+	}
+	if coupon.BrandIDs == nil {
+		coupon.BrandIDs = []int64{}
+	}
+	if coupon.CategoryIDs == nil {
+		coupon.CategoryIDs = []int64{}
+	}
+	return coupon, nil
+}
+
+// FindCoupon looks up a coupon by the code a customer entered
+func (s *CouponService) FindCoupon(code string) (*models.Coupon, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("coupon code is required")
+	}
+
+	coupon, err := s.couponRepo.GetByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if coupon == nil || !coupon.IsActive {
+		return nil, errors.New("invalid coupon code")
+	}
+	return coupon, nil
+}
+
+// Evaluate checks a coupon against the given lines and customer and returns the discount.
+// userID and email identify the customer for per-user limits (either may be empty).
+func (s *CouponService) Evaluate(coupon *models.Coupon, lines []discountLine, userID int, email string) (money.Money, error) {
+	discount, err := couponDiscount(coupon, lines, time.Now())
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if coupon.PerUserLimit != nil && (userID != 0 || email != "") {
+		used, err := s.couponRepo.CountRedemptions(coupon.ID, userID, email)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if used >= *coupon.PerUserLimit {
+			return money.Money{}, errors.New("you have already used this coupon")
+		}
+	}
+
+	return discount, nil
+}
+
+// Redeem re-checks a coupon with its row locked and records the redemption,
+// so concurrent checkouts can't exceed its usage limits.
+// Returns the redemption ID and discount; attach or release the redemption afterwards.
+func (s *CouponService) Redeem(couponID int, lines []discountLine, userID int, email string) (int, money.Money, error) {
+	tx, err := s.couponRepo.BeginTx()
+	if err != nil {
+		return 0, money.Money{}, err
+	}
+	defer tx.Rollback()
+
+	coupon, err := s.couponRepo.LockCoupon(tx, couponID)
+	if err != nil {
+		return 0, money.Money{}, err
+	}
+	if coupon == nil {
+		return 0, money.Money{}, errors.New("invalid coupon code")
+	}
+
+	discount, err := couponDiscount(coupon, lines, time.Now())
+	if err != nil {
+		return 0, money.Money{}, err
+	}
+
+	if coupon.PerUserLimit != nil {
+		used, err := s.couponRepo.CountRedemptionsTx(tx, coupon.ID, userID, email)
+		if err != nil {
+			return 0, money.Money{}, err
+		}
+		if used >= *coupon.PerUserLimit {
+			return 0, money.Money{}, errors.New("you have already used this coupon")
+		}
+	}
+
+	redemptionID, err := s.couponRepo.CreateRedemption(tx, coupon.ID, userID, email, discount)
+	if err != nil {
+		return 0, money.Money{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, money.Money{}, err
+	}
+	return redemptionID, discount, nil
+}
+
+// AttachRedemption links a redemption to the order that was placed
+func (s *CouponService) AttachRedemption(tx *sql.Tx, redemptionID, orderID int) error {
+	return s.couponRepo.AttachRedemption(tx, redemptionID, orderID)
+}
+
+// ReleaseRedemption gives back a redemption whose order failed
+func (s *CouponService) ReleaseRedemption(redemptionID int) error {
+	return s.couponRepo.ReleaseRedemption(redemptionID)
+}
+
+// GetCartCoupon returns the coupon applied to a cart, if any
+func (s *CouponService) GetCartCoupon(owner models.CartOwner) (*models.Coupon, error) {
+	return s.couponRepo.GetCartCoupon(owner)
+}
+
+// SetCartCoupon applies a coupon to a cart
+func (s *CouponService) SetCartCoupon(owner models.CartOwner, couponID int) error {
+	return s.couponRepo.SetCartCoupon(owner, couponID)
+}
+
+// RemoveCartCoupon removes the coupon from a cart
+func (s *CouponService) RemoveCartCoupon(owner models.CartOwner) error {
+	return s.couponRepo.RemoveCartCoupon(owner)
+}
+
+// MergeGuestCartCoupon carries a guest cart's coupon over to the user's cart
+func (s *CouponService) MergeGuestCartCoupon(guestID string, userID int) error {
+	return s.couponRepo.MergeGuestCartCoupon(guestID, userID)
+}
+
+// couponDiscount checks a coupon's status, validity window, global usage limit,
+// restrictions and minimum spend, and computes its discount on the eligible lines
+func couponDiscount(coupon *models.Coupon, lines []discountLine, now time.Time) (money.Money, error) {
+	var none money.Money
+	if !coupon.IsActive {
+		return none, errors.New("this coupon is no longer active")
+	}
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return none, errors.New("this coupon is not valid yet")
+	}
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		return none, errors.New("this coupon has expired")
+	}
+	if coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit {
+		return none, errors.New("this coupon has reached its usage limit")
+	}
+
+	var eligible money.Money
+	for _, line := range lines {
+		if couponAppliesTo(coupon, line) {
+			eligible = eligible.Add(line.total())
+		}
+	}
+	if !eligible.GreaterThan(none) {
+		return none, errors.New("this coupon doesn't apply to any items in your cart")
+	}
+	if eligible.LessThan(coupon.MinSubtotal) {
+		return none, fmt.Errorf("spend at least %s on eligible items to use this coupon", coupon.MinSubtotal)
+	}
+
+	var discount money.Money
+	if coupon.DiscountType == models.CouponPercentage {
+		discount = eligible.Percent(coupon.DiscountValue)
+		if coupon.MaxDiscount != nil {
+			discount = discount.Min(*coupon.MaxDiscount)
+		}
+	} else {
+		discount = money.FromFloat(coupon.DiscountValue)
+	}
+
+	return discount.Min(eligible), nil
+}
+
+// couponAppliesTo reports whether a line matches the coupon's brand and category restrictions
+func couponAppliesTo(coupon *models.Coupon, line discountLine) bool {
+	return inScope(coupon.BrandIDs, coupon.CategoryIDs, line)
+}
+
+// allocateCoupon splits a coupon discount over the eligible lines in proportion
+// to their totals; the shares always add up to the discount.
+func allocateCoupon(coupon *models.Coupon, lines []discountLine, discount money.Money) []money.Money {
+	weights := make([]int64, len(lines))
+	for i, line := range lines {
+		if couponAppliesTo(coupon, line) {
+			weights[i] = line.total().Amount
+		}
+	}
+	return discount.Allocate(weights)
+}
+
+// inScope reports whether a line matches brand and category lists (empty = any)
+func inScope(brandIDs, categoryIDs []int64, line discountLine) bool {
+	return matchesID(brandIDs, line.BrandID) && matchesID(categoryIDs, line.CategoryID)
+}
+
+// matchesID reports whether id is in ids; an empty list matches anything
+func matchesID(ids []int64, id *int) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	if id == nil {
+		return false
+	}
+	for _, allowed := range ids {
+		if allowed == int64(*id) {
+			return true
+		}
+	}
+	return false
+}
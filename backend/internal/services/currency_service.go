@@ -0,0 +1,211 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type CurrencyService struct {
+	currencyRepo *repository.CurrencyRepository
+}
+
+func NewCurrencyService(currencyRepo *repository.CurrencyRepository) *CurrencyService {
+	return &CurrencyService{currencyRepo: currencyRepo}
+}
+
+// GetCurrencies returns the base currency and the rates of the others
+func (s *CurrencyService) GetCurrencies() (*models.CurrencyList, error) {
+	rates, err := s.currencyRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return &models.CurrencyList{Base: money.DefaultCurrency, Rates: rates}, nil
+}
+
+// SetRate creates or updates a currency's exchange rate (admin).
+// Placed orders keep the rate they were charged at.
+func (s *CurrencyService) SetRate(currency string, req *models.ExchangeRateRequest) (*models.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyCodePattern.MatchString(currency) {
+		return nil, errors.New("currency must be a 3-letter ISO 4217 code")
+	}
+	if currency == money.DefaultCurrency {
+		return nil, errors.New("the base currency has no exchange rate")
+	}
+	if req.Rate <= 0 {
+		return nil, errors.New("rate must be greater than 0")
+	}
+
+	rate := &models.ExchangeRate{Currency: currency, Rate: req.Rate}
+	if err := s.currencyRepo.Upsert(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// DeleteRate stops offering a currency (admin)
+func (s *CurrencyService) DeleteRate(currency string) error {
+	deleted, err := s.currencyRepo.Delete(strings.ToUpper(strings.TrimSpace(currency)))
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("exchange rate not found")
+	}
+	return nil
+}
+
+// Converter returns the converter to a currency at its current rate.
+// An empty code means the base currency.
+func (s *CurrencyService) Converter(currency string) (*Converter, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == money.DefaultCurrency {
+		return &Converter{Currency: money.DefaultCurrency, Rate: 1}, nil
+	}
+
+	rate, err := s.currencyRepo.Get(currency)
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return nil, errors.New("unsupported currency: " + currency)
+	}
+	return &Converter{Currency: rate.Currency, Rate: rate.Rate}, nil
+}
+
+// Converter converts base currency amounts into another currency
+type Converter struct {
+	Currency string
+	Rate     float64 // Units of Currency per unit of the base currency
+}
+
+// IsBase reports whether the converter leaves amounts unchanged
+func (c *Converter) IsBase() bool {
+	return c.Currency == money.DefaultCurrency
+}
+
+// Money converts a base currency amount
+func (c *Converter) Money(m money.Money) money.Money {
+	if c.IsBase() {
+		return m
+	}
+	return m.Convert(c.Currency, c.Rate)
+}
+
+// ToBase converts an amount in the converter's currency back to the base currency
+func (c *Converter) ToBase(m money.Money) money.Money {
+	if c.IsBase() {
+		return m
+	}
+	return m.Convert(money.DefaultCurrency, 1/c.Rate)
+}
+
+// convertPtr converts an optional amount in place
+func (c *Converter) convertPtr(m *money.Money) {
+	if m != nil {
+		*m = c.Money(*m)
+	}
+}
+
+// reallocate replaces parts of a converted total with their share of it, so
+// they still add up to the total after rounding
+func reallocate(total money.Money, parts []*money.Money) {
+	weights := make([]int64, len(parts))
+	for i, part := range parts {
+		weights[i] = part.Amount
+	}
+	for i, amount := range total.Allocate(weights) {
+		*parts[i] = amount
+	}
+}
+
+// Product converts a product's prices for display
+func (c *Converter) Product(p *models.Product) {
+	if c.IsBase() || p == nil {
+		return
+	}
+	p.BasePrice = c.Money(p.BasePrice)
+	c.convertPtr(p.SalePrice)
+	c.convertPtr(p.CompareAtPrice)
+	for i := range p.Variants {
+		c.Variant(&p.Variants[i])
+	}
+}
+
+// Variant converts a variant's prices for display
+func (c *Converter) Variant(v *models.ProductVariant) {
+	if c.IsBase() || v == nil {
+		return
+	}
+	v.PriceAdjustment = c.Money(v.PriceAdjustment)
+	v.FinalPrice = c.Money(v.FinalPrice)
+	c.convertPtr(v.CompareAtPrice)
+}
+
+// Cart converts a cart for display. Line totals use the converted unit price,
+// as checkout does, and discounts keep adding up to the converted discount.
+func (c *Converter) Cart(cart *models.CartResponse) {
+	if c.IsBase() {
+		return
+	}
+
+	var totalPrice money.Money
+	itemDiscounts := []*money.Money{}
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		c.cartItem(item)
+		if isPurchasable(item) && item.Variant != nil {
+			totalPrice = totalPrice.Add(item.Variant.FinalPrice.Mul(item.Quantity))
+			itemDiscounts = append(itemDiscounts, &item.Discount)
+		}
+	}
+	for i := range cart.SavedItems {
+		c.cartItem(&cart.SavedItems[i])
+	}
+
+	discount := c.Money(cart.Discount)
+	reallocate(discount, itemDiscounts)
+	discounts := []*money.Money{}
+	for i := range cart.Promotions {
+		discounts = append(discounts, &cart.Promotions[i].Discount)
+	}
+	if cart.Coupon != nil {
+		discounts = append(discounts, &cart.Coupon.Discount)
+	}
+	reallocate(discount, discounts)
+
+	cart.TotalPrice = totalPrice.In(c.Currency)
+	cart.Discount = discount
+	cart.Total = cart.TotalPrice.Sub(discount)
+	cart.Currency = c.Currency
+}
+
+// cartItem converts a cart line's prices
+func (c *Converter) cartItem(item *models.CartItem) {
+	c.Product(item.Product)
+	c.Variant(item.Variant)
+	c.convertPtr(item.AddedUnitPrice)
+	for i := range item.Issues {
+		c.convertPtr(item.Issues[i].OldPrice)
+		c.convertPtr(item.Issues[i].NewPrice)
+	}
+}
+
+// ShippingQuotes converts shipping prices for display
+func (c *Converter) ShippingQuotes(quotes []models.ShippingQuote) {
+	if c.IsBase() {
+		return
+	}
+	for i := range quotes {
+		quotes[i].Price = c.Money(quotes[i].Price)
+		c.convertPtr(quotes[i].FreeThreshold)
+		c.convertPtr(quotes[i].AmountToFree)
+	}
+}
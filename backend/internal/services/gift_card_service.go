@@ -0,0 +1,431 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+// Gift card amounts customers can buy
+var (
+	minGiftCardAmount = money.FromMinor(10_00)
+	maxGiftCardAmount = money.FromMinor(10_000_00)
+)
+
+// giftCardAlphabet leaves out characters that are easy to mix up (0/O, 1/I/L)
+const giftCardAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// tender is gift card or store credit money taken for an order being placed
+type tender struct {
+	method        string
+	giftCardID    *int
+	code          string
+	amount        money.Money
+	transactionID int
+}
+
+type GiftCardService struct {
+	giftCardRepo        *repository.GiftCardRepository
+	notificationService *NotificationService
+}
+
+func NewGiftCardService(giftCardRepo *repository.GiftCardRepository, notificationService *NotificationService) *GiftCardService {
+	return &GiftCardService{giftCardRepo: giftCardRepo, notificationService: notificationService}
+}
+
+// GetAll returns all gift cards (admin)
+func (s *GiftCardService) GetAll() ([]models.GiftCard, error) {
+	return s.giftCardRepo.GetAll()
+}
+
+// GetPurchased returns the gift cards a user bought
+func (s *GiftCardService) GetPurchased(userID int) ([]models.GiftCard, error) {
+	return s.giftCardRepo.GetByPurchaser(userID)
+}
+
+// Issue creates an active gift card (admin) and emails it to the recipient
+func (s *GiftCardService) Issue(adminID int, apiKeyID *int, req *models.IssueGiftCardRequest) (*models.GiftCard, error) {
+	if !req.Amount.GreaterThan(money.Money{}) {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+	email, err := giftCardRecipient(req.RecipientEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &models.GiftCard{
+		InitialBalance: req.Amount,
+		Balance:        req.Amount,
+		RecipientEmail: email,
+		Message:        strings.TrimSpace(req.Message),
+		IsActive:       true,
+		ExpiresAt:      req.ExpiresAt,
+		APIKeyID:       apiKeyID,
+	}
+	if adminID != 0 {
+		card.IssuedBy = &adminID
+	}
+	if err := s.create(card); err != nil {
+		return nil, err
+	}
+
+	s.notifyRecipient(card)
+	return card, nil
+}
+
+// Purchase creates an inactive gift card for a customer to pay for.
+// It becomes usable once the payment is confirmed (see ActivatePurchased).
+func (s *GiftCardService) Purchase(userID int, req *models.PurchaseGiftCardRequest) (*models.GiftCard, error) {
+	if req.Amount.LessThan(minGiftCardAmount) || req.Amount.GreaterThan(maxGiftCardAmount) {
+		return nil, fmt.Errorf("gift cards are available from %s to %s %s",
+			minGiftCardAmount, maxGiftCardAmount, money.DefaultCurrency)
+	}
+	email, err := giftCardRecipient(req.RecipientEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &models.GiftCard{
+		InitialBalance:  req.Amount,
+		Balance:         req.Amount,
+		RecipientEmail:  email,
+		Message:         strings.TrimSpace(req.Message),
+		PurchaserUserID: &userID,
+	}
+	if err := s.create(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// GetForPayment returns a purchased gift card that is still waiting to be paid
+func (s *GiftCardService) GetForPayment(id int) (*models.GiftCard, error) {
+	card, err := s.giftCardRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil || card.PurchaserUserID == nil || card.PaymentTransactionID != "" {
+		return nil, errors.New("gift card not found or already paid")
+	}
+	return card, nil
+}
+
+// ActivatePurchased activates a purchased gift card after its payment succeeded
+// and emails it to the recipient
+func (s *GiftCardService) ActivatePurchased(id int, transactionID string) error {
+	activated, err := s.giftCardRepo.ActivatePurchased(id, transactionID)
+	if err != nil {
+		return err
+	}
+	if !activated {
+		return errors.New("gift card not found or already paid")
+	}
+
+	card, err := s.giftCardRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if card != nil {
+		s.notifyRecipient(card)
+	}
+	return nil
+}
+
+// Update deactivates, reactivates or changes the expiry of a gift card (admin)
+func (s *GiftCardService) Update(id int, req *models.UpdateGiftCardRequest) (*models.GiftCard, error) {
+	found, err := s.giftCardRepo.Update(id, req.IsActive, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("gift card not found")
+	}
+	return s.giftCardRepo.GetByID(id)
+}
+
+// CheckBalance returns the balance of a gift card code
+func (s *GiftCardService) CheckBalance(code string) (*models.GiftCardBalance, error) {
+	card, err := s.giftCardRepo.GetByCode(normalizeGiftCardCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if card == nil || (!card.IsActive && card.PaymentTransactionID == "" && card.PurchaserUserID != nil) {
+		return nil, errors.New("gift card not found")
+	}
+	return &models.GiftCardBalance{
+		Code:      card.Code,
+		Balance:   card.Balance,
+		Currency:  money.DefaultCurrency,
+		IsActive:  card.IsActive,
+		ExpiresAt: card.ExpiresAt,
+	}, nil
+}
+
+// GetStoreCredit returns a user's store credit balance and history
+func (s *GiftCardService) GetStoreCredit(userID int) (*models.StoreCredit, error) {
+	balance, err := s.giftCardRepo.GetStoreCreditBalance(userID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.giftCardRepo.GetStoreCreditTransactions(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StoreCredit{Balance: balance, Currency: money.DefaultCurrency, Transactions: transactions}, nil
+}
+
+// AdjustStoreCredit adds (or with a negative amount removes) store credit (admin)
+func (s *GiftCardService) AdjustStoreCredit(userID, adminID int, apiKeyID *int, req *models.StoreCreditAdjustmentRequest) (*models.StoreCreditTransaction, error) {
+	if req.Amount.IsZero() {
+		return nil, errors.New("amount is required")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	tx, err := s.giftCardRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	balance, err := s.giftCardRepo.LockStoreCredit(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Add(req.Amount).IsNegative() {
+		return nil, errors.New("store credit balance is only " + balance.String())
+	}
+
+	t := &models.StoreCreditTransaction{UserID: userID, Amount: req.Amount, Reason: reason, APIKeyID: apiKeyID}
+	if adminID != 0 {
+		t.CreatedBy = &adminID
+	}
+	if err := s.giftCardRepo.AddStoreCreditTransaction(tx, t); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// redeem takes up to amount from the gift cards, in the order given, and then from
+// the user's store credit. The cards and wallet stay locked while the balances are
+// taken, so concurrent checkouts can't spend the same money twice.
+// What was taken must be attached to the order, or released if it fails.
+func (s *GiftCardService) redeem(codes []string, userID int, useStoreCredit bool, amount money.Money) ([]tender, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		code = normalizeGiftCardCode(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+
+	tx, err := s.giftCardRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cards, err := s.giftCardRepo.LockGiftCards(tx, normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	tenders := []tender{}
+	remaining := amount
+	now := time.Now()
+	for _, code := range normalized {
+		card := cards[code]
+		if card == nil || (!card.IsActive && card.PurchaserUserID != nil && card.PaymentTransactionID == "") {
+			return nil, errors.New("gift card " + code + " not found")
+		}
+		if !card.IsActive {
+			return nil, errors.New("gift card " + code + " is no longer valid")
+		}
+		if card.ExpiresAt != nil && now.After(*card.ExpiresAt) {
+			return nil, errors.New("gift card " + code + " has expired")
+		}
+		if card.Balance.IsZero() {
+			return nil, errors.New("gift card " + code + " has no balance left")
+		}
+
+		take := card.Balance.Min(remaining)
+		if take.IsZero() {
+			continue
+		}
+		id, err := s.giftCardRepo.AddGiftCardTransaction(tx, card.ID, nil, take.Neg(), "Order payment")
+		if err != nil {
+			return nil, err
+		}
+		cardID := card.ID
+		tenders = append(tenders, tender{
+			method: models.PaymentMethodGiftCard, giftCardID: &cardID, code: card.Code, amount: take, transactionID: id,
+		})
+		remaining = remaining.Sub(take)
+	}
+
+	if useStoreCredit && userID != 0 {
+		balance, err := s.giftCardRepo.LockStoreCredit(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if take := balance.Min(remaining); take.GreaterThan(money.Money{}) {
+			t := &models.StoreCreditTransaction{UserID: userID, Amount: take.Neg(), Reason: "Order payment"}
+			if err := s.giftCardRepo.AddStoreCreditTransaction(tx, t); err != nil {
+				return nil, err
+			}
+			tenders = append(tenders, tender{
+				method: models.PaymentMethodStoreCredit, amount: take, transactionID: t.ID,
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return tenders, nil
+}
+
+// attach links the gift card and store credit transactions to the placed order
+func (s *GiftCardService) attach(tx *sql.Tx, tenders []tender, orderID int) error {
+	for _, t := range tenders {
+		var err error
+		if t.method == models.PaymentMethodGiftCard {
+			err = s.giftCardRepo.AttachGiftCardTransaction(tx, t.transactionID, orderID)
+		} else {
+			err = s.giftCardRepo.AttachStoreCreditTransaction(tx, t.transactionID, orderID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release gives back the money taken for an order that failed
+func (s *GiftCardService) release(tenders []tender) {
+	for _, t := range tenders {
+		var err error
+		if t.method == models.PaymentMethodGiftCard {
+			err = s.giftCardRepo.ReleaseGiftCardTransaction(t.transactionID)
+		} else {
+			err = s.giftCardRepo.ReleaseStoreCreditTransaction(t.transactionID)
+		}
+		if err != nil {
+			log.Printf("Failed to release %s transaction %d: %v", t.method, t.transactionID, err)
+		}
+	}
+}
+
+// creditGiftCard gives money back to a gift card inside a caller's transaction (refunds)
+func (s *GiftCardService) creditGiftCard(tx *sql.Tx, giftCardID, orderID int, amount money.Money, note string) error {
+	_, err := s.giftCardRepo.AddGiftCardTransaction(tx, giftCardID, &orderID, amount, note)
+	return err
+}
+
+// creditStoreCredit adds store credit inside a caller's transaction (refunds)
+func (s *GiftCardService) creditStoreCredit(tx *sql.Tx, t *models.StoreCreditTransaction) error {
+	return s.giftCardRepo.AddStoreCreditTransaction(tx, t)
+}
+
+// create generates a unique code and stores the card
+func (s *GiftCardService) create(card *models.GiftCard) error {
+	for attempt := 0; ; attempt++ {
+		code, err := newGiftCardCode()
+		if err != nil {
+			return err
+		}
+		existing, err := s.giftCardRepo.GetByCode(code)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			card.Code = code
+			break
+		}
+		if attempt == 5 {
+			return errors.New("failed to generate a gift card code")
+		}
+	}
+	return s.giftCardRepo.Create(card)
+}
+
+// notifyRecipient emails an active gift card to its recipient, if it has one
+func (s *GiftCardService) notifyRecipient(card *models.GiftCard) {
+	if card.RecipientEmail == "" {
+		return
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "You've received a gift card worth %s %s.\n\n", card.InitialBalance, money.DefaultCurrency)
+	if card.Message != "" {
+		fmt.Fprintf(&body, "%s\n\n", card.Message)
+	}
+	fmt.Fprintf(&body, "Your code: %s\n", card.Code)
+	if card.ExpiresAt != nil {
+		fmt.Fprintf(&body, "Valid until %s\n", card.ExpiresAt.Format("2006-01-02"))
+	}
+
+	_, err := s.notificationService.QueueEmail(nil, "gift_card", card.RecipientEmail, "You've received a gift card", body.String())
+	if err != nil {
+		log.Printf("Failed to queue gift card email for card %d: %v", card.ID, err)
+	}
+}
+
+// giftCardRecipient validates the optional recipient email
+func giftCardRecipient(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email != "" && !strings.Contains(email, "@") {
+		return "", errors.New("invalid recipient email")
+	}
+	return email, nil
+}
+
+// newGiftCardCode returns a random code like 7KQ2-M9XD-R4TB-WN3H
+func newGiftCardCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(giftCardAlphabet)))
+	for i := 0; i < 16; i++ {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(giftCardAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeGiftCardCode accepts codes typed in any case, with or without dashes and spaces
+func normalizeGiftCardCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			if b.Len() > 0 && (b.Len()+1)%5 == 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
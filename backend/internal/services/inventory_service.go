@@ -0,0 +1,236 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+// stockSyncBatchSize is how many SKUs are locked and updated per statement
+const stockSyncBatchSize = 500
+
+type InventoryService struct {
+	inventoryRepo *repository.InventoryRepository
+}
+
+func NewInventoryService(inventoryRepo *repository.InventoryRepository) *InventoryService {
+	return &InventoryService{inventoryRepo: inventoryRepo}
+}
+
+// SyncStock applies stock quantities and price adjustments by SKU.
+// All batches run in a single transaction and every change is written to the audit log,
+// naming the user or API key that made it.
+func (s *InventoryService) SyncStock(items []models.StockSyncItem, source string, changedBy, apiKeyID *int) (*models.StockSyncResult, error) {
+	if err := validateStockSyncItems(items); err != nil {
+		return nil, err
+	}
+
+	result := &models.StockSyncResult{
+		Updated:   []string{},
+		Unchanged: []string{},
+		Unknown:   []string{},
+	}
+
+	tx, err := s.inventoryRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(items); start += stockSyncBatchSize {
+		end := start + stockSyncBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		batch := items[start:end]
+
+		skus := make([]string, len(batch))
+		for i, item := range batch {
+			skus[i] = item.SKU
+		}
+
+		variants, err := s.inventoryRepo.LockVariantsBySKU(tx, skus)
+		if err != nil {
+			return nil, err
+		}
+		bySKU := map[string]models.ProductVariant{}
+		for _, v := range variants {
+			bySKU[v.SKU] = v
+		}
+
+		changes := []models.StockAuditEntry{}
+		for _, item := range batch {
+			variant, ok := bySKU[item.SKU]
+			if !ok {
+				result.Unknown = append(result.Unknown, item.SKU)
+				continue
+			}
+
+			change := models.StockAuditEntry{
+				ProductVariantID:   variant.ID,
+				SKU:                variant.SKU,
+				OldStockQuantity:   variant.StockQuantity,
+				NewStockQuantity:   variant.StockQuantity,
+				OldPriceAdjustment: variant.PriceAdjustment,
+				NewPriceAdjustment: variant.PriceAdjustment,
+			}
+			if item.StockQuantity != nil {
+				change.NewStockQuantity = *item.StockQuantity
+			}
+			if item.PriceAdjustment != nil {
+				change.NewPriceAdjustment = *item.PriceAdjustment
+			}
+
+			if change.NewStockQuantity == change.OldStockQuantity &&
+				change.NewPriceAdjustment.Equal(change.OldPriceAdjustment) {
+				result.Unchanged = append(result.Unchanged, item.SKU)
+				continue
+			}
+
+			changes = append(changes, change)
+			result.Updated = append(result.Updated, item.SKU)
+		}
+
+		if err := s.inventoryRepo.ApplyStockChanges(tx, changes, source, changedBy, apiKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetAuditLog returns recent stock changes, optionally filtered by SKU
+func (s *InventoryService) GetAuditLog(sku string, limit int) ([]models.StockAuditEntry, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return s.inventoryRepo.GetAuditLog(strings.TrimSpace(sku), limit)
+}
+
+// ParseStockSyncJSON parses either {"items": [...]} or a bare array of items
+func ParseStockSyncJSON(r io.Reader) ([]models.StockSyncItem, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var items []models.StockSyncItem
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, errors.New("invalid JSON")
+		}
+		return items, nil
+	}
+
+	var req models.StockSyncRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, errors.New("invalid JSON")
+	}
+	return req.Items, nil
+}
+
+// ParseStockSyncCSV parses a CSV with a sku column and optional
+// stock_quantity and price_adjustment columns. Empty cells are left unchanged.
+func ParseStockSyncCSV(r io.Reader) ([]models.StockSyncItem, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, errors.New("missing required column: sku")
+	}
+
+	items := []models.StockSyncItem{}
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		item := models.StockSyncItem{SKU: get("sku")}
+		if v := get("stock_quantity"); v != "" {
+			qty, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: stock_quantity must be an integer", line)
+			}
+			item.StockQuantity = &qty
+		}
+		if v := get("price_adjustment"); v != "" {
+			adj, err := money.Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: price_adjustment must be a number", line)
+			}
+			item.PriceAdjustment = &adj
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// validateStockSyncItems rejects the whole file on the first bad line
+func validateStockSyncItems(items []models.StockSyncItem) error {
+	if len(items) == 0 {
+		return errors.New("no items to sync")
+	}
+
+	seen := map[string]bool{}
+	for i := range items {
+		items[i].SKU = strings.TrimSpace(items[i].SKU)
+		item := items[i]
+
+		if item.SKU == "" {
+			return fmt.Errorf("item %d: sku is required", i+1)
+		}
+		if seen[item.SKU] {
+			return errors.New("duplicate SKU: " + item.SKU)
+		}
+		seen[item.SKU] = true
+
+		if item.StockQuantity == nil && item.PriceAdjustment == nil {
+			return errors.New("nothing to update for SKU: " + item.SKU)
+		}
+		if item.StockQuantity != nil && *item.StockQuantity < 0 {
+			return errors.New("stock_quantity cannot be negative for SKU: " + item.SKU)
+		}
+	}
+
+	return nil
+}
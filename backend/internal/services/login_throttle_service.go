@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+)
+
+// loginBackoffStart is the wait after the first failure that triggers backoff;
+// it doubles with each further failure
+const loginBackoffStart = time.Second
+
+// LoginThrottledError is returned for a login attempt made while the account
+// or IP is backing off or locked out
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottleService slows down password guessing. Failed logins are counted
+// per account email and per client IP. After half the allowed failures each
+// further failure makes the next attempt wait twice as long (from a second);
+// reaching the limit locks logins for the lockout period. Counts are forgotten
+// after a lockout period without failures, or for an account on a successful login.
+type LoginThrottleService struct {
+	loginAttemptRepo *repository.LoginAttemptRepository
+
+	maxFailures   int // Per account email
+	maxIPFailures int // Per client IP; higher since IPs can be shared
+	lockout       time.Duration
+}
+
+func NewLoginThrottleService(loginAttemptRepo *repository.LoginAttemptRepository, maxFailures, maxIPFailures int, lockout time.Duration) *LoginThrottleService {
+	return &LoginThrottleService{
+		loginAttemptRepo: loginAttemptRepo,
+		maxFailures:      maxFailures,
+		maxIPFailures:    maxIPFailures,
+		lockout:          lockout,
+	}
+}
+
+// Check returns a *LoginThrottledError if the account or IP may not try to log in yet
+func (s *LoginThrottleService) Check(email, ip string) error {
+	until, err := s.loginAttemptRepo.LockedUntil(email, ip)
+	if err != nil {
+		return err
+	}
+	if until != nil {
+		if wait := time.Until(*until); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// Fail records a failed login for the audit trail and, unless it was rejected
+// by the throttle itself, counts it against the account and IP
+func (s *LoginThrottleService) Fail(email string, userID *int, client models.ClientInfo, reason string) {
+	failure := &models.LoginFailure{
+		Email:     truncate(email, 255),
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		UserAgent: truncate(client.UserAgent, 255),
+		Reason:    reason,
+	}
+	if err := s.loginAttemptRepo.LogFailure(failure); err != nil {
+		log.Printf("Failed to log failed login for %s: %v", email, err)
+	}
+	if reason == models.LoginFailureThrottled {
+		return
+	}
+
+	s.count(models.LoginScopeAccount, email, s.maxFailures)
+	if client.IPAddress != "" {
+		s.count(models.LoginScopeIP, client.IPAddress, s.maxIPFailures)
+	}
+}
+
+// Succeed forgets an account's failed logins
+func (s *LoginThrottleService) Succeed(email string) {
+	if err := s.loginAttemptRepo.Reset(models.LoginScopeAccount, email); err != nil {
+		log.Printf("Failed to reset login failures for %s: %v", email, err)
+	}
+}
+
+// GetFailures returns the latest failed logins, optionally filtered by email and IP (admin)
+func (s *LoginThrottleService) GetFailures(email, ip string, limit int) ([]models.LoginFailure, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.loginAttemptRepo.GetFailures(email, ip, limit)
+}
+
+// Run removes stale failure counts every interval; meant to run in a goroutine
+func (s *LoginThrottleService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.loginAttemptRepo.DeleteStale(time.Now().Add(-s.lockout)); err != nil {
+			log.Printf("Login throttle cleanup failed: %v", err)
+		}
+	}
+}
+
+// count adds a failure for an account or IP and locks it for the resulting wait
+func (s *LoginThrottleService) count(scope, key string, max int) {
+	failures, err := s.loginAttemptRepo.RecordFailure(scope, key, s.lockout)
+	if err != nil {
+		log.Printf("Failed to count failed login for %s %s: %v", scope, key, err)
+		return
+	}
+
+	wait := s.wait(failures, max)
+	if wait == 0 {
+		return
+	}
+	if failures >= max {
+		log.Printf("Login locked for %s %s after %d failures", scope, key, failures)
+	}
+	if err := s.loginAttemptRepo.Lock(scope, key, time.Now().Add(wait)); err != nil {
+		log.Printf("Failed to lock login for %s %s: %v", scope, key, err)
+	}
+}
+
+// wait is how long to block logins after failures consecutive failures
+func (s *LoginThrottleService) wait(failures, max int) time.Duration {
+	if failures >= max {
+		return s.lockout
+	}
+	free := max / 2
+	if failures <= free {
+		return 0
+	}
+
+	wait := loginBackoffStart
+	for i := free + 1; i < failures && wait < s.lockout; i++ {
+		wait *= 2
+	}
+	if wait > s.lockout {
+		wait = s.lockout
+	}
+	return wait
+}
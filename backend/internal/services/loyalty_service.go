@@ -0,0 +1,327 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+type LoyaltyService struct {
+	loyaltyRepo *repository.LoyaltyRepository
+
+	pointsPerUnit int           // Points earned per unit of the base currency
+	pointValue    money.Money   // What one point pays for at checkout
+	expireAfter   time.Duration // Inactivity after which the balance expires
+}
+
+func NewLoyaltyService(loyaltyRepo *repository.LoyaltyRepository, pointsPerUnit int, pointValue money.Money, expireAfter time.Duration) *LoyaltyService {
+	return &LoyaltyService{
+		loyaltyRepo:   loyaltyRepo,
+		pointsPerUnit: pointsPerUnit,
+		pointValue:    pointValue,
+		expireAfter:   expireAfter,
+	}
+}
+
+// GetAccount returns a user's points balance, its value and history
+func (s *LoyaltyService) GetAccount(userID int) (*models.LoyaltyAccount, error) {
+	balance, lastActivity, err := s.loyaltyRepo.GetAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.loyaltyRepo.GetTransactions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.LoyaltyAccount{
+		Balance:       balance,
+		Value:         s.pointValue.Mul(balance),
+		PointValue:    s.pointValue,
+		PointsPerUnit: s.pointsPerUnit,
+		Currency:      money.DefaultCurrency,
+		Transactions:  transactions,
+	}
+	if balance > 0 && lastActivity != nil {
+		expiresAt := lastActivity.Add(s.expireAfter)
+		account.ExpiresAt = &expiresAt
+	}
+	return account, nil
+}
+
+// Adjust adds (or with negative points removes) points (admin)
+func (s *LoyaltyService) Adjust(userID, adminID int, apiKeyID *int, req *models.LoyaltyAdjustmentRequest) (*models.LoyaltyTransaction, error) {
+	if req.Points == 0 {
+		return nil, errors.New("points are required")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	tx, err := s.loyaltyRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	balance, err := s.loyaltyRepo.LockAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance+req.Points < 0 {
+		return nil, fmt.Errorf("points balance is only %d", balance)
+	}
+
+	t := &models.LoyaltyTransaction{
+		UserID: userID, Type: models.LoyaltyAdjust, Points: req.Points, Reason: reason, APIKeyID: apiKeyID,
+	}
+	if adminID != 0 {
+		t.CreatedBy = &adminID
+	}
+	if err := s.loyaltyRepo.AddTransaction(tx, t, req.Points > 0); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ExpireInactive expires the balances of customers who haven't earned or
+// redeemed points for the expiry period
+func (s *LoyaltyService) ExpireInactive() (int64, error) {
+	return s.loyaltyRepo.ExpireInactive(time.Now().Add(-s.expireAfter))
+}
+
+// Run expires inactive balances every interval; meant to run in a goroutine
+func (s *LoyaltyService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expired, err := s.ExpireInactive()
+		if err != nil {
+			log.Printf("Loyalty points expiry failed: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("✓ Loyalty points expired for %d customer(s)", expired)
+		}
+	}
+}
+
+// redeem spends up to points, worth at most max, for an order being placed.
+// Returns nil if no points were spent. The spend must be attached to the
+// order, or released if it fails.
+func (s *LoyaltyService) redeem(userID, points int, max money.Money) (*tender, error) {
+	if points < 0 {
+		return nil, errors.New("loyalty points can't be negative")
+	}
+	if userID == 0 {
+		return nil, errors.New("log in to use loyalty points")
+	}
+
+	// Don't spend points on more than is left to pay
+	if s.pointValue.Mul(points).GreaterThan(max) {
+		points = int(max.Amount / s.pointValue.Amount)
+	}
+	if points == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.loyaltyRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	balance, err := s.loyaltyRepo.LockAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance < points {
+		return nil, fmt.Errorf("you have only %d loyalty points", balance)
+	}
+
+	t := &models.LoyaltyTransaction{
+		UserID: userID, Type: models.LoyaltyRedeem, Points: -points, Reason: "Order payment",
+	}
+	if err := s.loyaltyRepo.AddTransaction(tx, t, true); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &tender{
+		method: models.PaymentMethodLoyaltyPoints, amount: s.pointValue.Mul(points), transactionID: t.ID,
+	}, nil
+}
+
+// attach links the points spent to the placed order
+func (s *LoyaltyService) attach(tx *sql.Tx, t *tender, orderID int) error {
+	if t == nil {
+		return nil
+	}
+	return s.loyaltyRepo.AttachTransaction(tx, t.transactionID, orderID)
+}
+
+// release gives back the points spent on an order that failed
+func (s *LoyaltyService) release(t *tender) {
+	if t == nil {
+		return
+	}
+	if err := s.loyaltyRepo.ReleaseTransaction(t.transactionID); err != nil {
+		log.Printf("Failed to release loyalty transaction %d: %v", t.transactionID, err)
+	}
+}
+
+// earn credits the points for a delivered order: pointsPerUnit for each whole
+// unit of its total in the base currency, less what was refunded
+func (s *LoyaltyService) earn(order *models.Order) error {
+	paid := order.Total
+	for _, refund := range order.Refunds {
+		paid = paid.Sub(refund.Amount)
+	}
+	charged := &Converter{Currency: order.Currency, Rate: order.ExchangeRate}
+	points := int(charged.ToBase(paid).Amount / 100 * int64(s.pointsPerUnit))
+	if points <= 0 {
+		return nil
+	}
+
+	tx, err := s.loyaltyRepo.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.loyaltyRepo.LockAccount(tx, order.UserID); err != nil {
+		return err
+	}
+	earned, err := s.loyaltyRepo.GetOrderPoints(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	if _, ok := earned[models.LoyaltyEarn]; ok {
+		return nil
+	}
+
+	orderID := order.ID
+	t := &models.LoyaltyTransaction{
+		UserID: order.UserID, Type: models.LoyaltyEarn, Points: points, OrderID: &orderID,
+		Reason: "Order " + order.OrderNumber + " delivered",
+	}
+	if err := s.loyaltyRepo.AddTransaction(tx, t, true); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// cancel takes back the points a cancelled order earned, inside the
+// cancellation's transaction. The points spent on it are given back with its
+// other payments (see returnSpent).
+func (s *LoyaltyService) cancel(tx *sql.Tx, order *models.Order) error {
+	balance, err := s.loyaltyRepo.LockAccount(tx, order.UserID)
+	if err != nil {
+		return err
+	}
+	points, err := s.loyaltyRepo.GetOrderPoints(tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	// Points already spent can't be taken back
+	reverse := points[models.LoyaltyEarn] + points[models.LoyaltyReverse]
+	if reverse > balance {
+		reverse = balance
+	}
+	if reverse <= 0 {
+		return nil
+	}
+
+	orderID := order.ID
+	t := &models.LoyaltyTransaction{
+		UserID: order.UserID, Type: models.LoyaltyReverse, Points: -reverse, OrderID: &orderID,
+		Reason: "Order " + order.OrderNumber + " cancelled",
+	}
+	return s.loyaltyRepo.AddTransaction(tx, t, false)
+}
+
+// returnSpent gives back the points behind amount of an order's points payment,
+// in proportion to what they paid, inside the caller's transaction (refunds and
+// cancellation). Once the rest of the payment is given back, so are all the
+// points left, so rounding never keeps any.
+func (s *LoyaltyService) returnSpent(tx *sql.Tx, order *models.Order, payment models.OrderPayment, amount money.Money, refundID *int, reason string) error {
+	if _, err := s.loyaltyRepo.LockAccount(tx, order.UserID); err != nil {
+		return err
+	}
+	points, err := s.loyaltyRepo.GetOrderPoints(tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	spent := -points[models.LoyaltyRedeem]
+	left := spent - points[models.LoyaltyReturn]
+	returned := left
+	if amount.LessThan(payment.Amount.Sub(payment.Refunded)) {
+		returned = int(int64(spent) * amount.Amount / payment.Amount.Amount)
+		if returned > left {
+			returned = left
+		}
+	}
+	if returned <= 0 {
+		return nil
+	}
+
+	orderID := order.ID
+	t := &models.LoyaltyTransaction{
+		UserID: order.UserID, Type: models.LoyaltyReturn, Points: returned, OrderID: &orderID, RefundID: refundID,
+		Reason: reason,
+	}
+	return s.loyaltyRepo.AddTransaction(tx, t, false)
+}
+
+// reverseRefund takes back the refunded share of the points an order earned,
+// inside the refund's transaction
+func (s *LoyaltyService) reverseRefund(tx *sql.Tx, order *models.Order, refund *models.OrderRefund) error {
+	if order.UserID == 0 || order.Total.IsZero() {
+		return nil
+	}
+
+	balance, err := s.loyaltyRepo.LockAccount(tx, order.UserID)
+	if err != nil {
+		return err
+	}
+	points, err := s.loyaltyRepo.GetOrderPoints(tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	earned := points[models.LoyaltyEarn]
+	reverse := int(int64(earned) * refund.Amount.Amount / order.Total.Amount)
+	if left := earned + points[models.LoyaltyReverse]; reverse > left {
+		reverse = left
+	}
+	// Points already spent can't be taken back
+	if reverse > balance {
+		reverse = balance
+	}
+	if reverse <= 0 {
+		return nil
+	}
+
+	orderID, refundID := order.ID, refund.ID
+	t := &models.LoyaltyTransaction{
+		UserID: order.UserID, Type: models.LoyaltyReverse, Points: -reverse, OrderID: &orderID, RefundID: &refundID,
+		Reason: "Refund for order " + order.OrderNumber,
+	}
+	return s.loyaltyRepo.AddTransaction(tx, t, false)
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ecommerce-backend/internal/models"
+)
+
+// FileSender writes each notification to its own file in Dir, in a
+// mail-like format. A local stand-in for an email provider.
+type FileSender struct {
+	Dir string
+}
+
+// fileSeq keeps file names unique when several mails are written at once
+var fileSeq atomic.Int64
+
+// Send writes the notification to a new file
+func (s FileSender) Send(n *models.Notification) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%d-%s.eml", time.Now().Format("20060102-150405"), fileSeq.Add(1), n.Type)
+	content := fmt.Sprintf("To: %s\nSubject: %s\nDate: %s\n\n%s\n",
+		n.Recipient, n.Subject, time.Now().Format(time.RFC1123Z), n.Body)
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(content), 0o600)
+}
+
+// NewSender returns the mailer for a MAIL_DRIVER setting: "log" (stdout) or "file"
+func NewSender(driver, dir string) (Sender, error) {
+	switch strings.ToLower(driver) {
+	case "", "log", "stdout":
+		return LogSender{}, nil
+	case "file":
+		return FileSender{Dir: dir}, nil
+	}
+	return nil, fmt.Errorf("unknown mail driver %q", driver)
+}
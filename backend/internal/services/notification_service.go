@@ -0,0 +1,109 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+)
+
+// maxNotificationAttempts is how often delivery is retried before giving up
+const maxNotificationAttempts = 5
+
+// notificationClaimLease is how long a dispatcher has to send the notifications
+// it claimed before another dispatcher may pick them up
+const notificationClaimLease = 5 * time.Minute
+
+// Sender delivers a notification over its channel (e.g. an email provider)
+type Sender interface {
+	Send(n *models.Notification) error
+}
+
+// LogSender writes notifications to the server log instead of sending them.
+// Used until a real email provider is configured.
+type LogSender struct{}
+
+// Send logs the notification
+func (LogSender) Send(n *models.Notification) error {
+	log.Printf("📧 [%s] to=%s subject=%q\n%s", n.Type, n.Recipient, n.Subject, n.Body)
+	return nil
+}
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	sender           Sender
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository, sender Sender) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		sender:           sender,
+	}
+}
+
+// QueueEmail adds an email to the outgoing queue; it is sent by the dispatcher
+func (s *NotificationService) QueueEmail(userID *int, notificationType, recipient, subject, body string) (*models.Notification, error) {
+	n := &models.Notification{
+		UserID:    userID,
+		Channel:   "email",
+		Type:      notificationType,
+		Recipient: recipient,
+		Subject:   subject,
+		Body:      body,
+	}
+	if err := s.notificationRepo.Create(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// SendEmail sends an email right away without queueing it. For messages carrying
+// secrets (e.g. password reset links) that shouldn't be stored in the queue.
+func (s *NotificationService) SendEmail(notificationType, recipient, subject, body string) error {
+	return s.sender.Send(&models.Notification{
+		Channel:   "email",
+		Type:      notificationType,
+		Recipient: recipient,
+		Subject:   subject,
+		Body:      body,
+	})
+}
+
+// DispatchPending sends queued notifications and returns how many were sent
+func (s *NotificationService) DispatchPending() (int, error) {
+	pending, err := s.notificationRepo.ClaimPending(100, notificationClaimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range pending {
+		n := &pending[i]
+		if err := s.sender.Send(n); err != nil {
+			log.Printf("Failed to send notification %d: %v", n.ID, err)
+			if err := s.notificationRepo.MarkAttemptFailed(n.ID, err.Error(), maxNotificationAttempts); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err := s.notificationRepo.MarkSent(n.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Run dispatches queued notifications every interval (blocks; start in a goroutine)
+func (s *NotificationService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.DispatchPending(); err != nil {
+			log.Printf("Notification dispatch failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// oidcCacheTTL is how long a provider's discovery document and keys are reused
+	oidcCacheTTL = 24 * time.Hour
+
+	// oidcKeysRefreshWait is the least time between key refetches for unknown
+	// key IDs, so forged tokens can't make us hammer the provider
+	oidcKeysRefreshWait = time.Minute
+
+	// oidcMaxResponse bounds what is read from a provider
+	oidcMaxResponse = 1 << 20
+)
+
+// OIDCProviderConfig describes an OpenID Connect sign-in provider
+type OIDCProviderConfig struct {
+	Name         string // Used in URLs and stored with linked identities
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       string
+	ResponseMode string // "query" or "form_post"
+	RedirectURL  string
+}
+
+// OIDCProvider signs users in through an OpenID Connect provider with the
+// authorization code flow and PKCE. Endpoints come from the issuer's discovery
+// document; ID tokens are checked against its published keys (JWKS).
+type OIDCProvider struct {
+	config OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	discoveredAt  time.Time
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// oidcDiscovery is the part of /.well-known/openid-configuration we use
+type oidcDiscovery struct {
+	Issuer                   string   `json:"issuer"`
+	AuthorizationEndpoint    string   `json:"authorization_endpoint"`
+	TokenEndpoint            string   `json:"token_endpoint"`
+	JWKSURI                  string   `json:"jwks_uri"`
+	TokenEndpointAuthMethods []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// oidcClaims are the ID token claims used to find or create the user
+type oidcClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified oidcBool `json:"email_verified"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Name          string   `json:"name"`
+	Nonce         string   `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// oidcBool accepts both true and "true"; Apple sends booleans as strings
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = oidcBool(s == "true")
+	return nil
+}
+
+func NewOIDCProvider(config OIDCProviderConfig) *OIDCProvider {
+	return &OIDCProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the provider's configured name
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// AuthorizationURL returns where to send the browser to sign in. The state is
+// echoed back to the redirect URL, the nonce ends up in the ID token and the
+// code verifier's S256 challenge binds the code to whoever holds the verifier.
+func (p *OIDCProvider) AuthorizationURL(state, nonce, codeVerifier string) (string, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {p.config.Scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if p.config.ResponseMode != "query" {
+		params.Set("response_mode", p.config.ResponseMode)
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for the user's verified ID token claims
+func (p *OIDCProvider) Exchange(code, codeVerifier, nonce string) (*oidcClaims, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	// client_secret_basic is the default unless the provider only takes the secret in the body
+	basicAuth := p.config.ClientSecret != "" && !onlyClientSecretPost(d.TokenEndpointAuthMethods)
+	if !basicAuth {
+		form.Set("client_id", p.config.ClientID)
+		if p.config.ClientSecret != "" {
+			form.Set("client_secret", p.config.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || token.IDToken == "" {
+		return nil, fmt.Errorf("%s token endpoint returned %d: %s %s", p.config.Name, status, token.Error, token.ErrorDescription)
+	}
+
+	return p.verifyIDToken(token.IDToken, d.Issuer, nonce)
+}
+
+// verifyIDToken checks an ID token's signature, issuer, audience, expiry and nonce
+func (p *OIDCProvider) verifyIDToken(raw, issuer, nonce string) (*oidcClaims, error) {
+	claims := &oidcClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.getKey(kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s ID token: %w", p.config.Name, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%s ID token has no subject", p.config.Name)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%s ID token nonce doesn't match", p.config.Name)
+	}
+	return claims, nil
+}
+
+// getDiscovery returns the issuer's (cached) discovery document
+func (p *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discoveredAt) < oidcCacheTTL {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(p.config.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	d := &oidcDiscovery{}
+	status, err := p.do(req, d)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%s discovery returned %d", p.config.Name, status)
+	}
+	// The document must be about the issuer we trust (OpenID Connect Discovery 4.3)
+	if d.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("%s discovery is for issuer %q, expected %q", p.config.Name, d.Issuer, p.config.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%s discovery is missing endpoints", p.config.Name)
+	}
+
+	p.discovery = d
+	p.discoveredAt = time.Now()
+	return d, nil
+}
+
+// getKey returns the provider's signing key with an ID, refetching the key set
+// when it is stale or (at most once a minute) doesn't have the key
+func (p *OIDCProvider) getKey(kid string) (*rsa.PublicKey, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.lookupKey(kid)
+	stale := time.Since(p.keysFetchedAt) >= oidcCacheTTL
+	if ok && !stale {
+		return key, nil
+	}
+	if !stale && time.Since(p.keysFetchedAt) < oidcKeysRefreshWait {
+		return nil, fmt.Errorf("unknown %s signing key %q", p.config.Name, kid)
+	}
+
+	keys, err := p.fetchKeys(d.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown %s signing key %q", p.config.Name, kid)
+}
+
+// lookupKey finds a cached key; a token without a key ID can only use a lone key
+func (p *OIDCProvider) lookupKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys downloads the provider's RSA signing keys
+func (p *OIDCProvider) fetchKeys(jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequest(http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%s JWKS returned %d", p.config.Name, status)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s JWKS has no RSA signing keys", p.config.Name)
+	}
+	return keys, nil
+}
+
+// do sends a request to the provider and decodes its JSON response
+func (p *OIDCProvider) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxResponse))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// onlyClientSecretPost reports whether a provider's token endpoint takes the
+// client secret only in the request body
+func onlyClientSecretPost(methods []string) bool {
+	if len(methods) == 0 {
+		return false
+	}
+	for _, m := range methods {
+		if m == "client_secret_basic" {
+			return false
+		}
+	}
+	for _, m := range methods {
+		if m == "client_secret_post" {
+			return true
+		}
+	}
+	return false
+}
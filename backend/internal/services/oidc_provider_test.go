@@ -0,0 +1,421 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "shop-client"
+	testClientSecret = "shop-secret"
+	testKeyID        = "key-1"
+	testNonce        = "nonce-123"
+	testVerifier     = "verifier-abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// signingKey returns an RSA key shared by the tests (generating one is slow)
+func signingKey(t *testing.T) *rsa.PrivateKey {
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		testKey = key
+	})
+	return testKey
+}
+
+// mockOIDC is an OpenID Connect provider serving discovery, its key set and a
+// token endpoint that returns idToken
+type mockOIDC struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu         sync.Mutex
+	issuer     string // Issuer in the discovery document; the server URL by default
+	idToken    string
+	tokenForm  url.Values
+	basicUser  string
+	basicPass  string
+	jwksServed int
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	m := &mockOIDC{key: signingKey(t)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		issuer := m.issuer
+		m.mu.Unlock()
+		if issuer == "" {
+			issuer = m.URL
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 issuer,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.jwksServed++
+		m.mu.Unlock()
+		pub := m.key.PublicKey
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"use": "sig",
+				"kid": testKeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.tokenForm = r.PostForm
+		m.basicUser, m.basicPass, _ = r.BasicAuth()
+		json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken})
+	})
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// provider returns a provider for the mock with an empty cache
+func (m *mockOIDC) provider() *OIDCProvider {
+	return NewOIDCProvider(OIDCProviderConfig{
+		Name:         "mock",
+		Issuer:       m.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		Scopes:       "openid email profile",
+		ResponseMode: "query",
+		RedirectURL:  "https://shop.example/oidc/callback/mock",
+	})
+}
+
+// claims returns valid ID token claims for the mock
+func (m *mockOIDC) claims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            testClientID,
+		"sub":            "user-42",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          testNonce,
+		"email":          "Jane@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+}
+
+// sign signs claims with the mock's key under a key ID and serves them as the ID token
+func (m *mockOIDC) sign(t *testing.T, claims jwt.MapClaims, kid string) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatalf("sign ID token: %v", err)
+	}
+	m.mu.Lock()
+	m.idToken = signed
+	m.mu.Unlock()
+}
+
+func TestOIDCAuthorizationURL(t *testing.T) {
+	m := newMockOIDC(t)
+
+	raw, err := m.provider().AuthorizationURL("state-1", testNonce, testVerifier)
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != m.URL+"/authorize" {
+		t.Errorf("endpoint = %q, want %q", got, m.URL+"/authorize")
+	}
+
+	challenge := sha256.Sum256([]byte(testVerifier))
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "https://shop.example/oidc/callback/mock",
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 testNonce,
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+	}
+	q := u.Query()
+	for name, value := range want {
+		if q.Get(name) != value {
+			t.Errorf("%s = %q, want %q", name, q.Get(name), value)
+		}
+	}
+	if q.Has("response_mode") {
+		t.Errorf("response_mode = %q, want none for query responses", q.Get("response_mode"))
+	}
+	if strings.Contains(raw, testVerifier) {
+		t.Error("authorization URL contains the code verifier")
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	m := newMockOIDC(t)
+	m.sign(t, m.claims(), testKeyID)
+
+	claims, err := m.provider().Exchange("code-1", testVerifier, testNonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if claims.Subject != "user-42" || claims.Email != "Jane@Example.com" || !bool(claims.EmailVerified) {
+		t.Errorf("claims = %+v", claims)
+	}
+	if claims.GivenName != "Jane" || claims.FamilyName != "Doe" {
+		t.Errorf("name = %q %q, want Jane Doe", claims.GivenName, claims.FamilyName)
+	}
+
+	// The PKCE verifier and code go to the token endpoint; the secret goes in basic auth
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if got := m.tokenForm.Get("code_verifier"); got != testVerifier {
+		t.Errorf("code_verifier = %q, want %q", got, testVerifier)
+	}
+	if got := m.tokenForm.Get("code"); got != "code-1" {
+		t.Errorf("code = %q, want code-1", got)
+	}
+	if got := m.tokenForm.Get("grant_type"); got != "authorization_code" {
+		t.Errorf("grant_type = %q, want authorization_code", got)
+	}
+	if m.basicUser != testClientID || m.basicPass != testClientSecret {
+		t.Errorf("basic auth = %q:%q, want the client credentials", m.basicUser, m.basicPass)
+	}
+	if m.tokenForm.Has("client_secret") {
+		t.Error("client secret sent in the body as well as basic auth")
+	}
+}
+
+func TestOIDCExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(c jwt.MapClaims)
+		kid   string
+		nonce string
+	}{
+		{
+			name: "wrong issuer",
+			edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		},
+		{
+			name: "wrong audience",
+			edit: func(c jwt.MapClaims) { c["aud"] = "another-client" },
+		},
+		{
+			name: "expired",
+			edit: func(c jwt.MapClaims) {
+				c["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+		},
+		{
+			name: "no expiry",
+			edit: func(c jwt.MapClaims) { delete(c, "exp") },
+		},
+		{
+			name: "issued in the future",
+			edit: func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+		},
+		{
+			name:  "nonce mismatch",
+			nonce: "another-nonce",
+		},
+		{
+			name: "missing nonce",
+			edit: func(c jwt.MapClaims) { delete(c, "nonce") },
+		},
+		{
+			name: "no subject",
+			edit: func(c jwt.MapClaims) { delete(c, "sub") },
+		},
+		{
+			name: "unknown key ID",
+			kid:  "key-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockOIDC(t)
+			claims := m.claims()
+			if tt.edit != nil {
+				tt.edit(claims)
+			}
+			kid := tt.kid
+			if kid == "" {
+				kid = testKeyID
+			}
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = testNonce
+			}
+			m.sign(t, claims, kid)
+
+			if claims, err := m.provider().Exchange("code-1", testVerifier, nonce); err == nil {
+				t.Errorf("Exchange accepted the ID token: %+v", claims)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeRejectsForeignSignature(t *testing.T) {
+	m := newMockOIDC(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims())
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(other)
+	if err != nil {
+		t.Fatalf("sign ID token: %v", err)
+	}
+	m.idToken = signed
+
+	if _, err := m.provider().Exchange("code-1", testVerifier, testNonce); err == nil {
+		t.Error("Exchange accepted an ID token signed with another key")
+	}
+}
+
+func TestOIDCExchangeRejectsHMAC(t *testing.T) {
+	m := newMockOIDC(t)
+
+	// A token "signed" with the public key as an HMAC secret must not pass
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, m.claims())
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(m.key.PublicKey.N.Bytes())
+	if err != nil {
+		t.Fatalf("sign ID token: %v", err)
+	}
+	m.idToken = signed
+
+	if _, err := m.provider().Exchange("code-1", testVerifier, testNonce); err == nil {
+		t.Error("Exchange accepted an HS256 ID token")
+	}
+}
+
+func TestOIDCUnknownKeyRefetchIsLimited(t *testing.T) {
+	m := newMockOIDC(t)
+	p := m.provider()
+
+	m.sign(t, m.claims(), testKeyID)
+	if _, err := p.Exchange("code-1", testVerifier, testNonce); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	// Tokens with unknown keys right after a fetch don't make us fetch again
+	m.sign(t, m.claims(), "key-2")
+	for i := 0; i < 3; i++ {
+		if _, err := p.Exchange("code-1", testVerifier, testNonce); err == nil {
+			t.Fatal("Exchange accepted an ID token with an unknown key ID")
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jwksServed != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", m.jwksServed)
+	}
+}
+
+func TestOIDCEmailVerifiedAsString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{true, true},
+		{"true", true},
+		{false, false},
+		{"false", false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		m := newMockOIDC(t)
+		claims := m.claims()
+		if tt.value == nil {
+			delete(claims, "email_verified")
+		} else {
+			claims["email_verified"] = tt.value
+		}
+		m.sign(t, claims, testKeyID)
+
+		got, err := m.provider().Exchange("code-1", testVerifier, testNonce)
+		if err != nil {
+			t.Fatalf("email_verified %#v: Exchange: %v", tt.value, err)
+		}
+		if bool(got.EmailVerified) != tt.want {
+			t.Errorf("email_verified %#v = %v, want %v", tt.value, got.EmailVerified, tt.want)
+		}
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	m := newMockOIDC(t)
+	m.issuer = "https://evil.example"
+
+	if _, err := m.provider().AuthorizationURL("state-1", testNonce, testVerifier); err == nil {
+		t.Error("AuthorizationURL accepted discovery for another issuer")
+	}
+}
+
+func TestOnlyClientSecretPost(t *testing.T) {
+	tests := []struct {
+		methods []string
+		want    bool
+	}{
+		{nil, false},
+		{[]string{"client_secret_basic"}, false},
+		{[]string{"client_secret_post"}, true},
+		{[]string{"client_secret_post", "client_secret_basic"}, false},
+		{[]string{"private_key_jwt"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := onlyClientSecretPost(tt.methods); got != tt.want {
+			t.Errorf("onlyClientSecretPost(%v) = %v, want %v", tt.methods, got, tt.want)
+		}
+	}
+}
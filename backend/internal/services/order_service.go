@@ -1,22 +1,49 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
 	"ecommerce-backend/internal/repository"
+	"ecommerce-backend/internal/utils"
+	"log"
+	"strings"
 )
 
 type OrderService struct {
 	orderRepo   *repository.OrderRepository
 	cartRepo    *repository.CartRepository
 	productRepo *repository.ProductRepository
+	jwtSecret   string
+
+	couponService        *CouponService
+	promotionService     *PromotionService
+	saleService          *SaleService
+	taxService           *TaxService
+	shippingService      *ShippingService
+	currencyService      *CurrencyService
+	giftCardService      *GiftCardService
+	loyaltyService       *LoyaltyService
+	abandonedCartService *AbandonedCartService
 }
 
-func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository) *OrderService {
+func NewOrderService(orderRepo *repository.OrderRepository, cartRepo *repository.CartRepository, productRepo *repository.ProductRepository, couponService *CouponService, promotionService *PromotionService, saleService *SaleService, taxService *TaxService, shippingService *ShippingService, currencyService *CurrencyService, giftCardService *GiftCardService, loyaltyService *LoyaltyService, abandonedCartService *AbandonedCartService, jwtSecret string) *OrderService {
 	return &OrderService{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
+		orderRepo:            orderRepo,
+		cartRepo:             cartRepo,
+		productRepo:          productRepo,
+		jwtSecret:            jwtSecret,
+		couponService:        couponService,
+		promotionService:     promotionService,
+		saleService:          saleService,
+		taxService:           taxService,
+		shippingService:      shippingService,
+		currencyService:      currencyService,
+		giftCardService:      giftCardService,
+		loyaltyService:       loyaltyService,
+		abandonedCartService: abandonedCartService,
 	}
 }
 
@@ -26,7 +53,7 @@ func (s *OrderService) CreateOrder(userID int, req *models.CreateOrderRequest) (
 	if req.AddressID == 0 {
 		return nil, errors.New("address is required")
 	}
-	if req.PaymentMethod == "" {
+	if req.PaymentMethod == "" && len(req.GiftCardCodes) == 0 && !req.UseStoreCredit && req.LoyaltyPoints == 0 {
 		return nil, errors.New("payment method is required")
 	}
 
@@ -39,8 +66,130 @@ func (s *OrderService) CreateOrder(userID int, req *models.CreateOrderRequest) (
 		return nil, errors.New("address not found")
 	}
 
+	order := &models.Order{
+		UserID:         userID,
+		PaymentMethod:  req.PaymentMethod,
+		ShippingMethod: req.ShippingMethod,
+		Currency:       req.Currency,
+		Notes:          req.Notes,
+	}
+	setShippingAddress(order, address)
+
+	order, err = s.placeOrder(models.CartOwner{UserID: userID}, order, tenderOptions{
+		giftCardCodes:  req.GiftCardCodes,
+		useStoreCredit: req.UseStoreCredit,
+		loyaltyPoints:  req.LoyaltyPoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Count the order as a recovery if the user got an abandoned cart email recently
+	abandonedCartID, err := s.abandonedCartService.AttributeOrder(userID, order.ID)
+	if err != nil {
+		log.Printf("Failed to attribute order %d to abandoned cart: %v", order.ID, err)
+	} else if abandonedCartID != 0 {
+		order.AbandonedCartID = &abandonedCartID
+	}
+
+	return order, nil
+}
+
+// CreateGuestOrder creates an order from a guest cart with an inline shipping address.
+// The returned order carries a signed lookup token the guest can use to view it later.
+func (s *OrderService) CreateGuestOrder(owner models.CartOwner, req *models.GuestCheckoutRequest) (*models.Order, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, errors.New("valid email is required")
+	}
+	if req.PaymentMethod == "" && len(req.GiftCardCodes) == 0 {
+		return nil, errors.New("payment method is required")
+	}
+	addr := req.Address
+	if addr.FullName == "" || addr.AddressLine1 == "" || addr.City == "" || addr.Country == "" ||
+		addr.PostalCode == "" || addr.Phone == "" {
+		return nil, errors.New("required address fields missing")
+	}
+
+	order := &models.Order{
+		GuestEmail:     email,
+		PaymentMethod:  req.PaymentMethod,
+		ShippingMethod: req.ShippingMethod,
+		Currency:       req.Currency,
+		Notes:          req.Notes,
+	}
+	setShippingAddress(order, &models.Address{
+		FullName:     addr.FullName,
+		Phone:        addr.Phone,
+		AddressLine1: addr.AddressLine1,
+		AddressLine2: addr.AddressLine2,
+		City:         addr.City,
+		State:        addr.State,
+		PostalCode:   addr.PostalCode,
+		Country:      addr.Country,
+	})
+
+	order, err := s.placeOrder(owner, order, tenderOptions{giftCardCodes: req.GiftCardCodes})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateOrderLookupToken(order.ID, email, s.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	order.LookupToken = token
+
+	return order, nil
+}
+
+// tenderOptions are what the customer chose to pay with before the payment method
+type tenderOptions struct {
+	giftCardCodes  []string
+	useStoreCredit bool
+	loyaltyPoints  int
+}
+
+// used reports whether any balance is used
+func (o tenderOptions) used() bool {
+	return len(o.giftCardCodes) > 0 || o.useStoreCredit || o.loyaltyPoints > 0
+}
+
+// checkBalanceCurrency refuses loyalty points, gift cards and store credit for
+// orders outside the base currency. The balances are kept in the base currency,
+// and taking them off a converted total would mix currencies (which panics).
+func checkBalanceCurrency(options tenderOptions, converter *Converter) error {
+	if options.used() && !converter.IsBase() {
+		return errors.New("loyalty points, gift cards and store credit can only be used for orders in " + money.DefaultCurrency)
+	}
+	return nil
+}
+
+// saleReservation is a number of units taken from a sale for an order
+type saleReservation struct {
+	sale     *models.SalePrice
+	sku      string
+	quantity int
+}
+
+// setShippingAddress copies an address onto the order's shipping snapshot
+func setShippingAddress(order *models.Order, address *models.Address) {
+	order.ShippingAddressLine1 = address.AddressLine1
+	order.ShippingAddressLine2 = address.AddressLine2
+	order.ShippingCity = address.City
+	order.ShippingState = address.State
+	order.ShippingPostalCode = address.PostalCode
+	order.ShippingCountry = address.Country
+	order.ShippingFullName = address.FullName
+	order.ShippingPhone = address.Phone
+}
+
+// placeOrder turns the owner's cart into the given order, reduces stock and clears the cart.
+// Loyalty points, gift cards and then store credit pay what they can; the order's
+// payment method pays the rest.
+func (s *OrderService) placeOrder(owner models.CartOwner, order *models.Order, options tenderOptions) (*models.Order, error) {
 	// Get cart
-	cartItems, err := s.cartRepo.GetUserCart(userID)
+	cartItems, err := s.cartRepo.GetCart(owner)
 	if err != nil {
 		return nil, err
 	}
@@ -49,9 +198,13 @@ func (s *OrderService) CreateOrder(userID int, req *models.CreateOrderRequest) (
 	}
 
 	// Calculate totals and validate stock
-	subtotal := 0.0
+	var subtotal money.Money
 	orderItems := []models.OrderItem{}
-	
+	lines := []discountLine{}
+	sales := []saleReservation{}
+	taxRates := []float64{}
+	shippingItems := []shippingItem{}
+
 	for _, cartItem := range cartItems {
 		// Get variant
 		variant, err := s.cartRepo.GetVariantByID(cartItem.ProductVariantID)
@@ -70,10 +223,26 @@ func (s *OrderService) CreateOrder(userID int, req *models.CreateOrderRequest) (
 			continue
 		}
 
-		// Calculate price
-		unitPrice := product.BasePrice + variant.PriceAdjustment
-		totalPrice := unitPrice * float64(cartItem.Quantity)
-		subtotal += totalPrice
+		// Calculate price (sale price if one applies to the whole line)
+		sale, err := s.saleService.PriceVariant(product, variant, cartItem.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := variant.FinalPrice
+
+		// Don't charge a price the customer hasn't seen
+		if cartItem.AddedUnitPrice != nil && !cartItem.AddedUnitPrice.Equal(unitPrice) {
+			return nil, errors.New("price changed for " + variant.SKU + ", please review your cart")
+		}
+		totalPrice := unitPrice.Mul(cartItem.Quantity)
+		subtotal = subtotal.Add(totalPrice)
+
+		// KDV rate for the product's category at the destination
+		taxRate, err := s.taxService.Rate(order.ShippingCountry, product.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		taxRates = append(taxRates, taxRate)
 
 		// Create order item
 		orderItems = append(orderItems, models.OrderItem{
@@ -85,59 +254,356 @@ func (s *OrderService) CreateOrder(userID int, req *models.CreateOrderRequest) (
 			Quantity:         cartItem.Quantity,
 			UnitPrice:        unitPrice,
 			TotalPrice:       totalPrice,
+			SalePriceID:      variant.SalePriceID,
 		})
+		lines = append(lines, newDiscountLine(product, variant, cartItem.Quantity, unitPrice))
+		shippingItems = append(shippingItems, shippingItem{VariantID: variant.ID, Quantity: cartItem.Quantity})
+		if sale != nil {
+			sales = append(sales, saleReservation{sale: sale, sku: variant.SKU, quantity: cartItem.Quantity})
+		}
+	}
+
+	// The shipping method must deliver to the address; it is priced after the discounts
+	if len(shippingItems) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+	shipping, err := s.shippingService.choose(order.ShippingMethod, order.ShippingCountry, order.ShippingCity, shippingItems)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prices are worked out in the base currency and converted at the current rate
+	converter, err := s.currencyService.Converter(order.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkBalanceCurrency(options, converter); err != nil {
+		return nil, err
+	}
+
+	// Take the units from their sales; flash sale limits are enforced atomically
+	reserved := []saleReservation{}
+	releaseSales := func() {
+		for _, r := range reserved {
+			if err := s.saleService.Release(r.sale.ID, r.quantity); err != nil {
+				log.Printf("Failed to release %d unit(s) of sale %d: %v", r.quantity, r.sale.ID, err)
+			}
+		}
+	}
+	for _, r := range sales {
+		if err := s.saleService.Reserve(r.sale, r.quantity); err != nil {
+			releaseSales()
+			return nil, errors.New("the sale price for " + r.sku + " is no longer available, please review your cart")
+		}
+		reserved = append(reserved, r)
+	}
+
+	// Automatic promotions first, then the coupon on the discounted prices
+	promotions, err := s.promotionService.Apply(lines)
+	if err != nil {
+		releaseSales()
+		return nil, err
+	}
+	var discount money.Money
+	for _, promotion := range promotions {
+		discount = discount.Add(promotion.Discount)
+	}
+
+	// Redeem the cart's coupon; this re-checks its limits with the coupon locked
+	coupon, err := s.couponService.GetCartCoupon(owner)
+	if err != nil {
+		releaseSales()
+		return nil, err
+	}
+	redemptionID := 0
+	var couponDiscount money.Money
+	if coupon != nil {
+		redemptionID, couponDiscount, err = s.couponService.Redeem(coupon.ID, lines, order.UserID, order.GuestEmail)
+		if err != nil {
+			releaseSales()
+			return nil, errors.New("coupon " + coupon.Code + " can't be used: " + err.Error())
+		}
+		discount = discount.Add(couponDiscount)
+		for i, amount := range allocateCoupon(coupon, lines, couponDiscount) {
+			lines[i].Discount = lines[i].Discount.Add(amount)
+		}
+	}
+
+	// Each order line keeps its share of the discounts so refunds can be pro-rated,
+	// and is taxed on what is left after them
+	var tax money.Money
+	for i := range orderItems {
+		orderItems[i].DiscountAmount = lines[i].Discount
+		orderItems[i].TaxRate = taxRates[i]
+		orderItems[i].TaxAmount = s.taxService.Tax(lines[i].total(), taxRates[i])
+		tax = tax.Add(orderItems[i].TaxAmount)
 	}
 
 	// Create order
-	order := &models.Order{
-		UserID:               userID,
-		OrderNumber:          s.orderRepo.GenerateOrderNumber(),
-		ShippingAddressLine1: address.AddressLine1,
-		ShippingAddressLine2: address.AddressLine2,
-		ShippingCity:         address.City,
-		ShippingState:        address.State,
-		ShippingPostalCode:   address.PostalCode,
-		ShippingCountry:      address.Country,
-		ShippingFullName:     address.FullName,
-		ShippingPhone:        address.Phone,
-		Subtotal:             subtotal,
-		ShippingCost:         0.00, // Free shipping
-		Tax:                  0.00, // No tax for now
-		Total:                subtotal,
-		Status:               "pending",
-		PaymentMethod:        req.PaymentMethod,
-		PaymentStatus:        "pending",
-		Notes:                req.Notes,
+	order.OrderNumber = s.orderRepo.GenerateOrderNumber()
+	order.Subtotal = subtotal
+	order.Discount = discount
+	order.ShippingMethod = shipping.method.Code
+	order.ShippingCost = shipping.quote(subtotal.Sub(discount)).Price
+	order.Tax = tax
+	order.PricesIncludeTax = s.taxService.PricesIncludeTax()
+
+	discountLines := []*money.Money{}
+	for i := range promotions {
+		discountLines = append(discountLines, &promotions[i].Discount)
+	}
+	if coupon != nil {
+		discountLines = append(discountLines, &couponDiscount)
+	}
+	convertOrder(converter, order, orderItems, discountLines)
+
+	order.Total = order.Subtotal.Sub(order.Discount).Add(order.ShippingCost)
+	if !order.PricesIncludeTax {
+		order.Total = order.Total.Add(order.Tax)
 	}
+	order.Status = "pending"
+	order.PaymentStatus = "pending"
 
-	orderID, err := s.orderRepo.CreateOrder(order)
+	releaseCoupon := func() {
+		if redemptionID != 0 {
+			if releaseErr := s.couponService.ReleaseRedemption(redemptionID); releaseErr != nil {
+				log.Printf("Failed to release coupon redemption %d: %v", redemptionID, releaseErr)
+			}
+		}
+	}
+
+	// Take the points, gift card and store credit balances; this locks them so
+	// two checkouts can't spend the same balance
+	var points *tender
+	tenders := []tender{}
+	releaseTenders := func() {
+		s.loyaltyService.release(points)
+		s.giftCardService.release(tenders)
+	}
+	if options.loyaltyPoints > 0 {
+		points, err = s.loyaltyService.redeem(order.UserID, options.loyaltyPoints, order.Total)
+		if err != nil {
+			releaseSales()
+			releaseCoupon()
+			return nil, err
+		}
+	}
+	remaining := order.Total
+	if points != nil {
+		remaining = remaining.Sub(points.amount)
+	}
+	if len(options.giftCardCodes) > 0 || options.useStoreCredit {
+		tenders, err = s.giftCardService.redeem(options.giftCardCodes, order.UserID, options.useStoreCredit, remaining)
+		if err != nil {
+			releaseSales()
+			releaseCoupon()
+			releaseTenders()
+			return nil, err
+		}
+	}
+	used := tenders
+	if points != nil {
+		used = append([]tender{*points}, tenders...)
+	}
+	for _, t := range tenders {
+		remaining = remaining.Sub(t.amount)
+	}
+
+	if remaining.IsZero() && len(used) > 0 {
+		// Fully paid without a charge
+		order.Status = "confirmed"
+		order.PaymentStatus = "paid"
+		if order.PaymentMethod == "" {
+			order.PaymentMethod = used[0].method
+		}
+	} else if order.PaymentMethod == "" {
+		releaseSales()
+		releaseCoupon()
+		releaseTenders()
+		return nil, errors.New("payment method is required for the remaining " + remaining.String() + " " + order.Currency)
+	}
+
+	// The order, its payments, discounts and items and the stock are written in
+	// one transaction. If any of it fails nothing is kept, and the sale units,
+	// coupon redemption and balances taken above are given back.
+	tx, err := s.orderRepo.BeginTx()
 	if err != nil {
+		releaseSales()
+		releaseCoupon()
+		releaseTenders()
+		return nil, err
+	}
+	defer tx.Rollback()
+	fail := func(err error) (*models.Order, error) {
+		// Roll back first; the releases need the rows the transaction locked
+		tx.Rollback()
+		releaseSales()
+		releaseCoupon()
+		releaseTenders()
 		return nil, err
 	}
 
+	orderID, err := s.orderRepo.CreateOrder(tx, order)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Record the points, gift cards and store credit as captured payments, and
+	// what is left as pending on the payment method
+	if err := s.loyaltyService.attach(tx, points, orderID); err != nil {
+		return fail(err)
+	}
+	if err := s.giftCardService.attach(tx, tenders, orderID); err != nil {
+		return fail(err)
+	}
+	payments := []models.OrderPayment{}
+	for _, t := range used {
+		payment := models.OrderPayment{
+			Method:     t.method,
+			Amount:     t.amount,
+			Status:     models.OrderPaymentCaptured,
+			GiftCardID: t.giftCardID,
+		}
+		if t.code != "" {
+			payment.GiftCardCode = t.code[len(t.code)-4:]
+		}
+		payments = append(payments, payment)
+	}
+	if !remaining.IsZero() || len(used) == 0 {
+		payments = append(payments, models.OrderPayment{
+			Method: order.PaymentMethod,
+			Amount: remaining,
+			Status: models.OrderPaymentPending,
+		})
+	}
+	for i := range payments {
+		payments[i].OrderID = orderID
+		if err := s.orderRepo.CreateOrderPayment(tx, &payments[i]); err != nil {
+			return fail(err)
+		}
+	}
+	order.Payments = payments
+
+	// Record promotions and the coupon as discount lines of the order
+	orderDiscounts := []models.OrderDiscount{}
+	for _, promotion := range promotions {
+		promotionID := promotion.PromotionID
+		orderDiscounts = append(orderDiscounts, models.OrderDiscount{
+			Type:        models.DiscountTypePromotion,
+			PromotionID: &promotionID,
+			Description: promotion.Name,
+			Amount:      promotion.Discount,
+		})
+	}
+	if coupon != nil {
+		if err := s.couponService.AttachRedemption(tx, redemptionID, orderID); err != nil {
+			return fail(err)
+		}
+		orderDiscounts = append(orderDiscounts, models.OrderDiscount{
+			Type:        models.DiscountTypeCoupon,
+			Code:        coupon.Code,
+			Description: coupon.Description,
+			Amount:      couponDiscount,
+		})
+	}
+	for i := range orderDiscounts {
+		orderDiscounts[i].OrderID = orderID
+		if err := s.orderRepo.CreateOrderDiscount(tx, &orderDiscounts[i]); err != nil {
+			return fail(err)
+		}
+	}
+	order.Discounts = orderDiscounts
+
 	// Create order items and reduce stock
 	for i := range orderItems {
 		orderItems[i].OrderID = orderID
-		err := s.orderRepo.CreateOrderItem(&orderItems[i])
+		err := s.orderRepo.CreateOrderItem(tx, &orderItems[i])
 		if err != nil {
-			return nil, err
+			return fail(err)
 		}
 		
 		// Reduce stock for this variant
-		err = s.productRepo.ReduceStock(orderItems[i].ProductVariantID, orderItems[i].Quantity)
+		err = s.productRepo.ReduceStock(tx, orderItems[i].ProductVariantID, orderItems[i].Quantity)
 		if err != nil {
-			return nil, err
+			return fail(err)
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
 	order.Items = orderItems
 
-	// Clear cart
-	s.cartRepo.ClearCart(userID)
+	// Clear cart and its coupon
+	s.cartRepo.Clear(owner)
+	s.couponService.RemoveCartCoupon(owner)
 
 	return order, nil
 }
 
+// convertOrder converts a priced order into the checkout currency. Unit prices are
+// converted and multiplied out as in the cart; the discount and tax are converted
+// as totals and shared over the lines and discount lines so they still add up.
+func convertOrder(c *Converter, order *models.Order, items []models.OrderItem, discountLines []*money.Money) {
+	order.Currency = c.Currency
+	order.ExchangeRate = c.Rate
+	if c.IsBase() {
+		return
+	}
+
+	subtotal := money.New(0, c.Currency)
+	itemDiscounts := []*money.Money{}
+	itemTaxes := []*money.Money{}
+	for i := range items {
+		items[i].UnitPrice = c.Money(items[i].UnitPrice)
+		items[i].TotalPrice = items[i].UnitPrice.Mul(items[i].Quantity)
+		subtotal = subtotal.Add(items[i].TotalPrice)
+		itemDiscounts = append(itemDiscounts, &items[i].DiscountAmount)
+		itemTaxes = append(itemTaxes, &items[i].TaxAmount)
+	}
+
+	order.Subtotal = subtotal
+	order.Discount = c.Money(order.Discount)
+	reallocate(order.Discount, itemDiscounts)
+	reallocate(order.Discount, discountLines)
+	order.Tax = c.Money(order.Tax)
+	reallocate(order.Tax, itemTaxes)
+	order.ShippingCost = c.Money(order.ShippingCost)
+}
+
+// LookupGuestOrder returns the guest order referenced by a signed lookup token
+func (s *OrderService) LookupGuestOrder(token string) (*models.Order, error) {
+	claims, err := utils.ValidateOrderLookupToken(token, s.jwtSecret)
+	if err != nil {
+		return nil, errors.New("invalid or expired order link")
+	}
+	return s.orderRepo.GetGuestOrderByID(claims.OrderID, claims.Email)
+}
+
+// LookupGuestOrderByNumber returns a guest order by order number and checkout email
+func (s *OrderService) LookupGuestOrderByNumber(req *models.OrderLookupRequest) (*models.Order, error) {
+	if req.OrderNumber == "" || req.Email == "" {
+		return nil, errors.New("order number and email are required")
+	}
+	return s.orderRepo.GetGuestOrderByNumber(strings.TrimSpace(req.OrderNumber), strings.TrimSpace(req.Email))
+}
+
+// ClaimGuestOrders attaches all guest orders placed with the account's email.
+// The lookup token proves the caller received the guest order confirmation,
+// so orders can't be claimed just by registering someone else's email.
+func (s *OrderService) ClaimGuestOrders(token string, userID int, userEmail string) (int64, error) {
+	claims, err := utils.ValidateOrderLookupToken(token, s.jwtSecret)
+	if err != nil {
+		return 0, errors.New("invalid or expired order link")
+	}
+	if !strings.EqualFold(claims.Email, userEmail) {
+		return 0, errors.New("order email does not match account email")
+	}
+	return s.orderRepo.AttachGuestOrders(claims.Email, userID)
+}
+
 // GetUserOrders retrieves all orders for a user
 func (s *OrderService) GetUserOrders(userID int) ([]models.Order, error) {
 	return s.orderRepo.GetUserOrders(userID)
@@ -187,7 +653,292 @@ func (s *OrderService) GetAllOrders() ([]models.Order, error) {
 	return s.orderRepo.GetAllOrders()
 }
 
-// UpdateOrderStatus updates order status (admin only)
+// UpdateOrderStatus updates order status (admin only). Customers earn loyalty
+// points when their order is delivered and lose them if it is cancelled. A
+// cancelled order gives back its points, gift card and store credit payments in
+// the same transaction as the status change.
 func (s *OrderService) UpdateOrderStatus(orderID int, status string) error {
-	return s.orderRepo.UpdateOrderStatus(orderID, status)
-}
\ No newline at end of file
+	order, err := s.orderRepo.GetOrder(orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return errors.New("order not found")
+	}
+
+	tx, err := s.orderRepo.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locked so a cancellation and a refund can't give the same payment back twice
+	current, _, err := s.orderRepo.LockOrder(tx, orderID)
+	if err != nil {
+		return err
+	}
+	if err := s.orderRepo.UpdateOrderStatus(tx, orderID, status); err != nil {
+		return err
+	}
+	if status == "cancelled" && current != "cancelled" {
+		if err := s.returnBalances(tx, order); err != nil {
+			return err
+		}
+		if order.UserID != 0 {
+			if err := s.loyaltyService.cancel(tx, order); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if status == "delivered" && current != status && order.UserID != 0 {
+		return s.loyaltyService.earn(order)
+	}
+	return nil
+}
+
+// GetOrder retrieves any order with items (admin only)
+func (s *OrderService) GetOrder(orderID int) (*models.Order, error) {
+	return s.orderRepo.GetOrder(orderID)
+}
+
+// RefundOrder refunds units of an order's lines (admin only). Each line gives back
+// its share of the price after discounts and the tax charged on it; the last units
+// of a line take whatever is left so the refunds add up to exactly what was paid.
+func (s *OrderService) RefundOrder(orderID, adminID int, apiKeyID *int, req *models.RefundRequest) (*models.OrderRefund, error) {
+	if len(req.Items) == 0 {
+		return nil, errors.New("select at least one item to refund")
+	}
+
+	order, err := s.orderRepo.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, errors.New("order not found")
+	}
+	items := map[int]models.OrderItem{}
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	tx, err := s.orderRepo.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, paymentStatus, err := s.orderRepo.LockOrder(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	// Only what was paid can be given back
+	if paymentStatus != "paid" && paymentStatus != "partially_refunded" {
+		return nil, errors.New("only paid orders can be refunded")
+	}
+	refunded, err := s.orderRepo.GetRefundedItems(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &models.OrderRefund{
+		OrderID:  orderID,
+		Reason:   strings.TrimSpace(req.Reason),
+		Method:   models.RefundToOriginal,
+		Items:    []models.OrderRefundItem{},
+		Payments: []models.OrderRefundPayment{},
+	}
+	if req.ToStoreCredit {
+		if order.UserID == 0 {
+			return nil, errors.New("guest orders can't be refunded to store credit")
+		}
+		refund.Method = models.RefundToStoreCredit
+	}
+	if adminID != 0 {
+		refund.CreatedBy = &adminID
+	}
+	refund.APIKeyID = apiKeyID
+
+	for _, r := range req.Items {
+		item, ok := items[r.OrderItemID]
+		if !ok {
+			return nil, errors.New("item is not part of this order")
+		}
+		done := refunded[item.ID]
+		remaining := item.Quantity - done.Quantity
+		if r.Quantity <= 0 || r.Quantity > remaining {
+			return nil, fmt.Errorf("can refund at most %d of %s", remaining, item.ProductSKU)
+		}
+
+		// What the customer paid for the line; tax is on top for tax-exclusive orders
+		paid := item.TotalPrice.Sub(item.DiscountAmount)
+		if !order.PricesIncludeTax {
+			paid = paid.Add(item.TaxAmount)
+		}
+
+		line := models.OrderRefundItem{OrderItemID: item.ID, Quantity: r.Quantity}
+		if r.Quantity == remaining {
+			line.Amount = paid.Sub(done.Amount)
+			line.TaxAmount = item.TaxAmount.Sub(done.TaxAmount)
+		} else {
+			// item.Quantity > 0 (order_items CHECK), so the ratio can't divide by zero
+			line.Amount = paid.Ratio(int64(r.Quantity), int64(item.Quantity))
+			line.TaxAmount = item.TaxAmount.Ratio(int64(r.Quantity), int64(item.Quantity))
+		}
+
+		done.Quantity += r.Quantity
+		done.Amount = done.Amount.Add(line.Amount)
+		done.TaxAmount = done.TaxAmount.Add(line.TaxAmount)
+		refunded[item.ID] = done
+
+		refund.Amount = refund.Amount.Add(line.Amount)
+		refund.Tax = refund.Tax.Add(line.TaxAmount)
+		refund.Items = append(refund.Items, line)
+	}
+
+	// Never give back more than the order's payments captured
+	payments, err := s.orderRepo.GetCapturedPayments(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var refundable money.Money
+	for _, p := range payments {
+		refundable = refundable.Add(p.Amount.Sub(p.Refunded))
+	}
+	if refund.Amount.GreaterThan(refundable) {
+		return nil, fmt.Errorf("can refund at most %s %s, the rest of what was captured", refundable.Max(money.Money{}), order.Currency)
+	}
+
+	if err := s.orderRepo.CreateRefund(tx, refund); err != nil {
+		return nil, err
+	}
+
+	// Give the money back through the payments that took it
+	for i, amount := range splitRefund(refund.Amount, payments) {
+		if !amount.GreaterThan(money.Money{}) {
+			continue
+		}
+		part, err := s.givePaymentBack(tx, order, payments[i], amount, refund)
+		if err != nil {
+			return nil, err
+		}
+		refund.Payments = append(refund.Payments, *part)
+	}
+
+	// Take back the loyalty points earned on what was refunded
+	if err := s.loyaltyService.reverseRefund(tx, order, refund); err != nil {
+		return nil, err
+	}
+
+	status := "refunded"
+	for _, item := range order.Items {
+		if refunded[item.ID].Quantity < item.Quantity {
+			status = "partially_refunded"
+			break
+		}
+	}
+	if err := s.orderRepo.SetPaymentStatus(tx, orderID, status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// splitRefund shares a refund over an order's captured payments in proportion
+// to what each has left to give back, so none gives back more than it took
+func splitRefund(amount money.Money, payments []models.OrderPayment) []money.Money {
+	weights := make([]int64, len(payments))
+	for i, p := range payments {
+		weights[i] = p.Amount.Sub(p.Refunded).Amount
+	}
+	return amount.Allocate(weights)
+}
+
+// returnBalances gives back what is left of the loyalty points, gift card and
+// store credit payments of a cancelled order, inside the cancellation's transaction.
+// Card payments are given back with a refund.
+func (s *OrderService) returnBalances(tx *sql.Tx, order *models.Order) error {
+	payments, err := s.orderRepo.GetCapturedPayments(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		switch payment.Method {
+		case models.PaymentMethodLoyaltyPoints, models.PaymentMethodGiftCard, models.PaymentMethodStoreCredit:
+		default:
+			continue
+		}
+		left := payment.Amount.Sub(payment.Refunded)
+		if !left.GreaterThan(money.Money{}) {
+			continue
+		}
+		if _, err := s.givePaymentBack(tx, order, payment, left, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// givePaymentBack gives amount back through one of the order's payments, for a
+// refund or, when refund is nil, a cancellation: as points for points, onto the
+// gift card or store credit that paid it, or to store credit for store credit
+// refunds. Card payments are recorded as pending, to be refunded through the
+// payment provider.
+func (s *OrderService) givePaymentBack(tx *sql.Tx, order *models.Order, payment models.OrderPayment, amount money.Money, refund *models.OrderRefund) (*models.OrderRefundPayment, error) {
+	part := &models.OrderRefundPayment{
+		OrderPaymentID: payment.ID,
+		Amount:         amount,
+		Method:         payment.Method,
+		Status:         models.RefundPaymentCompleted,
+	}
+	orderID := order.ID
+	credit := &models.StoreCreditTransaction{
+		UserID:  order.UserID,
+		Reason:  "Order " + order.OrderNumber + " cancelled",
+		OrderID: &orderID,
+	}
+	var refundID *int
+	toStoreCredit := payment.Method == models.PaymentMethodStoreCredit
+	if refund != nil {
+		refundID = &refund.ID
+		part.RefundID = refund.ID
+		credit.Reason = "Refund for order " + order.OrderNumber
+		credit.RefundID = refundID
+		credit.CreatedBy = refund.CreatedBy
+		credit.APIKeyID = refund.APIKeyID
+		toStoreCredit = toStoreCredit || refund.Method == models.RefundToStoreCredit
+	}
+
+	switch {
+	case payment.Method == models.PaymentMethodLoyaltyPoints:
+		// Points go back as points, even for store credit refunds
+		if err := s.loyaltyService.returnSpent(tx, order, payment, amount, refundID, credit.Reason); err != nil {
+			return nil, err
+		}
+	case toStoreCredit:
+		// Store credit is kept in the base currency, at the rate the order was charged
+		charged := &Converter{Currency: order.Currency, Rate: order.ExchangeRate}
+		credit.Amount = charged.ToBase(amount)
+		if err := s.giftCardService.creditStoreCredit(tx, credit); err != nil {
+			return nil, err
+		}
+		part.Method = models.PaymentMethodStoreCredit
+	case payment.Method == models.PaymentMethodGiftCard && payment.GiftCardID != nil:
+		if err := s.giftCardService.creditGiftCard(tx, *payment.GiftCardID, orderID, amount, credit.Reason); err != nil {
+			return nil, err
+		}
+	default:
+		part.Status = models.RefundPaymentPending
+	}
+
+	if err := s.orderRepo.CreateRefundPayment(tx, part); err != nil {
+		return nil, err
+	}
+	return part, nil
+}
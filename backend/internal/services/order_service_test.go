@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+)
+
+func TestCheckBalanceCurrency(t *testing.T) {
+	base := &Converter{Currency: money.DefaultCurrency, Rate: 1}
+	eur := &Converter{Currency: "EUR", Rate: 0.0285}
+
+	tests := []struct {
+		name      string
+		options   tenderOptions
+		converter *Converter
+		wantErr   bool
+	}{
+		{"no balances in base currency", tenderOptions{}, base, false},
+		{"no balances in other currency", tenderOptions{}, eur, false},
+		{"points in base currency", tenderOptions{loyaltyPoints: 100}, base, false},
+		{"gift card in base currency", tenderOptions{giftCardCodes: []string{"ABCD"}}, base, false},
+		{"points in other currency", tenderOptions{loyaltyPoints: 100}, eur, true},
+		{"gift card in other currency", tenderOptions{giftCardCodes: []string{"ABCD"}}, eur, true},
+		{"store credit in other currency", tenderOptions{useStoreCredit: true}, eur, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBalanceCurrency(tt.options, tt.converter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkBalanceCurrency() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A converted order total minus a base currency balance is what the check
+// above keeps from happening
+func TestBalanceOnConvertedTotalPanics(t *testing.T) {
+	total := (&Converter{Currency: "EUR", Rate: 0.0285}).Money(money.FromMinor(100000))
+	defer func() {
+		if recover() == nil {
+			t.Error("subtracting a base currency balance from an EUR total didn't panic")
+		}
+	}()
+	total.Sub(money.FromMinor(500).In(money.DefaultCurrency))
+}
+
+func TestTaxOnRateBounds(t *testing.T) {
+	tests := []struct {
+		amount    int64
+		rate      float64
+		inclusive bool
+		want      int64
+	}{
+		{11800, 18, true, 1800},
+		{10000, 18, false, 1800},
+		{10000, 0, true, 0},
+		{10000, 0, false, 0},
+		{10000, 100, true, 5000},
+		{10000, 100, false, 10000},
+		{-11800, 18, true, -1800}, // Refunds
+	}
+
+	for _, tt := range tests {
+		if got := taxOn(money.FromMinor(tt.amount), tt.rate, tt.inclusive).Amount; got != tt.want {
+			t.Errorf("taxOn(%d, %v, %v) = %d, want %d", tt.amount, tt.rate, tt.inclusive, got, tt.want)
+		}
+	}
+}
+
+func TestSplitRefund(t *testing.T) {
+	payment := func(amount, refunded int64) models.OrderPayment {
+		return models.OrderPayment{Amount: money.FromMinor(amount), Refunded: money.FromMinor(refunded)}
+	}
+
+	tests := []struct {
+		name     string
+		amount   int64
+		payments []models.OrderPayment
+		want     []int64
+	}{
+		{"single payment", 2500, []models.OrderPayment{payment(10000, 0)}, []int64{2500}},
+		{"in proportion to each payment", 5000, []models.OrderPayment{payment(2000, 0), payment(8000, 0)}, []int64{1000, 4000}},
+		{"full refund gives back each payment", 10000, []models.OrderPayment{payment(2999, 0), payment(7001, 0)}, []int64{2999, 7001}},
+		{"rounding still adds up", 200, []models.OrderPayment{payment(100, 0), payment(100, 0), payment(100, 0)}, []int64{67, 67, 66}},
+		{"what was given back is left out", 3000, []models.OrderPayment{payment(2000, 2000), payment(8000, 5000)}, []int64{0, 3000}},
+		{"rest of a partly refunded order", 4000, []models.OrderPayment{payment(2000, 1000), payment(8000, 5000)}, []int64{1000, 3000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitRefund(money.FromMinor(tt.amount), tt.payments)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.want))
+			}
+			for i, part := range parts {
+				if part.Amount != tt.want[i] {
+					t.Errorf("part %d = %d, want %d", i, part.Amount, tt.want[i])
+				}
+				if left := tt.payments[i].Amount.Sub(tt.payments[i].Refunded); part.GreaterThan(left) {
+					t.Errorf("part %d = %d is more than the %d left on its payment", i, part.Amount, left.Amount)
+				}
+			}
+		})
+	}
+}
@@ -7,10 +7,14 @@ import (
 
 type ProductService struct {
 	productRepo *repository.ProductRepository
+	saleService *SaleService
 }
 
-func NewProductService(productRepo *repository.ProductRepository) *ProductService {
-	return &ProductService{productRepo: productRepo}
+func NewProductService(productRepo *repository.ProductRepository, saleService *SaleService) *ProductService {
+	return &ProductService{
+		productRepo: productRepo,
+		saleService: saleService,
+	}
 }
 
 // GetProducts returns a list of products with filters
@@ -36,6 +40,11 @@ func (s *ProductService) GetProducts(query *models.ProductListQuery) ([]models.P
 		}
 	}
 
+	// Show running sales
+	if err := s.saleService.ApplyToProducts(products); err != nil {
+		return nil, err
+	}
+
 	return products, nil
 }
 
@@ -46,10 +55,10 @@ func (s *ProductService) GetProductByID(id int) (*models.Product, error) {
 		return nil, err
 	}
 
-	// Calculate final prices for variants
-	if product != nil && len(product.Variants) > 0 {
-		for i := range product.Variants {
-			product.Variants[i].FinalPrice = product.BasePrice + product.Variants[i].PriceAdjustment
+	// Calculate final prices for variants, including running sales
+	if product != nil {
+		if err := s.saleService.ApplyToProduct(product); err != nil {
+			return nil, err
 		}
 	}
 
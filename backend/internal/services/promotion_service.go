@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+type PromotionService struct {
+	promotionRepo *repository.PromotionRepository
+}
+
+func NewPromotionService(promotionRepo *repository.PromotionRepository) *PromotionService {
+	return &PromotionService{promotionRepo: promotionRepo}
+}
+
+// GetAllPromotions returns all promotions in evaluation order (admin)
+func (s *PromotionService) GetAllPromotions() ([]models.Promotion, error) {
+	return s.promotionRepo.GetAll()
+}
+
+// GetPromotion returns a promotion by ID (admin)
+func (s *PromotionService) GetPromotion(id int) (*models.Promotion, error) {
+	return s.promotionRepo.GetByID(id)
+}
+
+// CreatePromotion creates a promotion (admin)
+func (s *PromotionService) CreatePromotion(req *models.PromotionRequest) (*models.Promotion, error) {
+	promotion, err := promotionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.promotionRepo.Create(promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion updates a promotion (admin)
+func (s *PromotionService) UpdatePromotion(id int, req *models.PromotionRequest) (*models.Promotion, error) {
+	promotion, err := promotionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	promotion.ID = id
+
+	found, err := s.promotionRepo.Update(promotion)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("promotion not found")
+	}
+	return promotion, nil
+}
+
+// DeletePromotion deletes a promotion (admin)
+func (s *PromotionService) DeletePromotion(id int) error {
+	deleted, err := s.promotionRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("promotion not found")
+	}
+	return nil
+}
+
+// promotionFromRequest validates an admin promotion request
+func promotionFromRequest(req *models.PromotionRequest) (*models.Promotion, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if req.EndsAt != nil && req.StartsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, errors.New("end must be after start")
+	}
+
+	promotion := &models.Promotion{
+		Name:         name,
+		Description:  strings.TrimSpace(req.Description),
+		Type:         req.Type,
+		BrandIDs:     req.BrandIDs,
+		CategoryIDs:  req.CategoryIDs,
+		Tiers:        []models.PromotionTier{},
+		BundleGroups: []models.BundleGroup{},
+		Priority:     req.Priority,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		IsActive:     req.IsActive == nil || *req.IsActive,
+	}
+	if promotion.BrandIDs == nil {
+		promotion.BrandIDs = []int64{}
+	}
+	if promotion.CategoryIDs == nil {
+		promotion.CategoryIDs = []int64{}
+	}
+
+	switch req.Type {
+	case models.PromotionBuyXGetY:
+		if req.BuyQuantity < 1 || req.GetQuantity < 1 {
+			return nil, errors.New("buy and get quantities must be at least 1")
+		}
+		promotion.BuyQuantity = req.BuyQuantity
+		promotion.GetQuantity = req.GetQuantity
+		promotion.Percent = req.Percent
+		if promotion.Percent == 0 {
+			promotion.Percent = 100 // Free
+		}
+
+	case models.PromotionTiered:
+		if len(req.Tiers) == 0 {
+			return nil, errors.New("at least one tier is required")
+		}
+		for _, tier := range req.Tiers {
+			if tier.MinSubtotal.IsNegative() {
+				return nil, errors.New("tier minimum subtotal cannot be negative")
+			}
+			if !validPercent(tier.Percent) {
+				return nil, errors.New("tier percent must be between 0 and 100")
+			}
+		}
+		promotion.Tiers = append(promotion.Tiers, req.Tiers...)
+		sort.Slice(promotion.Tiers, func(i, j int) bool {
+			return promotion.Tiers[i].MinSubtotal.LessThan(promotion.Tiers[j].MinSubtotal)
+		})
+
+	case models.PromotionBundle:
+		if len(req.BundleGroups) < 2 {
+			return nil, errors.New("a bundle needs at least two groups")
+		}
+		for _, group := range req.BundleGroups {
+			if group.Quantity == 0 {
+				group.Quantity = 1
+			}
+			if group.Quantity < 0 {
+				return nil, errors.New("bundle group quantity must be at least 1")
+			}
+			if len(group.BrandIDs) == 0 && len(group.CategoryIDs) == 0 {
+				return nil, errors.New("each bundle group needs brand_ids or category_ids")
+			}
+			promotion.BundleGroups = append(promotion.BundleGroups, group)
+		}
+		promotion.Percent = req.Percent
+
+	default:
+		return nil, errors.New("type must be buy_x_get_y, tiered or bundle")
+	}
+
+	if req.Type != models.PromotionTiered && !validPercent(promotion.Percent) {
+		return nil, errors.New("percent must be between 0 and 100")
+	}
+
+	return promotion, nil
+}
+
+func validPercent(p float64) bool {
+	return p > 0 && p <= 100
+}
+
+// Apply evaluates the active promotions on the lines in priority order.
+// Each line's share is added to lines[i].Discount, so later promotions and the
+// coupon see the discounted prices. Returns the promotions that gave a discount.
+func (s *PromotionService) Apply(lines []discountLine) ([]models.AppliedPromotion, error) {
+	applied := []models.AppliedPromotion{}
+	if len(lines) == 0 {
+		return applied, nil
+	}
+
+	promotions, err := s.promotionRepo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range promotions {
+		alloc := promotionDiscount(&promotions[i], lines)
+
+		var total money.Money
+		for j, amount := range alloc {
+			lines[j].Discount = lines[j].Discount.Add(amount)
+			total = total.Add(amount)
+		}
+		if !total.IsZero() {
+			applied = append(applied, models.AppliedPromotion{
+				PromotionID: promotions[i].ID,
+				Name:        promotions[i].Name,
+				Discount:    total,
+			})
+		}
+	}
+
+	return applied, nil
+}
+
+// promotionDiscount computes a promotion's discount per line,
+// never more than what is left of a line
+func promotionDiscount(promotion *models.Promotion, lines []discountLine) []money.Money {
+	alloc := make([]money.Money, len(lines))
+
+	switch promotion.Type {
+	case models.PromotionBuyXGetY:
+		// Most expensive units first; in every group of buy+get units the cheapest get units are discounted
+		units := promotionUnits(lines, func(l discountLine) bool {
+			return inScope(promotion.BrandIDs, promotion.CategoryIDs, l)
+		})
+		group := promotion.BuyQuantity + promotion.GetQuantity
+		for start := 0; start+group <= len(units); start += group {
+			for _, u := range units[start+promotion.BuyQuantity : start+group] {
+				alloc[u.line] = alloc[u.line].Add(u.price.Percent(promotion.Percent))
+			}
+		}
+
+	case models.PromotionTiered:
+		var eligible money.Money
+		for _, line := range lines {
+			if inScope(promotion.BrandIDs, promotion.CategoryIDs, line) {
+				eligible = eligible.Add(line.total())
+			}
+		}
+		percent := 0.0
+		for _, tier := range promotion.Tiers {
+			if !eligible.LessThan(tier.MinSubtotal) {
+				percent = tier.Percent
+			}
+		}
+		if percent == 0 {
+			break
+		}
+		for i, line := range lines {
+			if inScope(promotion.BrandIDs, promotion.CategoryIDs, line) {
+				alloc[i] = line.total().Percent(percent)
+			}
+		}
+
+	case models.PromotionBundle:
+		// Build complete bundles from the most expensive units; each unit counts once
+		used := map[int]int{} // line -> units already in a bundle
+		for {
+			picked := []promotionUnit{}
+			taken := map[int]int{}
+			complete := true
+			for _, group := range promotion.BundleGroups {
+				units := promotionUnits(lines, func(l discountLine) bool {
+					return inScope(promotion.BrandIDs, promotion.CategoryIDs, l) &&
+						inScope(group.BrandIDs, group.CategoryIDs, l)
+				})
+				need := group.Quantity
+				for _, u := range units {
+					if need == 0 {
+						break
+					}
+					if u.index < used[u.line]+taken[u.line] {
+						continue
+					}
+					taken[u.line]++
+					picked = append(picked, u)
+					need--
+				}
+				if need > 0 {
+					complete = false
+					break
+				}
+			}
+			if !complete {
+				break
+			}
+			for line, n := range taken {
+				used[line] += n
+			}
+			for _, u := range picked {
+				alloc[u.line] = alloc[u.line].Add(u.price.Percent(promotion.Percent))
+			}
+		}
+	}
+
+	for i := range alloc {
+		alloc[i] = alloc[i].Min(lines[i].total()).Max(money.Money{})
+	}
+	return alloc
+}
+
+// promotionUnit is a single unit of a line quantity at its current net price
+type promotionUnit struct {
+	line  int
+	index int // Position of the unit within its line
+	price money.Money
+}
+
+// promotionUnits expands matching lines into units, most expensive first
+func promotionUnits(lines []discountLine, match func(discountLine) bool) []promotionUnit {
+	units := []promotionUnit{}
+	for i, line := range lines {
+		if line.Quantity <= 0 || !match(line) {
+			continue
+		}
+		// Split the net total exactly; leftover cents go to the first units
+		weights := make([]int64, line.Quantity)
+		for n := range weights {
+			weights[n] = 1
+		}
+		for n, price := range line.total().Allocate(weights) {
+			units = append(units, promotionUnit{line: i, index: n, price: price})
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].price.GreaterThan(units[j].price)
+	})
+	return units
+}
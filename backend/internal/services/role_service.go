@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,19}$`)
+
+type RoleService struct {
+	roleRepo *repository.RoleRepository
+}
+
+func NewRoleService(roleRepo *repository.RoleRepository) *RoleService {
+	return &RoleService{roleRepo: roleRepo}
+}
+
+// GetPermissions returns every permission a role can have (admin)
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	return s.roleRepo.GetPermissions()
+}
+
+// GetRoles returns all roles with their permissions (admin)
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	return s.roleRepo.GetRoles()
+}
+
+// CreateRole adds a staff role (admin)
+func (s *RoleService) CreateRole(req *models.RoleRequest) (*models.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("name must be 2-20 lowercase letters, digits, '-' or '_', starting with a letter")
+	}
+	permissions, err := s.validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.roleRepo.GetRoleByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("role already exists")
+	}
+
+	role := &models.Role{Name: name, Description: strings.TrimSpace(req.Description), Permissions: permissions}
+	if err := s.roleRepo.CreateRole(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole changes a custom role's description and permissions (admin).
+// Its users get the new permissions on their next request.
+func (s *RoleService) UpdateRole(id int, req *models.RoleRequest) (*models.Role, error) {
+	permissions, err := s.validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, errors.New("role not found")
+	}
+	if role.BuiltIn {
+		return nil, errors.New("built-in roles can't be changed")
+	}
+
+	role.Description = strings.TrimSpace(req.Description)
+	role.Permissions = permissions
+	updated, err := s.roleRepo.UpdateRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errors.New("role not found")
+	}
+	return s.roleRepo.GetRole(id)
+}
+
+// DeleteRole deletes a custom role no user has (admin)
+func (s *RoleService) DeleteRole(id int) error {
+	deleted, err := s.roleRepo.DeleteRole(id)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return nil
+	}
+
+	role, err := s.roleRepo.GetRole(id)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+	if role.BuiltIn {
+		return errors.New("built-in roles can't be deleted")
+	}
+	return fmt.Errorf("role is assigned to %d user(s), give them another role first", role.UserCount)
+}
+
+// AssignRole gives a user a role (admin). Admins can't change their own role,
+// so nobody locks themselves out.
+func (s *RoleService) AssignRole(adminID, userID int, req *models.AssignRoleRequest) error {
+	if userID == adminID {
+		return errors.New("you can't change your own role")
+	}
+
+	role, err := s.roleRepo.GetRoleByName(strings.ToLower(strings.TrimSpace(req.Role)))
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+
+	found, err := s.roleRepo.SetUserRole(userID, role.Name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// HasPermission reports whether a user's role has a permission
+func (s *RoleService) HasPermission(userID int, permission string) (bool, error) {
+	return s.roleRepo.UserHasPermission(userID, permission)
+}
+
+// validatePermissions checks a role's permissions exist and removes duplicates
+func (s *RoleService) validatePermissions(permissions []string) ([]string, error) {
+	known, err := s.roleRepo.GetPermissions()
+	if err != nil {
+		return nil, err
+	}
+	valid := map[string]bool{}
+	for _, p := range known {
+		valid[p.Name] = true
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if !valid[p] {
+			return nil, fmt.Errorf("unknown permission %q", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
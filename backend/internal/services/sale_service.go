@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+type SaleService struct {
+	saleRepo *repository.SaleRepository
+}
+
+func NewSaleService(saleRepo *repository.SaleRepository) *SaleService {
+	return &SaleService{saleRepo: saleRepo}
+}
+
+// GetAllSales returns all sales (admin)
+func (s *SaleService) GetAllSales() ([]models.SalePrice, error) {
+	return s.saleRepo.GetAll()
+}
+
+// CreateSale schedules a sale (admin)
+func (s *SaleService) CreateSale(req *models.SalePriceRequest) (*models.SalePrice, error) {
+	sale, err := saleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saleRepo.Create(sale); err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
+
+// UpdateSale updates a sale; units already sold are kept (admin)
+func (s *SaleService) UpdateSale(id int, req *models.SalePriceRequest) (*models.SalePrice, error) {
+	sale, err := saleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	sale.ID = id
+
+	found, err := s.saleRepo.Update(sale)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("sale not found")
+	}
+	return sale, nil
+}
+
+// DeleteSale deletes a sale (admin)
+func (s *SaleService) DeleteSale(id int) error {
+	deleted, err := s.saleRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("sale not found")
+	}
+	return nil
+}
+
+// saleFromRequest validates an admin sale request
+func saleFromRequest(req *models.SalePriceRequest) (*models.SalePrice, error) {
+	if (req.ProductID == nil) == (req.ProductVariantID == nil) {
+		return nil, errors.New("set either product_id or product_variant_id")
+	}
+	if req.Price.IsNegative() {
+		return nil, errors.New("price cannot be negative")
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		return nil, errors.New("starts_at and ends_at are required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, errors.New("end must be after start")
+	}
+	if req.QuantityLimit != nil && *req.QuantityLimit <= 0 {
+		return nil, errors.New("quantity limit must be greater than 0")
+	}
+
+	return &models.SalePrice{
+		ProductID:        req.ProductID,
+		ProductVariantID: req.ProductVariantID,
+		Price:            req.Price,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		QuantityLimit:    req.QuantityLimit,
+	}, nil
+}
+
+// PriceVariant sets the variant's final price for buying quantity units,
+// along with its sale fields, and returns the sale the price comes from (nil = regular price)
+func (s *SaleService) PriceVariant(product *models.Product, variant *models.ProductVariant, quantity int) (*models.SalePrice, error) {
+	sales, err := s.saleRepo.GetActiveForProducts([]int{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	return priceVariant(product, variant, quantity, sales), nil
+}
+
+// ApplyToProducts sets sale prices on products and their loaded variants
+func (s *SaleService) ApplyToProducts(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	sales, err := s.saleRepo.GetActiveForProducts(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		product := &products[i]
+		variantIDs := map[int]bool{}
+		for j := range product.Variants {
+			variantIDs[product.Variants[j].ID] = true
+			priceVariant(product, &product.Variants[j], 1, sales)
+		}
+
+		// Lowest sale price shown on the product (e.g. in listings)
+		for _, sale := range sales {
+			onProduct := sale.ProductID != nil && *sale.ProductID == product.ID
+			onVariant := sale.ProductVariantID != nil && variantIDs[*sale.ProductVariantID]
+			if !onProduct && !onVariant {
+				continue
+			}
+			if sale.Price.LessThan(product.BasePrice) && (product.SalePrice == nil || sale.Price.LessThan(*product.SalePrice)) {
+				price, regular := sale.Price, product.BasePrice
+				product.SalePrice = &price
+				product.CompareAtPrice = &regular
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyToProduct sets sale prices on a product and its variants
+func (s *SaleService) ApplyToProduct(product *models.Product) error {
+	products := []models.Product{*product}
+	if err := s.ApplyToProducts(products); err != nil {
+		return err
+	}
+	*product = products[0]
+	return nil
+}
+
+// Reserve takes units of a sale for an order; flash sale limits hold under concurrent checkouts
+func (s *SaleService) Reserve(sale *models.SalePrice, quantity int) error {
+	ok, err := s.saleRepo.Reserve(sale.ID, quantity)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("sale has ended or sold out")
+	}
+	return nil
+}
+
+// Release gives back units reserved for an order that wasn't placed
+func (s *SaleService) Release(saleID, quantity int) error {
+	return s.saleRepo.Release(saleID, quantity)
+}
+
+// priceVariant picks the lowest running sale that covers quantity units of the variant.
+// Variant sales set the final price; product sales replace the base price.
+func priceVariant(product *models.Product, variant *models.ProductVariant, quantity int, sales []models.SalePrice) *models.SalePrice {
+	regular := product.BasePrice.Add(variant.PriceAdjustment)
+	variant.FinalPrice = regular
+	variant.CompareAtPrice = nil
+	variant.SaleEndsAt = nil
+	variant.SaleRemaining = nil
+	variant.SalePriceID = nil
+
+	var best *models.SalePrice
+	for i := range sales {
+		sale := &sales[i]
+
+		var price money.Money
+		switch {
+		case sale.ProductVariantID != nil && *sale.ProductVariantID == variant.ID:
+			price = sale.Price
+		case sale.ProductID != nil && *sale.ProductID == product.ID:
+			price = sale.Price.Add(variant.PriceAdjustment)
+		default:
+			continue
+		}
+
+		// A flash sale only applies if the whole line fits in what's left
+		if remaining := sale.Remaining(); remaining != nil && *remaining < quantity {
+			continue
+		}
+		if price.LessThan(variant.FinalPrice) {
+			variant.FinalPrice = price
+			best = sale
+		}
+	}
+
+	if best != nil {
+		variant.CompareAtPrice = &regular
+		variant.SaleEndsAt = &best.EndsAt
+		variant.SaleRemaining = best.Remaining()
+		variant.SalePriceID = &best.ID
+	}
+	return best
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+// DefaultShippingMethod is used when an order doesn't name a shipping method
+const DefaultShippingMethod = "standard"
+
+var shippingCodePattern = regexp.MustCompile(`^[a-z0-9_]{2,50}$`)
+
+// shippingItem is a variant and quantity to be shipped
+type shippingItem struct {
+	VariantID int
+	Quantity  int
+}
+
+// parcel is what a shipment contains, as far as rates are concerned
+type parcel struct {
+	Items       int
+	WeightGrams int
+}
+
+// shippingChoice is a method and the rate that applies to a destination and parcel
+type shippingChoice struct {
+	method *models.ShippingMethod
+	rate   *models.ShippingRate
+	parcel parcel
+}
+
+// quote prices the choice for a cart amount (after discounts)
+func (c *shippingChoice) quote(amount money.Money) models.ShippingQuote {
+	return quoteRate(c.method, c.rate, c.parcel, amount)
+}
+
+type ShippingService struct {
+	shippingRepo *repository.ShippingRepository
+}
+
+func NewShippingService(shippingRepo *repository.ShippingRepository) *ShippingService {
+	return &ShippingService{shippingRepo: shippingRepo}
+}
+
+// GetMethods returns all shipping methods with their rates (admin)
+func (s *ShippingService) GetMethods() ([]models.ShippingMethod, error) {
+	return s.shippingRepo.GetMethods(false)
+}
+
+// CreateMethod creates a shipping method (admin)
+func (s *ShippingService) CreateMethod(req *models.ShippingMethodRequest) (*models.ShippingMethod, error) {
+	method, err := shippingMethodFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.shippingRepo.GetMethodByCode(method.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("shipping method code already exists")
+	}
+
+	if err := s.shippingRepo.CreateMethod(method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+// UpdateMethod updates a shipping method (admin)
+func (s *ShippingService) UpdateMethod(id int, req *models.ShippingMethodRequest) (*models.ShippingMethod, error) {
+	method, err := shippingMethodFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	method.ID = id
+
+	existing, err := s.shippingRepo.GetMethodByCode(method.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != id {
+		return nil, errors.New("shipping method code already exists")
+	}
+
+	found, err := s.shippingRepo.UpdateMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("shipping method not found")
+	}
+	return method, nil
+}
+
+// CreateRate adds a rate to a shipping method (admin)
+func (s *ShippingService) CreateRate(req *models.ShippingRateRequest) (*models.ShippingRate, error) {
+	rate, err := shippingRateFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.shippingRepo.CreateRate(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// UpdateRate updates a shipping rate (admin)
+func (s *ShippingService) UpdateRate(id int, req *models.ShippingRateRequest) (*models.ShippingRate, error) {
+	rate, err := shippingRateFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	rate.ID = id
+
+	found, err := s.shippingRepo.UpdateRate(rate)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("shipping rate not found")
+	}
+	return rate, nil
+}
+
+// DeleteRate deletes a shipping rate (admin)
+func (s *ShippingService) DeleteRate(id int) error {
+	deleted, err := s.shippingRepo.DeleteRate(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("shipping rate not found")
+	}
+	return nil
+}
+
+// shippingMethodFromRequest validates an admin shipping method request
+func shippingMethodFromRequest(req *models.ShippingMethodRequest) (*models.ShippingMethod, error) {
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if !shippingCodePattern.MatchString(code) {
+		return nil, errors.New("code must be 2-50 lowercase letters, digits or '_'")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	return &models.ShippingMethod{
+		Code:        code,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		SortOrder:   req.SortOrder,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		Rates:       []models.ShippingRate{},
+	}, nil
+}
+
+// shippingRateFromRequest validates an admin shipping rate request
+func shippingRateFromRequest(req *models.ShippingRateRequest) (*models.ShippingRate, error) {
+	if req.ShippingMethodID == 0 {
+		return nil, errors.New("shipping_method_id is required")
+	}
+	country := strings.TrimSpace(req.Country)
+	city := strings.TrimSpace(req.City)
+	if city != "" && country == "" {
+		return nil, errors.New("a city rate needs a country")
+	}
+	if req.Price.IsNegative() || req.PerItemPrice.IsNegative() || req.PerKgPrice.IsNegative() {
+		return nil, errors.New("prices cannot be negative")
+	}
+	if req.FreeThreshold != nil && req.FreeThreshold.IsNegative() {
+		return nil, errors.New("free shipping threshold cannot be negative")
+	}
+	if req.MaxWeightGrams != nil && *req.MaxWeightGrams <= 0 {
+		return nil, errors.New("max weight must be greater than 0")
+	}
+	if req.MinDays < 0 || req.MaxDays < req.MinDays {
+		return nil, errors.New("delivery days must be 0 or more, with max at least min")
+	}
+
+	return &models.ShippingRate{
+		ShippingMethodID: req.ShippingMethodID,
+		Country:          country,
+		City:             city,
+		Price:            req.Price,
+		PerItemPrice:     req.PerItemPrice,
+		PerKgPrice:       req.PerKgPrice,
+		MaxWeightGrams:   req.MaxWeightGrams,
+		FreeThreshold:    req.FreeThreshold,
+		MinDays:          req.MinDays,
+		MaxDays:          req.MaxDays,
+	}, nil
+}
+
+// Quote returns the active methods that deliver to a destination, priced for the
+// items and the cart amount after discounts
+func (s *ShippingService) Quote(country, city string, items []shippingItem, amount money.Money) ([]models.ShippingQuote, error) {
+	p, err := s.parcel(items)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := s.shippingRepo.GetMethods(true)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := []models.ShippingQuote{}
+	for i := range methods {
+		rate := matchRate(methods[i].Rates, country, city)
+		if rate == nil || !fits(rate, p) {
+			continue
+		}
+		quotes = append(quotes, quoteRate(&methods[i], rate, p, amount))
+	}
+	return quotes, nil
+}
+
+// choose checks that a method delivers the items to a destination.
+// The returned choice is priced once the order's discounts are known.
+func (s *ShippingService) choose(code, country, city string, items []shippingItem) (*shippingChoice, error) {
+	if code == "" {
+		code = DefaultShippingMethod
+	}
+	p, err := s.parcel(items)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := s.shippingRepo.GetMethods(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range methods {
+		if methods[i].Code != code {
+			continue
+		}
+		rate := matchRate(methods[i].Rates, country, city)
+		if rate == nil {
+			break
+		}
+		if !fits(rate, p) {
+			return nil, errors.New("your order is too heavy for " + methods[i].Name + " shipping")
+		}
+		return &shippingChoice{method: &methods[i], rate: rate, parcel: p}, nil
+	}
+	return nil, errors.New("shipping method " + code + " is not available for this address")
+}
+
+// parcel counts the items and their total weight
+func (s *ShippingService) parcel(items []shippingItem) (parcel, error) {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.VariantID
+	}
+	weights, err := s.shippingRepo.GetVariantWeights(ids)
+	if err != nil {
+		return parcel{}, err
+	}
+
+	var p parcel
+	for _, item := range items {
+		p.Items += item.Quantity
+		p.WeightGrams += weights[item.VariantID] * item.Quantity
+	}
+	return p, nil
+}
+
+// matchRate picks the most specific rate for a destination: city, then country, then anywhere
+func matchRate(rates []models.ShippingRate, country, city string) *models.ShippingRate {
+	country, city = normalizePlace(country), normalizePlace(city)
+
+	var best *models.ShippingRate
+	bestScore := -1
+	for i := range rates {
+		rate := &rates[i]
+		score := 0
+		if rate.Country != "" {
+			if normalizePlace(rate.Country) != country {
+				continue
+			}
+			score = 1
+		}
+		if rate.City != "" {
+			if normalizePlace(rate.City) != city {
+				continue
+			}
+			score = 2
+		}
+		if score > bestScore {
+			best, bestScore = rate, score
+		}
+	}
+	return best
+}
+
+// normalizePlace compares place names case-insensitively, treating the Turkish
+// dotted and dotless I as plain i ("İstanbul" = "Istanbul" = "istanbul")
+func normalizePlace(name string) string {
+	name = strings.NewReplacer("İ", "i", "I", "i", "ı", "i").Replace(strings.TrimSpace(name))
+	return strings.ToLower(name)
+}
+
+// fits reports whether a parcel is within a rate's weight limit
+func fits(rate *models.ShippingRate, p parcel) bool {
+	return rate.MaxWeightGrams == nil || p.WeightGrams <= *rate.MaxWeightGrams
+}
+
+// quoteRate prices a rate: base price plus per item and per started kilogram,
+// or free once the amount reaches the rate's threshold
+func quoteRate(method *models.ShippingMethod, rate *models.ShippingRate, p parcel, amount money.Money) models.ShippingQuote {
+	kilograms := (p.WeightGrams + 999) / 1000
+	price := rate.Price.Add(rate.PerItemPrice.Mul(p.Items)).Add(rate.PerKgPrice.Mul(kilograms))
+
+	quote := models.ShippingQuote{
+		Code:          method.Code,
+		Name:          method.Name,
+		Description:   method.Description,
+		Price:         price,
+		FreeThreshold: rate.FreeThreshold,
+		MinDays:       rate.MinDays,
+		MaxDays:       rate.MaxDays,
+	}
+	if rate.FreeThreshold != nil {
+		if amount.LessThan(*rate.FreeThreshold) {
+			left := rate.FreeThreshold.Sub(amount)
+			quote.AmountToFree = &left
+		} else {
+			quote.Price = money.Money{}
+		}
+	}
+	quote.FreeShipping = quote.Price.IsZero()
+	return quote
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/money"
+	"ecommerce-backend/internal/repository"
+)
+
+type TaxService struct {
+	taxRepo          *repository.TaxRepository
+	pricesIncludeTax bool
+}
+
+func NewTaxService(taxRepo *repository.TaxRepository, pricesIncludeTax bool) *TaxService {
+	return &TaxService{taxRepo: taxRepo, pricesIncludeTax: pricesIncludeTax}
+}
+
+// PricesIncludeTax reports whether catalog prices already include tax
+func (s *TaxService) PricesIncludeTax() bool {
+	return s.pricesIncludeTax
+}
+
+// GetAllRates returns all tax rates (admin)
+func (s *TaxService) GetAllRates() ([]models.TaxRate, error) {
+	return s.taxRepo.GetAll()
+}
+
+// CreateRate adds a tax rate for a country or a category in it (admin)
+func (s *TaxService) CreateRate(req *models.TaxRateRequest) (*models.TaxRate, error) {
+	rate, err := taxRateFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.taxRepo.Find(rate.Country, rate.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("a rate for this country and category already exists")
+	}
+
+	if err := s.taxRepo.Create(rate); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// UpdateRate updates a tax rate (admin). Placed orders keep the rate they were charged.
+func (s *TaxService) UpdateRate(id int, req *models.TaxRateRequest) (*models.TaxRate, error) {
+	rate, err := taxRateFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	rate.ID = id
+
+	existing, err := s.taxRepo.Find(rate.Country, rate.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != id {
+		return nil, errors.New("a rate for this country and category already exists")
+	}
+
+	found, err := s.taxRepo.Update(rate)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("tax rate not found")
+	}
+	return rate, nil
+}
+
+// DeleteRate deletes a tax rate (admin)
+func (s *TaxService) DeleteRate(id int) error {
+	deleted, err := s.taxRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("tax rate not found")
+	}
+	return nil
+}
+
+// taxRateFromRequest validates an admin tax rate request
+func taxRateFromRequest(req *models.TaxRateRequest) (*models.TaxRate, error) {
+	country := strings.TrimSpace(req.Country)
+	if country == "" {
+		return nil, errors.New("country is required")
+	}
+	if req.Rate < 0 || req.Rate > 100 {
+		return nil, errors.New("rate must be between 0 and 100")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "KDV"
+	}
+
+	return &models.TaxRate{
+		Country:    country,
+		CategoryID: req.CategoryID,
+		Rate:       math.Round(req.Rate*100) / 100,
+		Name:       name,
+	}, nil
+}
+
+// Rate returns the percent rate for a category shipped to country.
+// Countries without rates are not taxed.
+func (s *TaxService) Rate(country string, categoryID *int) (float64, error) {
+	rate, err := s.taxRepo.GetRate(strings.TrimSpace(country), categoryID)
+	if err != nil || rate == nil {
+		return 0, err
+	}
+	return rate.Rate, nil
+}
+
+// Tax computes the tax on an amount (after discounts) at a percent rate. For
+// tax-inclusive prices it is the tax part of the amount, otherwise the tax to add on top.
+func (s *TaxService) Tax(amount money.Money, rate float64) money.Money {
+	return taxOn(amount, rate, s.pricesIncludeTax)
+}
+
+// taxOn computes tax with rounding half away from zero to the minor unit.
+// Rates are 0 to 100 (checked on save and by tax_rates CHECK), so the
+// denominators below are never zero.
+func taxOn(amount money.Money, rate float64, inclusive bool) money.Money {
+	basisPoints := int64(math.Round(rate * 100))
+	if inclusive {
+		return amount.Ratio(basisPoints, 10000+basisPoints)
+	}
+	return amount.Ratio(basisPoints, 10000)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"ecommerce-backend/internal/models"
+	"ecommerce-backend/internal/repository"
+	"ecommerce-backend/internal/utils"
+)
+
+// recoveryCodeCount is how many recovery codes a user gets at a time
+const recoveryCodeCount = 10
+
+// TwoFactorService manages TOTP two-factor authentication: enrolment, recovery
+// codes and checking codes. Optional for customers, required for staff (users
+// whose role has any admin permission).
+type TwoFactorService struct {
+	userRepo      *repository.UserRepository
+	sessionRepo   *repository.SessionRepository
+	loginThrottle *LoginThrottleService
+	issuer        string // Account name prefix shown in authenticator apps
+}
+
+func NewTwoFactorService(userRepo *repository.UserRepository, sessionRepo *repository.SessionRepository, loginThrottle *LoginThrottleService, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		loginThrottle: loginThrottle,
+		issuer:        issuer,
+	}
+}
+
+// Status reports whether a user has two-factor authentication and whether
+// their current session passed it
+func (s *TwoFactorService) Status(userID, sessionID int) (*models.TwoFactorStatus, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{Enabled: user.TwoFactorEnabled, Required: len(user.Permissions) > 0}
+	if user.TwoFactorEnabled {
+		if status.RecoveryCodesLeft, err = s.userRepo.CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	if _, status.SessionVerified, err = s.sessionRepo.IsActive(sessionID, userID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Setup starts enrolment with a new secret for the user's authenticator app.
+// Two-factor authentication is turned on once Enable confirms a code from it.
+func (s *TwoFactorService) Setup(userID int) (*models.TwoFactorSetup, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.userRepo.SetPendingTOTPSecret(userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	return &models.TwoFactorSetup{Secret: secret, URI: utils.TOTPURI(s.issuer, user.Email, secret)}, nil
+}
+
+// Enable turns on two-factor authentication once the user proves their app
+// works with a code, and returns their recovery codes. The session it's done
+// in counts as having passed the second factor.
+func (s *TwoFactorService) Enable(userID, sessionID int, req *models.TwoFactorCodeRequest, client models.ClientInfo) (*models.RecoveryCodes, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if err := s.confirm(user, req.Code, client); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.EnableTOTP(userID, hashes); err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.MarkTwoFactor(sessionID); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication (not allowed for staff)
+func (s *TwoFactorService) Disable(userID int, req *models.TwoFactorConfirmRequest, client models.ClientInfo) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if len(user.Permissions) > 0 {
+		return errors.New("two-factor authentication is required for staff accounts")
+	}
+
+	if err := s.confirmWithPassword(user, req, client); err != nil {
+		return err
+	}
+	return s.userRepo.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, e.g. after using some
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int, req *models.TwoFactorConfirmRequest, client models.ClientInfo) (*models.RecoveryCodes, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := s.confirmWithPassword(user, req, client); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *TwoFactorService) getUser(userID int) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// confirmWithPassword checks the user's password and a code
+func (s *TwoFactorService) confirmWithPassword(user *models.User, req *models.TwoFactorConfirmRequest, client models.ClientInfo) error {
+	if err := s.loginThrottle.Check(user.Email, client.IPAddress); err != nil {
+		return err
+	}
+	if !user.HasPassword {
+		return errors.New("set a password first (use \"Forgot password\" to get a link)")
+	}
+	if !utils.CheckPassword(req.Password, user.PasswordHash) {
+		s.loginThrottle.Fail(user.Email, &user.ID, client, models.LoginFailureWrongPassword)
+		return errors.New("incorrect password")
+	}
+	return s.confirm(user, req.Code, client)
+}
+
+// confirm checks a code from the user's authenticator app or, once enabled,
+// one of their recovery codes. Each code works once. Wrong codes count as
+// failed logins, so guessing is throttled like passwords.
+func (s *TwoFactorService) confirm(user *models.User, code string, client models.ClientInfo) error {
+	if strings.TrimSpace(code) == "" {
+		return errors.New("code is required")
+	}
+	if err := s.loginThrottle.Check(user.Email, client.IPAddress); err != nil {
+		return err
+	}
+
+	ok, err := s.checkCode(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.loginThrottle.Fail(user.Email, &user.ID, client, models.LoginFailureWrongCode)
+		return errors.New("invalid two-factor code")
+	}
+	return nil
+}
+
+func (s *TwoFactorService) checkCode(user *models.User, code string) (bool, error) {
+	secret, err := s.userRepo.GetTOTPSecret(user.ID)
+	if err != nil || secret == "" {
+		return false, err
+	}
+
+	if step, ok := utils.ValidateTOTP(secret, code, time.Now()); ok {
+		return s.userRepo.UseTOTPStep(user.ID, step)
+	}
+	if !user.TwoFactorEnabled {
+		return false, nil
+	}
+	return s.userRepo.UseRecoveryCode(user.ID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+}
+
+// newRecoveryCodes generates a set of recovery codes and their hashes for storing
+func newRecoveryCodes() (*models.RecoveryCodes, []string, error) {
+	codes := &models.RecoveryCodes{Codes: make([]string, recoveryCodeCount)}
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes.Codes {
+		code, err := utils.NewRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes.Codes[i] = code
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
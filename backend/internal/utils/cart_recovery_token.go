@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// CartRecoveryTokenTTL is how long an abandoned cart recovery link stays valid
+const CartRecoveryTokenTTL = 14 * 24 * time.Hour
+
+const cartRecoverySubject = "cart_recovery"
+
+type CartRecoveryClaims struct {
+	AbandonedCartID int `json:"abandoned_cart_id"`
+	UserID          int `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateCartRecoveryToken signs the deep link sent in an abandoned cart email
+func GenerateCartRecoveryToken(abandonedCartID, userID int, secret string) (string, error) {
+	claims := CartRecoveryClaims{
+		AbandonedCartID: abandonedCartID,
+		UserID:          userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   cartRecoverySubject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(CartRecoveryTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(DeriveKey(secret, cartRecoverySubject))
+}
+
+// ValidateCartRecoveryToken validates a recovery link token and returns its claims
+func ValidateCartRecoveryToken(tokenString, secret string) (*CartRecoveryClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CartRecoveryClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return DeriveKey(secret, cartRecoverySubject), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject(cartRecoverySubject))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*CartRecoveryClaims); ok && token.Valid && claims.AbandonedCartID > 0 {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid cart recovery token")
+}
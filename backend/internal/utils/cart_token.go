@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// CartTokenTTL is how long an anonymous cart token stays valid
+const CartTokenTTL = 30 * 24 * time.Hour
+
+const cartTokenSubject = "guest_cart"
+
+type CartTokenClaims struct {
+	GuestID string `json:"guest_id"`
+	jwt.RegisteredClaims
+}
+
+// NewGuestID generates a random identifier for an anonymous cart
+func NewGuestID() (string, error) {
+	return RandomHex(16)
+}
+
+// RandomHex returns n random bytes encoded as hex
+func RandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DeriveKey derives a purpose-specific signing key from the app secret,
+// so a token minted for one purpose can never pass as another (e.g. an access token)
+func DeriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// GenerateCartToken signs a guest cart ID so clients can't guess other carts
+func GenerateCartToken(guestID, secret string) (string, error) {
+	claims := CartTokenClaims{
+		GuestID: guestID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   cartTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(CartTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(DeriveKey(secret, cartTokenSubject))
+}
+
+// ValidateCartToken validates a cart token and returns the guest cart ID
+func ValidateCartToken(tokenString, secret string) (string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CartTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return DeriveKey(secret, cartTokenSubject), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject(cartTokenSubject))
+
+	if err != nil {
+		return "", err
+	}
+
+	if claims, ok := token.Claims.(*CartTokenClaims); ok && token.Valid && claims.GuestID != "" {
+		return claims.GuestID, nil
+	}
+
+	return "", errors.New("invalid cart token")
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const oidcLoginTokenSubject = "oidc_login"
+
+// OIDCLoginClaims carry the secrets of a social sign-in in progress, so the
+// callback can be checked without storing anything server-side
+type OIDCLoginClaims struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"` // PKCE
+	jwt.RegisteredClaims
+}
+
+// GenerateOIDCLoginToken issues the token a client keeps while the user signs
+// in at the provider. It is signed with its own key so it can never pass as
+// another kind of token.
+func GenerateOIDCLoginToken(provider, state, nonce, codeVerifier, secret string, ttl time.Duration) (string, error) {
+	claims := OIDCLoginClaims{
+		Provider:     provider,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   oidcLoginTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(DeriveKey(secret, oidcLoginTokenSubject))
+}
+
+// ValidateOIDCLoginToken validates an OIDC login token and returns its claims
+func ValidateOIDCLoginToken(tokenString, secret string) (*OIDCLoginClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &OIDCLoginClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return DeriveKey(secret, oidcLoginTokenSubject), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject(oidcLoginTokenSubject))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*OIDCLoginClaims); ok && token.Valid && claims.State != "" {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid OIDC login token")
+}
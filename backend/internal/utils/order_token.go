@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OrderLookupTokenTTL is how long a guest order lookup link stays valid
+const OrderLookupTokenTTL = 90 * 24 * time.Hour
+
+const orderLookupSubject = "order_lookup"
+
+type OrderLookupClaims struct {
+	OrderID int    `json:"order_id"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateOrderLookupToken signs a link that lets a guest view their order
+func GenerateOrderLookupToken(orderID int, email, secret string) (string, error) {
+	claims := OrderLookupClaims{
+		OrderID: orderID,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   orderLookupSubject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(OrderLookupTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(DeriveKey(secret, orderLookupSubject))
+}
+
+// ValidateOrderLookupToken validates an order lookup token and returns its claims
+func ValidateOrderLookupToken(tokenString, secret string) (*OrderLookupClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &OrderLookupClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return DeriveKey(secret, orderLookupSubject), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject(orderLookupSubject))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*OrderLookupClaims); ok && token.Valid && claims.OrderID > 0 {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid order lookup token")
+}
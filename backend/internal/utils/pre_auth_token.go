@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const preAuthTokenSubject = "two_factor"
+
+type PreAuthClaims struct {
+	UserID int `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePreAuthToken issues the short-lived token a user who passed the
+// password step exchanges, with a two-factor code, for a session. It is
+// signed with its own key so it can never pass as an access token.
+func GeneratePreAuthToken(userID int, secret string, ttl time.Duration) (string, error) {
+	claims := PreAuthClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   preAuthTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(DeriveKey(secret, preAuthTokenSubject))
+}
+
+// ValidatePreAuthToken validates a pre-auth token and returns its user ID
+func ValidatePreAuthToken(tokenString, secret string) (int, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &PreAuthClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return DeriveKey(secret, preAuthTokenSubject), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithSubject(preAuthTokenSubject))
+
+	if err != nil {
+		return 0, err
+	}
+
+	if claims, ok := token.Claims.(*PreAuthClaims); ok && token.Valid && claims.UserID != 0 {
+		return claims.UserID, nil
+	}
+
+	return 0, errors.New("invalid pre-auth token")
+}
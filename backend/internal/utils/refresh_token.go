@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// NewRefreshToken generates an opaque random refresh token
+func NewRefreshToken() (string, error) {
+	return RandomHex(32)
+}
+
+// HashToken returns the SHA-256 of a random token, for storing it without the token itself.
+// Random tokens have enough entropy that a fast hash is safe (unlike passwords).
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,7 +2,10 @@ package utils
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Response is the standard API response structure
@@ -37,3 +40,14 @@ func Error(w http.ResponseWriter, statusCode int, message string) {
 		Error:   message,
 	})
 }
+
+// TooManyRequests sends a 429 error response telling the client how long to
+// wait (in whole seconds, rounded up) before retrying
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	Error(w, http.StatusTooManyRequests, message)
+}
@@ -0,0 +1,2 @@
+-- Drop stock_audit_log table
+DROP TABLE IF EXISTS stock_audit_log CASCADE;
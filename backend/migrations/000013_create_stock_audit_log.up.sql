@@ -0,0 +1,21 @@
+-- Create stock_audit_log table (records every stock/price change made by bulk syncs)
+CREATE TABLE stock_audit_log (
+    id SERIAL PRIMARY KEY,
+    product_variant_id INTEGER REFERENCES product_variants(id) ON DELETE SET NULL,
+    sku VARCHAR(100) NOT NULL,
+    
+    old_stock_quantity INTEGER NOT NULL,
+    new_stock_quantity INTEGER NOT NULL,
+    old_price_adjustment DECIMAL(10, 2) NOT NULL,
+    new_price_adjustment DECIMAL(10, 2) NOT NULL,
+    
+    -- Where the change came from: api, cli
+    source VARCHAR(50) NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for faster audit queries
+CREATE INDEX idx_stock_audit_variant ON stock_audit_log(product_variant_id);
+CREATE INDEX idx_stock_audit_created ON stock_audit_log(created_at);
@@ -0,0 +1,6 @@
+-- Remove guest carts
+DELETE FROM cart WHERE user_id IS NULL;
+DROP INDEX IF EXISTS idx_cart_guest;
+ALTER TABLE cart DROP CONSTRAINT IF EXISTS cart_guest_variant_unique;
+ALTER TABLE cart DROP CONSTRAINT IF EXISTS cart_owner_check;
+ALTER TABLE cart DROP COLUMN IF EXISTS guest_id;
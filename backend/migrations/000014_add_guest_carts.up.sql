@@ -0,0 +1,11 @@
+-- Allow anonymous (guest) carts identified by a signed cart token
+ALTER TABLE cart ADD COLUMN IF NOT EXISTS guest_id VARCHAR(64);
+
+-- Each cart row belongs to either a user or a guest
+ALTER TABLE cart ADD CONSTRAINT cart_owner_check CHECK (user_id IS NOT NULL OR guest_id IS NOT NULL);
+
+-- Ensure guest can only have one entry per variant
+ALTER TABLE cart ADD CONSTRAINT cart_guest_variant_unique UNIQUE (guest_id, product_variant_id);
+
+-- Create index for faster guest cart queries
+CREATE INDEX IF NOT EXISTS idx_cart_guest ON cart(guest_id);
@@ -0,0 +1,6 @@
+-- Remove guest orders support
+DROP INDEX IF EXISTS idx_orders_guest_email;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_owner_check;
+DROP TRIGGER IF EXISTS users_keep_order_owner_email ON users;
+DROP FUNCTION IF EXISTS keep_order_owner_email();
+ALTER TABLE orders DROP COLUMN IF EXISTS guest_email;
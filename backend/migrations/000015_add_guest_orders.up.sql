@@ -0,0 +1,25 @@
+-- Allow orders placed without an account (guest checkout)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS guest_email VARCHAR(255);
+
+-- Orders whose user was already deleted (user_id set NULL) keep no owner;
+-- there is no email left to give them, so they are attributed to a placeholder
+UPDATE orders SET guest_email = 'deleted-user@invalid' WHERE user_id IS NULL AND guest_email IS NULL;
+
+-- Orders of a user being deleted keep the user's email, so they still have an
+-- owner once orders.user_id is set NULL
+CREATE OR REPLACE FUNCTION keep_order_owner_email() RETURNS trigger AS $$
+BEGIN
+    UPDATE orders SET guest_email = OLD.email WHERE user_id = OLD.id AND guest_email IS NULL;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_keep_order_owner_email
+    BEFORE DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION keep_order_owner_email();
+
+-- Each order belongs to either a user or a guest email
+ALTER TABLE orders ADD CONSTRAINT orders_owner_check CHECK (user_id IS NOT NULL OR guest_email IS NOT NULL);
+
+-- Create index for order lookup by email
+CREATE INDEX IF NOT EXISTS idx_orders_guest_email ON orders(guest_email);
@@ -0,0 +1,2 @@
+-- Remove cart price snapshot
+ALTER TABLE cart DROP COLUMN IF EXISTS unit_price;
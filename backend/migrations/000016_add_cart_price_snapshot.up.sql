@@ -0,0 +1,4 @@
+-- Remember the unit price a cart line was added at, to detect price changes
+ALTER TABLE cart ADD COLUMN IF NOT EXISTS unit_price DECIMAL(10, 2);
+
+COMMENT ON COLUMN cart.unit_price IS 'Unit price (base_price + price_adjustment) when the item was added';
@@ -0,0 +1,3 @@
+-- Remove saved-for-later lines and column
+DELETE FROM cart WHERE saved_for_later = true;
+ALTER TABLE cart DROP COLUMN IF EXISTS saved_for_later;
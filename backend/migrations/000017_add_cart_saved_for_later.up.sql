@@ -0,0 +1,4 @@
+-- Cart lines can be moved to a "saved for later" list
+ALTER TABLE cart ADD COLUMN IF NOT EXISTS saved_for_later BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN cart.saved_for_later IS 'Saved lines do not count toward cart totals or checkout';
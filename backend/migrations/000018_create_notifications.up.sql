@@ -0,0 +1,22 @@
+-- Create notifications table (outgoing message queue)
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    
+    channel VARCHAR(20) NOT NULL DEFAULT 'email' CHECK (channel IN ('email')),
+    type VARCHAR(50) NOT NULL, -- e.g. abandoned_cart
+    recipient VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    claimed_until TIMESTAMP, -- Set while a dispatcher is sending it
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+-- Create index for the dispatcher
+CREATE INDEX idx_notifications_pending ON notifications(status, created_at);
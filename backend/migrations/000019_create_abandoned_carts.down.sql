@@ -0,0 +1,3 @@
+-- Drop abandoned cart tracking
+ALTER TABLE orders DROP COLUMN IF EXISTS abandoned_cart_id;
+DROP TABLE IF EXISTS abandoned_carts CASCADE;
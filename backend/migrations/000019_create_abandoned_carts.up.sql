@@ -0,0 +1,28 @@
+-- Create abandoned_carts table (one row per detected idle cart)
+CREATE TABLE abandoned_carts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    
+    -- Last cart activity when detected; a new activity creates a new row
+    last_activity_at TIMESTAMP NOT NULL,
+    
+    -- Snapshot of the cart lines: [{"product_variant_id": 1, "quantity": 2}]
+    items JSONB NOT NULL,
+    cart_total DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    
+    notification_id INTEGER REFERENCES notifications(id) ON DELETE SET NULL,
+    notified_at TIMESTAMP,
+    clicked_at TIMESTAMP,
+    recovered_order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    recovered_at TIMESTAMP,
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    UNIQUE(user_id, last_activity_at)
+);
+
+-- Create index for faster user lookups
+CREATE INDEX idx_abandoned_carts_user ON abandoned_carts(user_id);
+
+-- Orders placed after a recovery email point back to the abandoned cart
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS abandoned_cart_id INTEGER REFERENCES abandoned_carts(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+-- Drop coupons and order discounts
+ALTER TABLE orders DROP COLUMN IF EXISTS discount;
+DROP TABLE IF EXISTS order_discounts CASCADE;
+DROP TABLE IF EXISTS cart_coupons CASCADE;
+DROP TABLE IF EXISTS coupon_redemptions CASCADE;
+DROP TABLE IF EXISTS coupons CASCADE;
@@ -0,0 +1,70 @@
+-- Create coupons table
+CREATE TABLE coupons (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    
+    -- percentage: discount_value is a percent; fixed: an amount off
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value DECIMAL(10, 2) NOT NULL CHECK (discount_value > 0),
+    max_discount DECIMAL(10, 2),
+    min_subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    
+    -- Empty means no restriction; otherwise only matching lines are discounted
+    brand_ids INTEGER[] NOT NULL DEFAULT '{}',
+    category_ids INTEGER[] NOT NULL DEFAULT '{}',
+    
+    -- NULL means unlimited
+    usage_limit INTEGER,
+    per_user_limit INTEGER,
+    times_used INTEGER NOT NULL DEFAULT 0,
+    
+    starts_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create coupon_redemptions table (one row per order that used a coupon)
+CREATE TABLE coupon_redemptions (
+    id SERIAL PRIMARY KEY,
+    coupon_id INTEGER NOT NULL REFERENCES coupons(id) ON DELETE RESTRICT,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    guest_email VARCHAR(255),
+    discount_amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for per-user limit checks
+CREATE INDEX idx_coupon_redemptions_user ON coupon_redemptions(coupon_id, user_id);
+CREATE INDEX idx_coupon_redemptions_email ON coupon_redemptions(coupon_id, LOWER(guest_email));
+
+-- Coupon applied to a cart (one per user or guest cart)
+CREATE TABLE cart_coupons (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    guest_id VARCHAR(64) UNIQUE,
+    coupon_id INTEGER NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    CHECK (user_id IS NOT NULL OR guest_id IS NOT NULL)
+);
+
+-- Discount lines of an order (coupons and later promotions)
+CREATE TABLE order_discounts (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    code VARCHAR(50),
+    description TEXT,
+    amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for faster order lookups
+CREATE INDEX idx_order_discounts_order ON order_discounts(order_id);
+
+-- Total of all discount lines
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount DECIMAL(10, 2) NOT NULL DEFAULT 0.00;
@@ -0,0 +1,4 @@
+-- Drop promotions
+ALTER TABLE order_discounts DROP COLUMN IF EXISTS promotion_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS discount_amount;
+DROP TABLE IF EXISTS promotions CASCADE;
@@ -0,0 +1,41 @@
+-- Create promotions table (automatic discounts, no code needed)
+CREATE TABLE promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    
+    -- buy_x_get_y: buy_quantity paid + get_quantity at percent off (e.g. buy 2 get 1 free)
+    -- tiered: percent off from the highest tier reached by the eligible subtotal
+    -- bundle: percent off every complete set of bundle_groups
+    type VARCHAR(20) NOT NULL CHECK (type IN ('buy_x_get_y', 'tiered', 'bundle')),
+    
+    -- Which lines take part (empty = any brand / category)
+    brand_ids INTEGER[] NOT NULL DEFAULT '{}',
+    category_ids INTEGER[] NOT NULL DEFAULT '{}',
+    
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    get_quantity INTEGER NOT NULL DEFAULT 0,
+    percent DECIMAL(5, 2) NOT NULL DEFAULT 0.00,
+    
+    -- tiered: [{"min_subtotal": 1000, "percent": 10}]
+    tiers JSONB NOT NULL DEFAULT '[]',
+    -- bundle: [{"category_ids": [1], "brand_ids": [], "quantity": 1}, ...]
+    bundle_groups JSONB NOT NULL DEFAULT '[]',
+    
+    -- Lower priority runs first; later promotions see the already discounted prices
+    priority INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for loading active promotions
+CREATE INDEX idx_promotions_active ON promotions(is_active, priority);
+
+-- Share of all order discounts (promotions and coupon) allocated to each line, for pro-rated refunds
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0.00;
+
+-- Promotion discount lines point back to their promotion
+ALTER TABLE order_discounts ADD COLUMN IF NOT EXISTS promotion_id INTEGER REFERENCES promotions(id) ON DELETE SET NULL;
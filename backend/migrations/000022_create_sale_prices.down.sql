@@ -0,0 +1,3 @@
+-- Drop sale prices
+ALTER TABLE order_items DROP COLUMN IF EXISTS sale_price_id;
+DROP TABLE IF EXISTS sale_prices CASCADE;
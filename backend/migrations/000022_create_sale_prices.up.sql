@@ -0,0 +1,31 @@
+-- Create sale_prices table (scheduled sales and flash sales)
+CREATE TABLE sale_prices (
+    id SERIAL PRIMARY KEY,
+    
+    -- Product-level sales replace base_price (variant adjustments still apply);
+    -- variant-level sales set the variant's final price
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    product_variant_id INTEGER REFERENCES product_variants(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    
+    -- Flash sales: at most quantity_limit units are sold at this price (NULL = unlimited)
+    quantity_limit INTEGER CHECK (quantity_limit > 0),
+    quantity_sold INTEGER NOT NULL DEFAULT 0,
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    CHECK ((product_id IS NULL) <> (product_variant_id IS NULL)),
+    CHECK (ends_at > starts_at),
+    CHECK (quantity_limit IS NULL OR quantity_sold <= quantity_limit)
+);
+
+-- Create indexes for looking up active sales
+CREATE INDEX idx_sale_prices_product ON sale_prices(product_id, starts_at, ends_at);
+CREATE INDEX idx_sale_prices_variant ON sale_prices(product_variant_id, starts_at, ends_at);
+
+-- Order lines bought at a sale price point to the sale
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sale_price_id INTEGER REFERENCES sale_prices(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+-- Currency of all amounts on an order (ISO 4217)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'TRY';
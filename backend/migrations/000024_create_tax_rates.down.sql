@@ -0,0 +1,11 @@
+-- Drop tax rates and refunds
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_payment_status_check;
+UPDATE orders SET payment_status = 'paid' WHERE payment_status = 'partially_refunded';
+ALTER TABLE orders ADD CONSTRAINT orders_payment_status_check
+    CHECK (payment_status IN ('pending', 'paid', 'failed', 'refunded'));
+DROP TABLE IF EXISTS order_refund_items CASCADE;
+DROP TABLE IF EXISTS order_refunds CASCADE;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE orders DROP COLUMN IF EXISTS prices_include_tax;
+DROP TABLE IF EXISTS tax_rates CASCADE;
@@ -0,0 +1,55 @@
+-- Create tax_rates table (KDV / VAT by destination country and category)
+CREATE TABLE tax_rates (
+    id SERIAL PRIMARY KEY,
+    country VARCHAR(100) NOT NULL,
+    
+    -- NULL = the country's default rate; a category rate also covers its subcategories
+    category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+    rate DECIMAL(5, 2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    name VARCHAR(100) NOT NULL DEFAULT 'KDV',
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One rate per country and category
+CREATE UNIQUE INDEX idx_tax_rates_country_category ON tax_rates(LOWER(country), COALESCE(category_id, 0));
+
+-- General KDV rate in Turkey
+INSERT INTO tax_rates (country, category_id, rate, name) VALUES ('Turkey', NULL, 20.00, 'KDV');
+
+-- Whether catalog prices included tax when the order was placed
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS prices_include_tax BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Tax breakdown per order line
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_rate DECIMAL(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Create order_refunds table (partial and full refunds)
+CREATE TABLE order_refunds (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL,
+    tax DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    reason TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create order_refund_items table (refunded quantity of each order line)
+CREATE TABLE order_refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER NOT NULL REFERENCES order_refunds(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(10, 2) NOT NULL,
+    tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_order_refunds_order ON order_refunds(order_id);
+CREATE INDEX idx_order_refund_items_item ON order_refund_items(order_item_id);
+
+-- Partial refunds have their own payment status
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_payment_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_payment_status_check
+    CHECK (payment_status IN ('pending', 'paid', 'failed', 'partially_refunded', 'refunded'));
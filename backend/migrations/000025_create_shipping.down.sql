@@ -0,0 +1,5 @@
+-- Drop shipping methods and rates
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_method;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS weight_grams;
+DROP TABLE IF EXISTS shipping_rates CASCADE;
+DROP TABLE IF EXISTS shipping_methods CASCADE;
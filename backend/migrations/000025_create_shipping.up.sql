@@ -0,0 +1,61 @@
+-- Create shipping_methods table
+CREATE TABLE shipping_methods (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create shipping_rates table
+-- A method is available where it has a rate; the most specific rate wins
+-- (city, then country, then everywhere)
+CREATE TABLE shipping_rates (
+    id SERIAL PRIMARY KEY,
+    shipping_method_id INTEGER NOT NULL REFERENCES shipping_methods(id) ON DELETE CASCADE,
+    country VARCHAR(100),
+    city VARCHAR(100),
+    
+    -- price + per_item_price * items + per_kg_price * started kg
+    price DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    per_item_price DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (per_item_price >= 0),
+    per_kg_price DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (per_kg_price >= 0),
+    max_weight_grams INTEGER CHECK (max_weight_grams > 0),
+    
+    -- Free when the cart total after discounts reaches this amount (NULL = never)
+    free_shipping_threshold DECIMAL(10, 2) CHECK (free_shipping_threshold >= 0),
+    
+    min_days INTEGER NOT NULL DEFAULT 0,
+    max_days INTEGER NOT NULL DEFAULT 0,
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    CHECK (city IS NULL OR country IS NOT NULL)
+);
+
+CREATE INDEX idx_shipping_rates_method ON shipping_rates(shipping_method_id);
+
+-- Weight of a single unit, for weight-based rates
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS weight_grams INTEGER NOT NULL DEFAULT 0;
+
+-- Chosen method on the order
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_method VARCHAR(50);
+
+-- Default methods
+INSERT INTO shipping_methods (code, name, description, sort_order) VALUES
+    ('standard', 'Standard', 'Delivered in 2-4 business days', 1),
+    ('express', 'Express', 'Delivered the next business day', 2),
+    ('same_day', 'Same Day', 'Delivered today for orders before 14:00 (Istanbul only)', 3);
+
+INSERT INTO shipping_rates (shipping_method_id, country, city, price, free_shipping_threshold, min_days, max_days)
+SELECT id, 'Turkey', NULL, 49.90, 500.00, 2, 4 FROM shipping_methods WHERE code = 'standard';
+
+INSERT INTO shipping_rates (shipping_method_id, country, city, price, min_days, max_days)
+SELECT id, 'Turkey', NULL, 89.90, 1, 1 FROM shipping_methods WHERE code = 'express';
+
+INSERT INTO shipping_rates (shipping_method_id, country, city, price, min_days, max_days)
+SELECT id, 'Turkey', 'Istanbul', 129.90, 0, 0 FROM shipping_methods WHERE code = 'same_day';
@@ -0,0 +1,3 @@
+-- Drop exchange rates
+ALTER TABLE orders DROP COLUMN IF EXISTS exchange_rate;
+DROP TABLE IF EXISTS exchange_rates CASCADE;
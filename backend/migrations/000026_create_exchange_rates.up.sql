@@ -0,0 +1,11 @@
+-- Create exchange_rates table (units of each currency per one unit of the base currency)
+CREATE TABLE exchange_rates (
+    currency VARCHAR(3) PRIMARY KEY,
+    rate DECIMAL(18, 8) NOT NULL CHECK (rate > 0),
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rate used to convert the order from the base currency at checkout (1 = charged in the base currency)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS exchange_rate DECIMAL(18, 8) NOT NULL DEFAULT 1;
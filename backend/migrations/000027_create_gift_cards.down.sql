@@ -0,0 +1,8 @@
+-- Drop gift cards, store credit and split payments
+DROP TABLE IF EXISTS order_refund_payments CASCADE;
+ALTER TABLE order_refunds DROP COLUMN IF EXISTS method;
+DROP TABLE IF EXISTS order_payments CASCADE;
+DROP TABLE IF EXISTS store_credit_transactions CASCADE;
+DROP TABLE IF EXISTS store_credits CASCADE;
+DROP TABLE IF EXISTS gift_card_transactions CASCADE;
+DROP TABLE IF EXISTS gift_cards CASCADE;
@@ -0,0 +1,96 @@
+-- Create gift_cards table (purchased or admin-issued, amounts in the base currency)
+CREATE TABLE gift_cards (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(19) UNIQUE NOT NULL, -- XXXX-XXXX-XXXX-XXXX
+    initial_balance DECIMAL(10, 2) NOT NULL CHECK (initial_balance > 0),
+    balance DECIMAL(10, 2) NOT NULL CHECK (balance >= 0),
+    
+    recipient_email VARCHAR(255),
+    message TEXT,
+    
+    -- Who paid for it (purchased cards become active once paid) or issued it
+    purchaser_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    issued_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    payment_transaction_id VARCHAR(255),
+    
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP,
+    
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_gift_cards_purchaser ON gift_cards(purchaser_user_id);
+
+-- Create gift_card_transactions table (negative = spent, positive = loaded or given back)
+CREATE TABLE gift_card_transactions (
+    id SERIAL PRIMARY KEY,
+    gift_card_id INTEGER NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_gift_card_transactions_card ON gift_card_transactions(gift_card_id);
+
+-- Create store_credits table (customer wallet balance, in the base currency)
+CREATE TABLE store_credits (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    balance DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create store_credit_transactions table (wallet ledger)
+CREATE TABLE store_credit_transactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES order_refunds(id) ON DELETE SET NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_store_credit_transactions_user ON store_credit_transactions(user_id, created_at DESC);
+
+-- Create order_payments table (split tender: gift cards, store credit and the rest by card etc.)
+CREATE TABLE order_payments (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    method VARCHAR(50) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'captured', 'failed')),
+    gift_card_id INTEGER REFERENCES gift_cards(id) ON DELETE SET NULL,
+    transaction_id VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_payments_order ON order_payments(order_id);
+
+-- Existing orders become a single payment of their method
+INSERT INTO order_payments (order_id, method, amount, status, transaction_id, created_at)
+SELECT id, COALESCE(payment_method, 'unknown'), total,
+       CASE WHEN payment_status IN ('paid', 'partially_refunded', 'refunded') THEN 'captured' ELSE 'pending' END,
+       payment_transaction_id, created_at
+FROM orders;
+
+-- Refunds can go back to the original payment or to the customer's store credit
+ALTER TABLE order_refunds ADD COLUMN IF NOT EXISTS method VARCHAR(20) NOT NULL DEFAULT 'original';
+
+-- Create order_refund_payments table (what refunds and cancellation gave back through each payment)
+CREATE TABLE order_refund_payments (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER REFERENCES order_refunds(id) ON DELETE CASCADE, -- NULL when the order was cancelled
+    order_payment_id INTEGER NOT NULL REFERENCES order_payments(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    method VARCHAR(50) NOT NULL, -- Where it went: the payment's own method, or store_credit
+    -- Balances are given back at once; card payments wait for the payment provider
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'completed')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_refund_payments_refund ON order_refund_payments(refund_id);
+CREATE INDEX idx_order_refund_payments_payment ON order_refund_payments(order_payment_id);
@@ -0,0 +1,3 @@
+-- Drop loyalty points
+DROP TABLE IF EXISTS loyalty_transactions CASCADE;
+DROP TABLE IF EXISTS loyalty_accounts CASCADE;
@@ -0,0 +1,31 @@
+-- Create loyalty_accounts table (points balance per customer)
+CREATE TABLE loyalty_accounts (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    balance INTEGER NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    
+    -- Points expire after a period without earning or redeeming
+    last_activity_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_loyalty_accounts_activity ON loyalty_accounts(last_activity_at) WHERE balance > 0;
+
+-- Create loyalty_transactions table (points ledger)
+CREATE TABLE loyalty_transactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('earn', 'redeem', 'reverse', 'return', 'expire', 'adjust')),
+    points INTEGER NOT NULL, -- Negative when taken from the balance
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES order_refunds(id) ON DELETE SET NULL,
+    reason VARCHAR(255),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_loyalty_transactions_user ON loyalty_transactions(user_id, created_at DESC);
+CREATE INDEX idx_loyalty_transactions_order ON loyalty_transactions(order_id);
+
+-- An order earns points once
+CREATE UNIQUE INDEX idx_loyalty_transactions_earn ON loyalty_transactions(order_id) WHERE type = 'earn';
@@ -0,0 +1,3 @@
+-- Drop login attempt tracking
+DROP TABLE IF EXISTS login_failures CASCADE;
+DROP TABLE IF EXISTS login_throttles CASCADE;
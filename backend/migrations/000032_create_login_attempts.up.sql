@@ -0,0 +1,23 @@
+-- Create login_throttles table (consecutive failed logins per account email and per IP)
+CREATE TABLE login_throttles (
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('account', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+-- Create login_failures table (audit trail of failed logins)
+CREATE TABLE login_failures (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('unknown_email', 'wrong_password', 'throttled')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_login_failures_email ON login_failures(email, created_at);
+CREATE INDEX idx_login_failures_ip ON login_failures(ip_address, created_at);
@@ -0,0 +1,11 @@
+-- Drop two-factor authentication
+DELETE FROM login_failures WHERE reason = 'wrong_code';
+ALTER TABLE login_failures DROP CONSTRAINT login_failures_reason_check;
+ALTER TABLE login_failures ADD CONSTRAINT login_failures_reason_check
+    CHECK (reason IN ('unknown_email', 'wrong_password', 'throttled'));
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS two_factor;
+DROP TABLE IF EXISTS recovery_codes CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
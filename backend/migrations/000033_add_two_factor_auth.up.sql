@@ -0,0 +1,24 @@
+-- TOTP two-factor authentication. The secret is set at enrolment and only
+-- required at login once enabled; totp_last_step stops a code being used twice.
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT;
+
+-- Create recovery_codes table (one-time codes for a lost authenticator; only hashes are stored)
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_recovery_codes_user ON recovery_codes(user_id);
+
+-- Sessions record whether the login passed a second factor (required for admin routes)
+ALTER TABLE sessions ADD COLUMN two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Wrong two-factor codes are audited like wrong passwords
+ALTER TABLE login_failures DROP CONSTRAINT login_failures_reason_check;
+ALTER TABLE login_failures ADD CONSTRAINT login_failures_reason_check
+    CHECK (reason IN ('unknown_email', 'wrong_password', 'wrong_code', 'throttled'));
@@ -0,0 +1,56 @@
+-- Create permissions table (fixed set; checked per admin route)
+CREATE TABLE permissions (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('stats:read', 'View dashboard statistics'),
+    ('orders:read', 'View all orders'),
+    ('orders:write', 'Update order statuses'),
+    ('orders:refund', 'Refund orders'),
+    ('catalog:read', 'Export the catalog and view the stock audit log'),
+    ('catalog:write', 'Enable/disable products, import the catalog and sync stock'),
+    ('customers:read', 'View customers and their loyalty points'),
+    ('customers:write', 'Adjust store credit and loyalty points, end customer sessions'),
+    ('marketing:read', 'View coupons, promotions, sales and abandoned cart stats'),
+    ('marketing:write', 'Manage coupons, promotions and sales'),
+    ('settings:read', 'View tax rates, shipping methods and exchange rates'),
+    ('settings:write', 'Manage tax rates, shipping methods and exchange rates'),
+    ('gift_cards:read', 'View gift cards'),
+    ('gift_cards:write', 'Issue and update gift cards'),
+    ('security:read', 'View failed logins'),
+    ('roles:read', 'View roles and permissions'),
+    ('roles:write', 'Manage roles and assign them to users');
+
+-- Create roles table (users.role names one; built-in roles can't be changed)
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(20) UNIQUE NOT NULL,
+    description TEXT,
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+INSERT INTO roles (name, description, built_in) VALUES
+    ('customer', 'Shop customers; no admin access', TRUE),
+    ('admin', 'Full admin access', TRUE),
+    ('warehouse', 'Fulfils orders: views orders and updates their status', FALSE);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'warehouse' AND p.name IN ('orders:read', 'orders:write');
+
+-- users.role now names a row in roles instead of a fixed list
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name);
@@ -0,0 +1,9 @@
+-- Drop API keys
+DELETE FROM permissions WHERE name IN ('api_keys:read', 'api_keys:write');
+ALTER TABLE loyalty_transactions DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE store_credit_transactions DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE gift_cards DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE order_refunds DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE stock_audit_log DROP COLUMN IF EXISTS api_key_id;
+DROP TABLE IF EXISTS api_key_requests CASCADE;
+DROP TABLE IF EXISTS api_keys CASCADE;
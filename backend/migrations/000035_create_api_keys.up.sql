@@ -0,0 +1,43 @@
+-- Create api_keys table (keys for server-to-server integrations; only a hash is stored)
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(12) NOT NULL, -- Start of the key, so it can be recognised
+    key_hash VARCHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- Permission names the key may use
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(45),
+    revoked_at TIMESTAMP,
+    revoked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create api_key_requests table (audit trail of every request made with a key)
+CREATE TABLE api_key_requests (
+    id BIGSERIAL PRIMARY KEY,
+    api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    status INTEGER NOT NULL,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_api_key_requests_key ON api_key_requests(api_key_id, created_at);
+
+-- Stock changes, refunds, gift cards and balance adjustments made through a key name it
+ALTER TABLE stock_audit_log ADD COLUMN api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL;
+ALTER TABLE order_refunds ADD COLUMN api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL;
+ALTER TABLE gift_cards ADD COLUMN api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL;
+ALTER TABLE store_credit_transactions ADD COLUMN api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL;
+ALTER TABLE loyalty_transactions ADD COLUMN api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('api_keys:read', 'View API keys and their requests'),
+    ('api_keys:write', 'Create and revoke API keys');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, p.name FROM roles CROSS JOIN (VALUES ('api_keys:read'), ('api_keys:write')) AS p(name)
+WHERE roles.name = 'admin';
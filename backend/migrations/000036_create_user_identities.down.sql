@@ -0,0 +1,6 @@
+-- Drop user_identities table
+DROP TABLE IF EXISTS user_identities CASCADE;
+
+-- An empty hash never matches, so passwordless users stay locked out of password login
+UPDATE users SET password_hash = '' WHERE password_hash IS NULL;
+ALTER TABLE users ALTER COLUMN password_hash SET NOT NULL;
@@ -0,0 +1,16 @@
+-- Users who sign up through a social sign-in provider have no password
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+
+-- Create user_identities table (OpenID Connect accounts linked to users)
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL, -- Name from OIDC_PROVIDERS, e.g. 'google'
+    subject VARCHAR(255) NOT NULL, -- The provider's stable user ID ("sub" claim)
+    email VARCHAR(255), -- Email the provider last reported
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
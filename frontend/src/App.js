@@ -9,6 +9,7 @@ import ProductList from './pages/ProductList';
 import ProductDetail from './pages/ProductDetail';
 import Login from './pages/Login';
 import Register from './pages/Register';
+import OIDCCallback from './pages/OIDCCallback';
 import Cart from './pages/Cart';
 import CheckoutWithPayment from './pages/CheckoutWithPayment';
 import OrderConfirmation from './pages/OrderConfirmation';
@@ -32,6 +33,7 @@ function App() {
                 <Route path="/products/:id" element={<ProductDetail />} />
                 <Route path="/login" element={<Login />} />
                 <Route path="/register" element={<Register />} />
+                <Route path="/oidc/callback/:provider" element={<OIDCCallback />} />
                 <Route path="/cart" element={<Cart />} />
                 <Route path="/checkout" element={<CheckoutWithPayment />} />
                 <Route path="/order-confirmation/:id" element={<OrderConfirmation />} />
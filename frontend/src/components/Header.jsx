@@ -9,6 +9,15 @@ const Header = () => {
   const { cart } = useCart();
   const [activeDropdown, setActiveDropdown] = useState(null);
 
+  // Staff land on the first admin page their role can see
+  const adminPages = [
+    ['stats:read', '/admin'],
+    ['orders:read', '/admin/orders'],
+    ['catalog:write', '/admin/products'],
+    ['customers:read', '/admin/customers'],
+  ];
+  const adminPage = adminPages.find(([permission]) => user?.permissions?.includes(permission));
+
   const categories = {
     women: [
       { id: 4, name: "Dresses" },
@@ -141,8 +150,8 @@ const Header = () => {
                     <span className="ml-2 text-xs">({cart.total_items})</span>
                   )}
                 </Link>
-                {user.role === 'admin' && (
-                  <Link to="/admin" className="text-sm uppercase tracking-wider hover:opacity-50 transition">
+                {adminPage && (
+                  <Link to={adminPage[1]} className="text-sm uppercase tracking-wider hover:opacity-50 transition">
                     Admin
                   </Link>
                 )}
@@ -25,6 +25,7 @@ export const AuthProvider = ({ children }) => {
         })
         .catch(() => {
           localStorage.removeItem('token');
+          localStorage.removeItem('refreshToken');
         })
         .finally(() => {
           setLoading(false);
@@ -34,29 +35,66 @@ export const AuthProvider = ({ children }) => {
     }
   }, []);
 
-  const login = async (email, password) => {
-    const response = await authAPI.login({ email, password });
-    const { token, user } = response.data.data;
+  const startSession = ({ token, refresh_token, user }) => {
     localStorage.setItem('token', token);
+    localStorage.setItem('refreshToken', refresh_token);
     setUser(user);
     return user;
   };
 
+  // Resolves to the user, or to { twoFactorRequired, preAuthToken } when a
+  // two-factor code is needed to finish with verifyTwoFactor
+  const login = async (email, password) => {
+    const response = await authAPI.login({ email, password });
+    const data = response.data.data;
+    if (data.two_factor_required) {
+      return { twoFactorRequired: true, preAuthToken: data.pre_auth_token };
+    }
+    return startSession(data);
+  };
+
+  // Social sign-in: the browser leaves for the provider, which sends it back to
+  // /oidc/callback/:provider where completeOIDCLogin finishes like login
+  const startOIDCLogin = async (provider) => {
+    const response = await authAPI.startOIDCLogin(provider);
+    const { authorization_url, login_token } = response.data.data;
+    sessionStorage.setItem('oidcLoginToken', login_token);
+    window.location.assign(authorization_url);
+  };
+
+  const completeOIDCLogin = async (provider, code, state) => {
+    const loginToken = sessionStorage.getItem('oidcLoginToken');
+    sessionStorage.removeItem('oidcLoginToken');
+    const response = await authAPI.oidcLogin(provider, { code, state, login_token: loginToken });
+    const data = response.data.data;
+    if (data.two_factor_required) {
+      return { twoFactorRequired: true, preAuthToken: data.pre_auth_token };
+    }
+    return startSession(data);
+  };
+
+  const verifyTwoFactor = async (preAuthToken, code) => {
+    const response = await authAPI.verifyTwoFactor(preAuthToken, code);
+    return startSession(response.data.data);
+  };
+
   const register = async (userData) => {
     const response = await authAPI.register(userData);
-    const { token, user } = response.data.data;
-    localStorage.setItem('token', token);
-    setUser(user);
-    return user;
+    return startSession(response.data.data);
   };
 
   const logout = () => {
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (refreshToken) {
+      authAPI.logout(refreshToken).catch(() => {});
+    }
     localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
     setUser(null);
   };
 
   return (
-    <AuthContext.Provider value={{ user, login, register, logout, loading }}>
+    <AuthContext.Provider value={{ user, login, verifyTwoFactor, startOIDCLogin, completeOIDCLogin, register, logout, loading }}>
       {children}
     </AuthContext.Provider>
   );
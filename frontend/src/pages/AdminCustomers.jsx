@@ -12,8 +12,8 @@ const AdminCustomers = () => {
   const [loading, setLoading] = useState(true);
 
   useEffect(() => {
-    // Redirect if not admin
-    if (user && user.role !== 'admin') {
+    // Redirect if the role lacks the permission
+    if (user && !user.permissions?.includes('customers:read')) {
       navigate('/');
       return;
     }
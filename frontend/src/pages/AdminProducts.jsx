@@ -10,8 +10,8 @@ const AdminProducts = () => {
   const [loading, setLoading] = useState(true);
 
   useEffect(() => {
-    // Redirect if not admin
-    if (user && user.role !== 'admin') {
+    // Redirect if the role lacks the permission
+    if (user && !user.permissions?.includes('catalog:write')) {
       navigate('/');
       return;
     }
@@ -1,22 +1,53 @@
-import React, { useState } from 'react';
-import { Link, useNavigate } from 'react-router-dom';
+import React, { useEffect, useState } from 'react';
+import { Link, useLocation, useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { authAPI } from '../services/api';
 
 const Login = () => {
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
+  const location = useLocation();
+  // Social sign-in of a two-factor account lands here for the code
+  const [preAuthToken, setPreAuthToken] = useState(location.state?.preAuthToken || '');
+  const [code, setCode] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
-  const { login } = useAuth();
+  const [providers, setProviders] = useState([]);
+  const { login, verifyTwoFactor, startOIDCLogin } = useAuth();
   const navigate = useNavigate();
 
+  useEffect(() => {
+    authAPI.getOIDCProviders()
+      .then((response) => setProviders(response.data.data || []))
+      .catch(() => {});
+  }, []);
+
+  const handleProvider = async (provider) => {
+    setError('');
+    setLoading(true);
+    try {
+      await startOIDCLogin(provider);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Sign-in failed');
+      setLoading(false);
+    }
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     setError('');
     setLoading(true);
 
     try {
-      await login(email, password);
+      if (preAuthToken) {
+        await verifyTwoFactor(preAuthToken, code);
+      } else {
+        const result = await login(email, password);
+        if (result.twoFactorRequired) {
+          setPreAuthToken(result.preAuthToken);
+          return;
+        }
+      }
       navigate('/');
     } catch (err) {
       setError(err.response?.data?.error || 'Login failed');
@@ -37,6 +68,22 @@ const Login = () => {
         )}
 
         <form onSubmit={handleSubmit} className="space-y-6">
+          {preAuthToken ? (
+          <div>
+            <label className="block text-xs uppercase tracking-wider mb-2">Authentication code</label>
+            <input
+              type="text"
+              autoComplete="one-time-code"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              className="input-field"
+              placeholder="Code from your app, or a recovery code"
+              required
+              autoFocus
+            />
+          </div>
+          ) : (
+          <>
           <div>
             <label className="block text-xs uppercase tracking-wider mb-2">Email</label>
             <input
@@ -58,16 +105,34 @@ const Login = () => {
               required
             />
           </div>
+          </>
+          )}
 
           <button 
             type="submit" 
             disabled={loading}
             className="btn-primary w-full disabled:opacity-50"
           >
-            {loading ? 'Logging in...' : 'Login'}
+            {loading ? 'Logging in...' : preAuthToken ? 'Verify' : 'Login'}
           </button>
         </form>
 
+        {!preAuthToken && providers.length > 0 && (
+          <div className="mt-6 space-y-3">
+            {providers.map((provider) => (
+              <button
+                key={provider}
+                type="button"
+                disabled={loading}
+                onClick={() => handleProvider(provider)}
+                className="btn-secondary w-full disabled:opacity-50"
+              >
+                Continue with {provider.charAt(0).toUpperCase() + provider.slice(1)}
+              </button>
+            ))}
+          </div>
+        )}
+
         <div className="mt-8 text-center">
           <p className="text-sm">
             Don't have an account?{' '}
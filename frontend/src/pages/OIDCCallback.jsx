@@ -0,0 +1,57 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Link, useNavigate, useParams, useSearchParams } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+// Where a social sign-in provider sends the browser back to
+const OIDCCallback = () => {
+  const { provider } = useParams();
+  const [searchParams] = useSearchParams();
+  const [error, setError] = useState('');
+  const { completeOIDCLogin } = useAuth();
+  const navigate = useNavigate();
+  const started = useRef(false);
+
+  useEffect(() => {
+    // The code works once; don't send it twice
+    if (started.current) return;
+    started.current = true;
+
+    const code = searchParams.get('code');
+    const state = searchParams.get('state');
+    if (searchParams.get('error') || !code || !state) {
+      setError('Sign-in was cancelled or failed');
+      return;
+    }
+
+    completeOIDCLogin(provider, code, state)
+      .then((result) => {
+        if (result.twoFactorRequired) {
+          navigate('/login', { replace: true, state: { preAuthToken: result.preAuthToken } });
+        } else {
+          navigate('/', { replace: true });
+        }
+      })
+      .catch((err) => setError(err.response?.data?.error || 'Sign-in failed'));
+  }, [provider, searchParams, completeOIDCLogin, navigate]);
+
+  return (
+    <div className="min-h-screen flex items-center justify-center px-8 py-12">
+      <div className="w-full max-w-md text-center">
+        {error ? (
+          <>
+            <div className="border border-black bg-gray-100 p-4 mb-6">
+              <p className="text-sm">{error}</p>
+            </div>
+            <Link to="/login" className="underline hover:no-underline text-sm">
+              Back to login
+            </Link>
+          </>
+        ) : (
+          <p className="text-sm uppercase tracking-wider">Signing in...</p>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default OIDCCallback;
@@ -16,13 +16,71 @@ api.interceptors.request.use((config) => {
   if (token) {
     config.headers.Authorization = `Bearer ${token}`;
   }
+  // Guest cart token (anonymous carts, merged into the account on login)
+  const cartToken = localStorage.getItem('cartToken');
+  if (cartToken) {
+    config.headers['X-Cart-Token'] = cartToken;
+  }
   return config;
 });
 
+// Remember the guest cart token returned by cart endpoints
+api.interceptors.response.use((response) => {
+  const cartToken = response.headers['x-cart-token'];
+  if (cartToken) {
+    localStorage.setItem('cartToken', cartToken);
+  }
+  // Guest cart was merged into the account (not yet if a two-factor code is still needed)
+  const loggedIn = ['/auth/login', '/auth/register', '/auth/2fa/verify'].includes(response.config.url)
+    || /^\/auth\/oidc\/[^/]+\/callback$/.test(response.config.url);
+  if (loggedIn && response.data?.data?.token) {
+    localStorage.removeItem('cartToken');
+  }
+  return response;
+}, async (error) => {
+  // Access tokens are short-lived: renew with the refresh token once and retry
+  const { config, response } = error;
+  const refreshToken = localStorage.getItem('refreshToken');
+  if (response?.status !== 401 || !refreshToken || config._retried || config.url.startsWith('/auth/')) {
+    return Promise.reject(error);
+  }
+  config._retried = true;
+
+  try {
+    const { data } = await refreshSession(refreshToken);
+    localStorage.setItem('token', data.data.token);
+    localStorage.setItem('refreshToken', data.data.refresh_token);
+  } catch (refreshError) {
+    localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
+    return Promise.reject(error);
+  }
+  return api(config);
+});
+
+// Concurrent 401s share one refresh, since each refresh token works only once
+let refreshing = null;
+const refreshSession = (refreshToken) => {
+  if (!refreshing) {
+    refreshing = api.post('/auth/refresh', { refresh_token: refreshToken })
+      .finally(() => { refreshing = null; });
+  }
+  return refreshing;
+};
+
 // Auth API
 export const authAPI = {
   register: (data) => api.post('/auth/register', data),
   login: (data) => api.post('/auth/login', data),
+  verifyTwoFactor: (preAuthToken, code) => api.post('/auth/2fa/verify', { pre_auth_token: preAuthToken, code }),
+  getOIDCProviders: () => api.get('/auth/oidc/providers'),
+  startOIDCLogin: (provider) => api.post(`/auth/oidc/${provider}/start`),
+  oidcLogin: (provider, data) => api.post(`/auth/oidc/${provider}/callback`, data),
+  forgotPassword: (email) => api.post('/auth/forgot-password', { email }),
+  resetPassword: (token, password) => api.post('/auth/reset-password', { token, password }),
+  verifyEmail: (token) => api.post('/auth/verify-email', { token }),
+  resendVerification: () => api.post('/auth/resend-verification'),
+  logout: (refreshToken) => api.post('/auth/logout', { refresh_token: refreshToken }),
   getCurrentUser: () => api.get('/auth/me'),
 };
 